@@ -0,0 +1,138 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// executeAlarmStatusQuery returns the current state of the metric and composite alarms of the account as a table
+func (e *cloudWatchExecutor) executeAlarmStatusQuery(pluginCtx backend.PluginContext, model DataQueryJson, query backend.DataQuery) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+
+	params := &cloudwatch.DescribeAlarmsInput{
+		MaxRecords: aws.Int64(100),
+		AlarmTypes: alarmTypes,
+	}
+	if model.StateValue != "" {
+		if !isValidStateValue(model.StateValue) {
+			return result, fmt.Errorf("invalid alarm status query: unknown state %q", model.StateValue)
+		}
+		params.StateValue = aws.String(model.StateValue)
+	}
+	if model.AlarmNamePrefix != "" {
+		params.AlarmNamePrefix = aws.String(model.AlarmNamePrefix)
+	}
+	if model.ActionPrefix != "" {
+		params.ActionPrefix = aws.String(model.ActionPrefix)
+	}
+
+	cli, err := e.getCWClient(pluginCtx, model.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := newAlarmStatusFrame(query.RefID)
+	err = cli.DescribeAlarmsPages(params, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+		for _, alarm := range page.MetricAlarms {
+			frame.AppendRow(
+				aws.StringValue(alarm.AlarmName),
+				cloudwatch.AlarmTypeMetricAlarm,
+				aws.StringValue(alarm.StateValue),
+				aws.StringValue(alarm.StateReason),
+				alarm.Threshold,
+				aws.StringValue(alarm.ComparisonOperator),
+				alarmMetric(alarm),
+				formatAlarmDimensions(alarm.Dimensions),
+				alarm.StateUpdatedTimestamp,
+			)
+		}
+		for _, alarm := range page.CompositeAlarms {
+			frame.AppendRow(
+				aws.StringValue(alarm.AlarmName),
+				cloudwatch.AlarmTypeCompositeAlarm,
+				aws.StringValue(alarm.StateValue),
+				aws.StringValue(alarm.StateReason),
+				(*float64)(nil),
+				"",
+				"",
+				"",
+				alarm.StateUpdatedTimestamp,
+			)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarms", err)
+	}
+
+	rowCount, _ := frame.RowLen()
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTable,
+		Custom: map[string]interface{}{
+			"rowCount": rowCount,
+		},
+	}
+
+	respD := result.Responses[query.RefID]
+	respD.Frames = append(respD.Frames, frame)
+	result.Responses[query.RefID] = respD
+
+	return result, nil
+}
+
+func newAlarmStatusFrame(refID string) *data.Frame {
+	return data.NewFrame(refID,
+		data.NewField("name", nil, []string{}),
+		data.NewField("type", nil, []string{}),
+		data.NewField("state", nil, []string{}),
+		data.NewField("stateReason", nil, []string{}),
+		data.NewField("threshold", nil, []*float64{}),
+		data.NewField("comparisonOperator", nil, []string{}),
+		data.NewField("metric", nil, []string{}),
+		data.NewField("dimensions", nil, []string{}),
+		data.NewField("lastStateChange", nil, []*time.Time{}),
+	)
+}
+
+// alarmMetric returns the namespace and name of the metric an alarm watches. Alarms on metric math expressions
+// list the metrics of the expression.
+func alarmMetric(alarm *cloudwatch.MetricAlarm) string {
+	if alarm.MetricName != nil {
+		return aws.StringValue(alarm.Namespace) + "/" + aws.StringValue(alarm.MetricName)
+	}
+	metrics := make([]string, 0, len(alarm.Metrics))
+	for _, m := range alarm.Metrics {
+		switch {
+		case m.MetricStat != nil && m.MetricStat.Metric != nil:
+			metrics = append(metrics, aws.StringValue(m.MetricStat.Metric.Namespace)+"/"+aws.StringValue(m.MetricStat.Metric.MetricName))
+		case m.Expression != nil:
+			metrics = append(metrics, aws.StringValue(m.Expression))
+		}
+	}
+	return strings.Join(metrics, ", ")
+}
+
+func formatAlarmDimensions(dimensions []*cloudwatch.Dimension) string {
+	formatted := make([]string, 0, len(dimensions))
+	for _, d := range dimensions {
+		formatted = append(formatted, aws.StringValue(d.Name)+"="+aws.StringValue(d.Value))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ", ")
+}
+
+func isValidStateValue(stateValue string) bool {
+	for _, s := range cloudwatch.StateValue_Values() {
+		if s == stateValue {
+			return true
+		}
+	}
+	return false
+}
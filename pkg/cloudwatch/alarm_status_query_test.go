@@ -0,0 +1,88 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteAlarmStatusQuery(t *testing.T) {
+	updated := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	newClient := func() *fakeCWClient {
+		cpu := metricAlarm("cpu", map[string]string{"InstanceId": "i-1", "AutoScalingGroupName": "web"})
+		cpu.StateValue = aws.String(cloudwatch.StateValueAlarm)
+		cpu.StateReason = aws.String("Threshold Crossed")
+		cpu.Threshold = aws.Float64(80)
+		cpu.ComparisonOperator = aws.String(cloudwatch.ComparisonOperatorGreaterThanThreshold)
+		cpu.StateUpdatedTimestamp = aws.Time(updated)
+		math := &cloudwatch.MetricAlarm{
+			AlarmName:  aws.String("errors-rate"),
+			StateValue: aws.String(cloudwatch.StateValueOk),
+			Metrics: []*cloudwatch.MetricDataQuery{
+				{MetricStat: &cloudwatch.MetricStat{Metric: &cloudwatch.Metric{Namespace: aws.String("AWS/ApplicationELB"), MetricName: aws.String("HTTPCode_Target_5XX_Count")}}},
+				{Expression: aws.String("m1/m2")},
+			},
+		}
+		return &fakeCWClient{
+			metricAlarms:    []*cloudwatch.MetricAlarm{cpu, math},
+			compositeAlarms: []*cloudwatch.CompositeAlarm{{AlarmName: aws.String("service"), StateValue: aws.String(cloudwatch.StateValueInsufficientData), StateUpdatedTimestamp: aws.Time(updated)}},
+		}
+	}
+	query := backend.DataQuery{RefID: "A"}
+
+	t.Run("returns a row per alarm", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		res, err := newTestExecutor().executeAlarmStatusQuery(backend.PluginContext{}, DataQueryJson{Region: "us-east-1"}, query)
+		require.NoError(t, err)
+		frame := res.Responses["A"].Frames[0]
+		names := []string{}
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		assert.Equal(t, []string{"name", "type", "state", "stateReason", "threshold", "comparisonOperator", "metric", "dimensions", "lastStateChange"}, names)
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+		assert.Equal(t, 3, frame.Meta.Custom.(map[string]interface{})["rowCount"])
+
+		row := func(i int) []interface{} {
+			values := []interface{}{}
+			for _, field := range frame.Fields {
+				values = append(values, field.At(i))
+			}
+			return values
+		}
+		assert.Equal(t, []interface{}{"cpu", cloudwatch.AlarmTypeMetricAlarm, cloudwatch.StateValueAlarm, "Threshold Crossed", aws.Float64(80),
+			cloudwatch.ComparisonOperatorGreaterThanThreshold, "AWS/EC2/CPUUtilization", "AutoScalingGroupName=web, InstanceId=i-1", &updated}, row(0))
+		assert.Equal(t, "AWS/ApplicationELB/HTTPCode_Target_5XX_Count, m1/m2", row(1)[6])
+		assert.Equal(t, []interface{}{"service", cloudwatch.AlarmTypeCompositeAlarm, cloudwatch.StateValueInsufficientData, "", (*float64)(nil), "", "", "", &updated}, row(2))
+	})
+	t.Run("passes the state and name filters on", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		_, err := newTestExecutor().executeAlarmStatusQuery(backend.PluginContext{}, DataQueryJson{
+			Region: "us-east-1", StateValue: cloudwatch.StateValueAlarm, AlarmNamePrefix: "cpu", ActionPrefix: "arn:aws:sns",
+		}, query)
+		require.NoError(t, err)
+		require.Len(t, client.describeInputs, 1)
+		input := client.describeInputs[0]
+		assert.Equal(t, cloudwatch.StateValueAlarm, aws.StringValue(input.StateValue))
+		assert.Equal(t, "cpu", aws.StringValue(input.AlarmNamePrefix))
+		assert.Equal(t, "arn:aws:sns", aws.StringValue(input.ActionPrefix))
+		assert.Equal(t, []string{cloudwatch.AlarmTypeMetricAlarm, cloudwatch.AlarmTypeCompositeAlarm}, aws.StringValueSlice(input.AlarmTypes))
+	})
+	t.Run("rejects unknown states", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		_, err := newTestExecutor().executeAlarmStatusQuery(backend.PluginContext{}, DataQueryJson{StateValue: "FIRING"}, query)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown state "FIRING"`)
+		assert.Empty(t, client.describeInputs)
+	})
+}
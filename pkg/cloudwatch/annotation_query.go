@@ -1,9 +1,12 @@
 package cloudwatch
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,12 +16,26 @@ import (
 )
 
 type annotationEvent struct {
-	Title string
-	Time  time.Time
-	Tags  string
-	Text  string
+	Title    string
+	Time     time.Time
+	Tags     string
+	Text     string
+	OldState string
+	NewState string
 }
 
+// alarmHistoryData is the part of an alarm history item's HistoryData which describes a state update
+type alarmHistoryData struct {
+	OldState struct {
+		StateValue string `json:"stateValue"`
+	} `json:"oldState"`
+	NewState struct {
+		StateValue string `json:"stateValue"`
+	} `json:"newState"`
+}
+
+var alarmTypes = aws.StringSlice([]string{cloudwatch.AlarmTypeMetricAlarm, cloudwatch.AlarmTypeCompositeAlarm})
+
 func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginContext, model DataQueryJson, query backend.DataQuery) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
 	statistic := ""
@@ -40,6 +57,10 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 		period = 300
 	}
 
+	if model.HistoryItemType != "" && !isValidHistoryItemType(model.HistoryItemType) {
+		return result, fmt.Errorf("invalid annotations query: unknown history item type %q", model.HistoryItemType)
+	}
+
 	actionPrefix := model.ActionPrefix
 	alarmNamePrefix := model.AlarmNamePrefix
 
@@ -49,39 +70,41 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 	}
 
 	var alarmNames []*string
-	if model.PrefixMatching {
+	exactDimensions, isExact := exactAlarmDimensions(model.Dimensions)
+	if model.PrefixMatching || !isExact {
+		// the alarms of the metric are filtered here when DescribeAlarmsForMetric can't match the dimensions
+		if !model.PrefixMatching && (model.Region == "" || model.Namespace == "" || model.MetricName == "" || statistic == "") {
+			return result, errors.New("invalid annotations query")
+		}
 		params := &cloudwatch.DescribeAlarmsInput{
-			MaxRecords:      aws.Int64(100),
-			ActionPrefix:    aws.String(actionPrefix),
-			AlarmNamePrefix: aws.String(alarmNamePrefix),
+			MaxRecords: aws.Int64(100),
+			AlarmTypes: alarmTypes,
+		}
+		if model.PrefixMatching && actionPrefix != "" {
+			params.ActionPrefix = aws.String(actionPrefix)
 		}
-		resp, err := cli.DescribeAlarms(params)
+		if model.PrefixMatching && alarmNamePrefix != "" {
+			params.AlarmNamePrefix = aws.String(alarmNamePrefix)
+		}
+		alarms := &cloudwatch.DescribeAlarmsOutput{}
+		err := cli.DescribeAlarmsPages(params, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+			alarms.MetricAlarms = append(alarms.MetricAlarms, page.MetricAlarms...)
+			alarms.CompositeAlarms = append(alarms.CompositeAlarms, page.CompositeAlarms...)
+			return !lastPage
+		})
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarms", err)
 		}
-		alarmNames = filterAlarms(resp, model.Namespace, model.MetricName, model.Dimensions, statistic, period)
+		alarmNames = filterAlarms(alarms, model.Namespace, model.MetricName, model.Dimensions, statistic, period)
 	} else {
 		if model.Region == "" || model.Namespace == "" || model.MetricName == "" || statistic == "" {
 			return result, errors.New("invalid annotations query")
 		}
 
-		var qd []*cloudwatch.Dimension
-		for k, v := range model.Dimensions {
-			if vv, ok := v.([]interface{}); ok {
-				for _, vvv := range vv {
-					if vvvv, ok := vvv.(string); ok {
-						qd = append(qd, &cloudwatch.Dimension{
-							Name:  aws.String(k),
-							Value: aws.String(vvvv),
-						})
-					}
-				}
-			}
-		}
 		params := &cloudwatch.DescribeAlarmsForMetricInput{
 			Namespace:  aws.String(model.Namespace),
 			MetricName: aws.String(model.MetricName),
-			Dimensions: qd,
+			Dimensions: exactDimensions,
 			Statistic:  aws.String(statistic),
 			Period:     aws.Int64(period),
 		}
@@ -98,22 +121,23 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 	for _, alarmName := range alarmNames {
 		params := &cloudwatch.DescribeAlarmHistoryInput{
 			AlarmName:  alarmName,
+			AlarmTypes: alarmTypes,
 			StartDate:  aws.Time(query.TimeRange.From),
 			EndDate:    aws.Time(query.TimeRange.To),
 			MaxRecords: aws.Int64(100),
 		}
-		resp, err := cli.DescribeAlarmHistory(params)
+		if model.HistoryItemType != "" {
+			params.HistoryItemType = aws.String(model.HistoryItemType)
+		}
+		err := cli.DescribeAlarmHistoryPages(params, func(page *cloudwatch.DescribeAlarmHistoryOutput, lastPage bool) bool {
+			for _, history := range page.AlarmHistoryItems {
+				annotations = append(annotations, newAnnotationEvent(history))
+			}
+			return !lastPage
+		})
 		if err != nil {
 			return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarmHistory", err)
 		}
-		for _, history := range resp.AlarmHistoryItems {
-			annotations = append(annotations, &annotationEvent{
-				Time:  *history.Timestamp,
-				Title: *history.AlarmName,
-				Tags:  *history.HistoryItemType,
-				Text:  *history.HistorySummary,
-			})
-		}
 	}
 
 	respD := result.Responses[query.RefID]
@@ -123,16 +147,44 @@ func (e *cloudWatchExecutor) executeAnnotationQuery(pluginCtx backend.PluginCont
 	return result, err
 }
 
+func newAnnotationEvent(history *cloudwatch.AlarmHistoryItem) *annotationEvent {
+	event := &annotationEvent{
+		Time:  aws.TimeValue(history.Timestamp),
+		Title: aws.StringValue(history.AlarmName),
+		Tags:  aws.StringValue(history.HistoryItemType),
+		Text:  aws.StringValue(history.HistorySummary),
+	}
+	if aws.StringValue(history.HistoryItemType) == cloudwatch.HistoryItemTypeStateUpdate && history.HistoryData != nil {
+		var historyData alarmHistoryData
+		if err := json.Unmarshal([]byte(*history.HistoryData), &historyData); err == nil {
+			event.OldState = historyData.OldState.StateValue
+			event.NewState = historyData.NewState.StateValue
+		}
+	}
+	return event
+}
+
+func isValidHistoryItemType(historyItemType string) bool {
+	for _, t := range cloudwatch.HistoryItemType_Values() {
+		if t == historyItemType {
+			return true
+		}
+	}
+	return false
+}
+
 func transformAnnotationToTable(annotations []*annotationEvent, query backend.DataQuery) *data.Frame {
 	frame := data.NewFrame(query.RefID,
 		data.NewField("time", nil, []time.Time{}),
 		data.NewField("title", nil, []string{}),
 		data.NewField("tags", nil, []string{}),
 		data.NewField("text", nil, []string{}),
+		data.NewField("oldState", nil, []string{}),
+		data.NewField("newState", nil, []string{}),
 	)
 
 	for _, a := range annotations {
-		frame.AppendRow(a.Time, a.Title, a.Tags, a.Text)
+		frame.AppendRow(a.Time, a.Title, a.Tags, a.Text, a.OldState, a.NewState)
 	}
 
 	frame.Meta = &data.FrameMeta{
@@ -144,44 +196,117 @@ func transformAnnotationToTable(annotations []*annotationEvent, query backend.Da
 	return frame
 }
 
+// filterAlarms returns the names of the metric alarms matching the given metric. Composite alarms don't watch a
+// metric themselves, so they're only returned when no metric is given.
 func filterAlarms(alarms *cloudwatch.DescribeAlarmsOutput, namespace string, metricName string,
 	dimensions map[string]interface{}, statistic string, period int64) []*string {
 	alarmNames := make([]*string, 0)
 
 	for _, alarm := range alarms.MetricAlarms {
-		if namespace != "" && *alarm.Namespace != namespace {
+		if namespace != "" && aws.StringValue(alarm.Namespace) != namespace {
 			continue
 		}
-		if metricName != "" && *alarm.MetricName != metricName {
+		if metricName != "" && aws.StringValue(alarm.MetricName) != metricName {
 			continue
 		}
 
-		matchDimension := true
-		if len(dimensions) != 0 {
-			if len(alarm.Dimensions) != len(dimensions) {
-				matchDimension = false
-			} else {
-				for _, d := range alarm.Dimensions {
-					if _, ok := dimensions[*d.Name]; !ok {
-						matchDimension = false
-					}
-				}
-			}
-		}
-		if !matchDimension {
+		if !matchAlarmDimensions(alarm.Dimensions, dimensions) {
 			continue
 		}
 
-		if *alarm.Statistic != statistic {
+		if statistic != "" && aws.StringValue(alarm.Statistic) != statistic {
 			continue
 		}
 
-		if period != 0 && *alarm.Period != period {
+		if period != 0 && aws.Int64Value(alarm.Period) != period {
 			continue
 		}
 
 		alarmNames = append(alarmNames, alarm.AlarmName)
 	}
 
+	if namespace == "" && metricName == "" && len(dimensions) == 0 {
+		for _, alarm := range alarms.CompositeAlarms {
+			alarmNames = append(alarmNames, alarm.AlarmName)
+		}
+	}
+
 	return alarmNames
 }
+
+// exactAlarmDimensions returns the dimensions of the query for DescribeAlarmsForMetric. It reports false when a
+// dimension has no value, several values or a wildcard, which DescribeAlarmsForMetric can't match.
+func exactAlarmDimensions(dimensions map[string]interface{}) ([]*cloudwatch.Dimension, bool) {
+	exact := make([]*cloudwatch.Dimension, 0, len(dimensions))
+	for k, v := range dimensions {
+		patterns := dimensionPatterns(v)
+		if len(patterns) != 1 || strings.Contains(patterns[0], "*") {
+			return nil, false
+		}
+		exact = append(exact, &cloudwatch.Dimension{Name: aws.String(k), Value: aws.String(patterns[0])})
+	}
+	return exact, true
+}
+
+// matchAlarmDimensions reports whether the alarm has exactly the dimension keys of the query and each dimension
+// value matches one of the query's values for it. Query values may contain * wildcards.
+func matchAlarmDimensions(alarmDimensions []*cloudwatch.Dimension, dimensions map[string]interface{}) bool {
+	if len(dimensions) == 0 {
+		return true
+	}
+	if len(alarmDimensions) != len(dimensions) {
+		return false
+	}
+	for _, d := range alarmDimensions {
+		values, ok := dimensions[aws.StringValue(d.Name)]
+		if !ok {
+			return false
+		}
+		if !matchDimensionValue(aws.StringValue(d.Value), values) {
+			return false
+		}
+	}
+	return true
+}
+
+// dimensionPatterns returns the values of a query dimension, given either as a single value or a list
+func dimensionPatterns(values interface{}) []string {
+	var patterns []string
+	switch v := values.(type) {
+	case string:
+		patterns = []string{v}
+	case []interface{}:
+		for _, p := range v {
+			if s, ok := p.(string); ok {
+				patterns = append(patterns, s)
+			}
+		}
+	}
+	return patterns
+}
+
+func matchDimensionValue(value string, values interface{}) bool {
+	patterns := dimensionPatterns(values)
+	// a dimension without values matches any value
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if wildcardMatch(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func wildcardMatch(pattern string, value string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expr, value)
+	return err == nil && matched
+}
@@ -0,0 +1,247 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCWClient serves the alarms in pages of one alarm, and the history items of every alarm
+type fakeCWClient struct {
+	cloudwatchiface.CloudWatchAPI
+	metricAlarms       []*cloudwatch.MetricAlarm
+	compositeAlarms    []*cloudwatch.CompositeAlarm
+	history            []*cloudwatch.AlarmHistoryItem
+	describeInputs     []*cloudwatch.DescribeAlarmsInput
+	forMetricInputs    []*cloudwatch.DescribeAlarmsForMetricInput
+	historyInputs      []*cloudwatch.DescribeAlarmHistoryInput
+	forMetricResponses []*cloudwatch.MetricAlarm
+}
+
+func (c *fakeCWClient) DescribeAlarmsPages(input *cloudwatch.DescribeAlarmsInput, fn func(*cloudwatch.DescribeAlarmsOutput, bool) bool) error {
+	c.describeInputs = append(c.describeInputs, input)
+	pages := []*cloudwatch.DescribeAlarmsOutput{}
+	for _, alarm := range c.metricAlarms {
+		pages = append(pages, &cloudwatch.DescribeAlarmsOutput{MetricAlarms: []*cloudwatch.MetricAlarm{alarm}})
+	}
+	for _, alarm := range c.compositeAlarms {
+		pages = append(pages, &cloudwatch.DescribeAlarmsOutput{CompositeAlarms: []*cloudwatch.CompositeAlarm{alarm}})
+	}
+	for i, page := range pages {
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeCWClient) DescribeAlarmsForMetric(input *cloudwatch.DescribeAlarmsForMetricInput) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
+	c.forMetricInputs = append(c.forMetricInputs, input)
+	return &cloudwatch.DescribeAlarmsForMetricOutput{MetricAlarms: c.forMetricResponses}, nil
+}
+
+func (c *fakeCWClient) DescribeAlarmHistoryPages(input *cloudwatch.DescribeAlarmHistoryInput, fn func(*cloudwatch.DescribeAlarmHistoryOutput, bool) bool) error {
+	c.historyInputs = append(c.historyInputs, input)
+	items := []*cloudwatch.AlarmHistoryItem{}
+	for _, item := range c.history {
+		if aws.StringValue(item.AlarmName) == aws.StringValue(input.AlarmName) {
+			items = append(items, item)
+		}
+	}
+	fn(&cloudwatch.DescribeAlarmHistoryOutput{AlarmHistoryItems: items}, true)
+	return nil
+}
+
+func stubCWClient(t *testing.T, client *fakeCWClient) {
+	t.Helper()
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() { NewCWClient = origNewCWClient })
+	NewCWClient = func(*session.Session) cloudwatchiface.CloudWatchAPI {
+		return client
+	}
+}
+
+func metricAlarm(name string, dimensions map[string]string) *cloudwatch.MetricAlarm {
+	alarm := &cloudwatch.MetricAlarm{
+		AlarmName:  aws.String(name),
+		Namespace:  aws.String("AWS/EC2"),
+		MetricName: aws.String("CPUUtilization"),
+		Statistic:  aws.String("Average"),
+		Period:     aws.Int64(300),
+	}
+	for k, v := range dimensions {
+		alarm.Dimensions = append(alarm.Dimensions, &cloudwatch.Dimension{Name: aws.String(k), Value: aws.String(v)})
+	}
+	return alarm
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "*", value: "i-123", want: true},
+		{pattern: "i-123", value: "i-123", want: true},
+		{pattern: "i-123", value: "i-1234", want: false},
+		{pattern: "i-*", value: "i-123", want: true},
+		{pattern: "*-prod", value: "api-prod", want: true},
+		{pattern: "api-*-eu", value: "api-prod-eu", want: true},
+		{pattern: "api-*-eu", value: "api-prod-us", want: false},
+		{pattern: "api.*", value: "apix", want: false},
+		{pattern: "api.*", value: "api.prod", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, wildcardMatch(tt.pattern, tt.value))
+		})
+	}
+}
+
+func TestMatchAlarmDimensions(t *testing.T) {
+	alarm := metricAlarm("a", map[string]string{"InstanceId": "i-123", "AutoScalingGroupName": "web"})
+	tests := []struct {
+		name       string
+		dimensions map[string]interface{}
+		want       bool
+	}{
+		{name: "no dimensions", dimensions: nil, want: true},
+		{name: "all values", dimensions: map[string]interface{}{"InstanceId": []interface{}{"i-123"}, "AutoScalingGroupName": []interface{}{"web"}}, want: true},
+		{name: "single string values", dimensions: map[string]interface{}{"InstanceId": "i-123", "AutoScalingGroupName": "web"}, want: true},
+		{name: "one of the values", dimensions: map[string]interface{}{"InstanceId": []interface{}{"i-456", "i-123"}, "AutoScalingGroupName": "web"}, want: true},
+		{name: "wildcards", dimensions: map[string]interface{}{"InstanceId": "i-*", "AutoScalingGroupName": "*"}, want: true},
+		{name: "a key without values", dimensions: map[string]interface{}{"InstanceId": []interface{}{}, "AutoScalingGroupName": "web"}, want: true},
+		{name: "another value", dimensions: map[string]interface{}{"InstanceId": "i-456", "AutoScalingGroupName": "web"}, want: false},
+		{name: "missing key", dimensions: map[string]interface{}{"InstanceId": "i-123"}, want: false},
+		{name: "other key", dimensions: map[string]interface{}{"InstanceId": "i-123", "Other": "web"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchAlarmDimensions(alarm.Dimensions, tt.dimensions))
+		})
+	}
+}
+
+func TestNewAnnotationEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		itemType    string
+		historyData *string
+		oldState    string
+		newState    string
+	}{
+		{name: "state update", itemType: cloudwatch.HistoryItemTypeStateUpdate, historyData: aws.String(`{"oldState":{"stateValue":"OK"},"newState":{"stateValue":"ALARM"}}`), oldState: "OK", newState: "ALARM"},
+		{name: "malformed history data", itemType: cloudwatch.HistoryItemTypeStateUpdate, historyData: aws.String(`{`)},
+		{name: "without history data", itemType: cloudwatch.HistoryItemTypeStateUpdate},
+		{name: "configuration update", itemType: cloudwatch.HistoryItemTypeConfigurationUpdate, historyData: aws.String(`{"oldState":{"stateValue":"OK"}}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := newAnnotationEvent(&cloudwatch.AlarmHistoryItem{
+				AlarmName:       aws.String("a"),
+				HistoryItemType: aws.String(tt.itemType),
+				HistorySummary:  aws.String("summary"),
+				HistoryData:     tt.historyData,
+				Timestamp:       aws.Time(time.Unix(0, 0)),
+			})
+			assert.Equal(t, "a", event.Title)
+			assert.Equal(t, tt.itemType, event.Tags)
+			assert.Equal(t, tt.oldState, event.OldState)
+			assert.Equal(t, tt.newState, event.NewState)
+		})
+	}
+}
+
+func TestExecuteAnnotationQuery(t *testing.T) {
+	query := backend.DataQuery{RefID: "A", TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)}}
+	history := []*cloudwatch.AlarmHistoryItem{
+		{AlarmName: aws.String("cpu-1"), HistoryItemType: aws.String(cloudwatch.HistoryItemTypeStateUpdate), Timestamp: aws.Time(time.Unix(60, 0)),
+			HistoryData: aws.String(`{"oldState":{"stateValue":"OK"},"newState":{"stateValue":"ALARM"}}`)},
+		{AlarmName: aws.String("composite"), HistoryItemType: aws.String(cloudwatch.HistoryItemTypeAction), Timestamp: aws.Time(time.Unix(120, 0))},
+	}
+	newClient := func() *fakeCWClient {
+		return &fakeCWClient{
+			metricAlarms: []*cloudwatch.MetricAlarm{
+				metricAlarm("cpu-1", map[string]string{"InstanceId": "i-1"}),
+				metricAlarm("cpu-2", map[string]string{"InstanceId": "i-2"}),
+				metricAlarm("cpu-web", map[string]string{"InstanceId": "web-1"}),
+			},
+			compositeAlarms:    []*cloudwatch.CompositeAlarm{{AlarmName: aws.String("composite")}},
+			history:            history,
+			forMetricResponses: []*cloudwatch.MetricAlarm{metricAlarm("cpu-1", map[string]string{"InstanceId": "i-1"})},
+		}
+	}
+	alarmNames := func(inputs []*cloudwatch.DescribeAlarmHistoryInput) []string {
+		names := []string{}
+		for _, input := range inputs {
+			names = append(names, aws.StringValue(input.AlarmName))
+		}
+		return names
+	}
+
+	t.Run("exact dimensions use DescribeAlarmsForMetric", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		res, err := newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{
+			Region: "us-east-1", Namespace: "AWS/EC2", MetricName: "CPUUtilization", Statistic: aws.String("Average"),
+			Dimensions: map[string]interface{}{"InstanceId": "i-1"},
+		}, query)
+		require.NoError(t, err)
+		require.Len(t, client.forMetricInputs, 1)
+		assert.Empty(t, client.describeInputs)
+		assert.Equal(t, []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}}, client.forMetricInputs[0].Dimensions)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		assert.Equal(t, "OK", frame.Fields[4].At(0))
+		assert.Equal(t, "ALARM", frame.Fields[5].At(0))
+	})
+	t.Run("wildcards are matched on the alarms of the metric", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		_, err := newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{
+			Region: "us-east-1", Namespace: "AWS/EC2", MetricName: "CPUUtilization", Statistic: aws.String("Average"),
+			Dimensions: map[string]interface{}{"InstanceId": []interface{}{"i-*"}}, AlarmNamePrefix: "ignored",
+		}, query)
+		require.NoError(t, err)
+		assert.Empty(t, client.forMetricInputs)
+		require.Len(t, client.describeInputs, 1)
+		assert.Nil(t, client.describeInputs[0].AlarmNamePrefix)
+		assert.Equal(t, []string{"cpu-1", "cpu-2"}, alarmNames(client.historyInputs))
+	})
+	t.Run("composite alarms are only returned without a metric", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		res, err := newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{PrefixMatching: true, AlarmNamePrefix: "c"}, query)
+		require.NoError(t, err)
+		assert.Equal(t, "c", aws.StringValue(client.describeInputs[0].AlarmNamePrefix))
+		assert.Equal(t, []string{"cpu-1", "cpu-2", "cpu-web", "composite"}, alarmNames(client.historyInputs))
+		assert.Equal(t, 2, res.Responses["A"].Frames[0].Rows())
+
+		client = newClient()
+		stubCWClient(t, client)
+		_, err = newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{PrefixMatching: true, Namespace: "AWS/EC2"}, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cpu-1", "cpu-2", "cpu-web"}, alarmNames(client.historyInputs))
+	})
+	t.Run("the history item type is passed on and validated", func(t *testing.T) {
+		client := newClient()
+		stubCWClient(t, client)
+		_, err := newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{PrefixMatching: true, HistoryItemType: cloudwatch.HistoryItemTypeStateUpdate}, query)
+		require.NoError(t, err)
+		for _, input := range client.historyInputs {
+			assert.Equal(t, cloudwatch.HistoryItemTypeStateUpdate, aws.StringValue(input.HistoryItemType))
+		}
+
+		_, err = newTestExecutor().executeAnnotationQuery(backend.PluginContext{}, DataQueryJson{PrefixMatching: true, HistoryItemType: "Unknown"}, query)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown history item type "Unknown"`)
+	})
+}
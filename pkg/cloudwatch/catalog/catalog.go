@@ -0,0 +1,307 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// SourceEmbedded is the source of the catalog compiled into the plugin
+const SourceEmbedded = "embedded"
+
+// Catalog file modes
+const (
+	// ModeExtend merges the namespaces of the file into the embedded catalog
+	ModeExtend = "extend"
+	// ModeOverride replaces the embedded catalog by the namespaces of the file
+	ModeOverride = "override"
+)
+
+//go:embed default_metric_catalog.json
+var defaultCatalogJSON []byte
+
+// Namespace lists the known metrics and dimension keys of a CloudWatch namespace
+type Namespace struct {
+	Metrics    []string `json:"metrics"`
+	Dimensions []string `json:"dimensions"`
+}
+
+// document is the format of the embedded catalog and of catalog files, either as JSON or YAML
+type document struct {
+	Version    string               `json:"version"`
+	Mode       string               `json:"mode"`
+	Namespaces map[string]Namespace `json:"namespaces"`
+}
+
+// Info describes where a catalog comes from. It is what the metric catalog endpoint returns.
+type Info struct {
+	Version              string     `json:"version"`
+	Source               string     `json:"source"`
+	Mode                 string     `json:"mode"`
+	DefaultVersion       string     `json:"defaultVersion"`
+	LoadedAt             time.Time  `json:"loadedAt"`
+	DiscoveredAt         *time.Time `json:"discoveredAt,omitempty"`
+	Namespaces           int        `json:"namespaces"`
+	DiscoveredNamespaces []string   `json:"discoveredNamespaces"`
+	Error                string     `json:"error,omitempty"`
+}
+
+// source holds the namespaces loaded from a catalog source. It is shared by the datasources using the source and
+// never holds the namespaces discovered through ListMetrics.
+type source struct {
+	mu         sync.RWMutex
+	version    string
+	source     string
+	mode       string
+	loadedAt   time.Time
+	lastError  string
+	namespaces map[string]Namespace
+	// loading is closed once the load in progress is done
+	loading chan struct{}
+}
+
+// overlay holds what the refresh routine found through ListMetrics in one account. It survives reloads of the source.
+type overlay struct {
+	mu           sync.RWMutex
+	discovered   map[string]Namespace
+	discoveredAt time.Time
+	discovering  bool
+}
+
+// Catalog is the set of namespaces, metrics and dimension keys offered without calling ListMetrics: the namespaces
+// of the catalog source of a datasource, extended by the ones discovered in its account. It is safe for concurrent use.
+type Catalog struct {
+	src     *source
+	overlay *overlay
+}
+
+var defaultDocument = func() document {
+	doc, err := parseDocument(defaultCatalogJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded metric catalog: %v", err))
+	}
+	return doc
+}()
+
+// Default returns a catalog holding the embedded namespaces only
+func Default() *Catalog {
+	return &Catalog{src: newSource(), overlay: newOverlay()}
+}
+
+func newSource() *source {
+	s := &source{}
+	s.apply(defaultDocument, SourceEmbedded, ModeExtend)
+	return s
+}
+
+func newOverlay() *overlay {
+	return &overlay{discovered: make(map[string]Namespace)}
+}
+
+func parseDocument(b []byte) (document, error) {
+	// YAML is a superset of JSON, so both are read the same way
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return document{}, err
+	}
+	var doc document
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return document{}, err
+	}
+	switch doc.Mode {
+	case "":
+		doc.Mode = ModeExtend
+	case ModeExtend, ModeOverride:
+	default:
+		return document{}, fmt.Errorf("invalid catalog mode %q, expected %q or %q", doc.Mode, ModeExtend, ModeOverride)
+	}
+	return doc, nil
+}
+
+// apply replaces the namespaces of the source by the embedded ones, extended or overridden by doc
+func (s *source) apply(doc document, name string, mode string) {
+	namespaces := make(map[string]Namespace)
+	if mode != ModeOverride {
+		for name, ns := range defaultDocument.Namespaces {
+			namespaces[name] = ns
+		}
+	}
+	if name != SourceEmbedded {
+		for name, ns := range doc.Namespaces {
+			namespaces[name] = mergeNamespace(namespaces[name], ns)
+		}
+	}
+
+	version := doc.Version
+	if version == "" {
+		version = defaultDocument.Version
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+	s.source = name
+	s.mode = mode
+	s.loadedAt = time.Now()
+	s.lastError = ""
+	s.namespaces = namespaces
+}
+
+func (s *source) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err.Error()
+	// the source is retried at the next refresh
+	s.loadedAt = time.Now()
+}
+
+// lookup must be called with the read locks of the source and the overlay held
+func (c *Catalog) lookup(namespace string) (Namespace, bool) {
+	ns, ok := c.src.namespaces[namespace]
+	if discovered, found := c.overlay.discovered[namespace]; found {
+		return mergeNamespace(ns, discovered), true
+	}
+	return ns, ok
+}
+
+func (c *Catalog) rLock() {
+	c.src.mu.RLock()
+	c.overlay.mu.RLock()
+}
+
+func (c *Catalog) rUnlock() {
+	c.overlay.mu.RUnlock()
+	c.src.mu.RUnlock()
+}
+
+// HasNamespace reports whether the catalog knows the namespace
+func (c *Catalog) HasNamespace(namespace string) bool {
+	c.rLock()
+	defer c.rUnlock()
+	_, ok := c.lookup(namespace)
+	return ok
+}
+
+// Metrics returns the metric names of the namespace
+func (c *Catalog) Metrics(namespace string) ([]string, bool) {
+	c.rLock()
+	defer c.rUnlock()
+	ns, ok := c.lookup(namespace)
+	return ns.Metrics, ok
+}
+
+// DimensionKeys returns the dimension keys of the namespace
+func (c *Catalog) DimensionKeys(namespace string) ([]string, bool) {
+	c.rLock()
+	defer c.rUnlock()
+	ns, ok := c.lookup(namespace)
+	return ns.Dimensions, ok
+}
+
+// Namespaces returns the names of all namespaces of the catalog, sorted
+func (c *Catalog) Namespaces() []string {
+	c.rLock()
+	defer c.rUnlock()
+	seen := make(map[string]bool, len(c.src.namespaces)+len(c.overlay.discovered))
+	names := make([]string, 0, len(c.src.namespaces)+len(c.overlay.discovered))
+	for _, m := range []map[string]Namespace{c.src.namespaces, c.overlay.discovered} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartDiscovery reports whether the namespaces seen through ListMetrics are due to be merged in again.
+// When it returns true the caller must call MergeDiscovered or FinishDiscovery once done.
+func (c *Catalog) StartDiscovery(interval time.Duration, force bool) bool {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	c.overlay.mu.Lock()
+	defer c.overlay.mu.Unlock()
+	if c.overlay.discovering || (!force && time.Since(c.overlay.discoveredAt) < interval) {
+		return false
+	}
+	c.overlay.discovering = true
+	return true
+}
+
+// MergeDiscovered adds the metrics and dimension keys seen through ListMetrics to the catalog of the account
+func (c *Catalog) MergeDiscovered(namespaces map[string]Namespace) {
+	c.overlay.mu.Lock()
+	defer c.overlay.mu.Unlock()
+	for name, ns := range namespaces {
+		c.overlay.discovered[name] = mergeNamespace(c.overlay.discovered[name], ns)
+	}
+	c.overlay.discoveredAt = time.Now()
+	c.overlay.discovering = false
+}
+
+// FinishDiscovery ends a discovery which failed. The next one is attempted after the refresh interval.
+func (c *Catalog) FinishDiscovery() {
+	c.overlay.mu.Lock()
+	defer c.overlay.mu.Unlock()
+	c.overlay.discoveredAt = time.Now()
+	c.overlay.discovering = false
+}
+
+// Info returns the version and source of the catalog
+func (c *Catalog) Info() Info {
+	c.rLock()
+	discovered := make([]string, 0)
+	for name := range c.overlay.discovered {
+		if _, ok := c.src.namespaces[name]; !ok {
+			discovered = append(discovered, name)
+		}
+	}
+	info := Info{
+		Version:        c.src.version,
+		Source:         c.src.source,
+		Mode:           c.src.mode,
+		DefaultVersion: defaultDocument.Version,
+		LoadedAt:       c.src.loadedAt,
+		Error:          c.src.lastError,
+	}
+	if !c.overlay.discoveredAt.IsZero() {
+		discoveredAt := c.overlay.discoveredAt
+		info.DiscoveredAt = &discoveredAt
+	}
+	c.rUnlock()
+
+	sort.Strings(discovered)
+	info.Namespaces = len(c.Namespaces())
+	info.DiscoveredNamespaces = discovered
+	return info
+}
+
+// mergeNamespace returns the union of the metrics and dimension keys of a and b
+func mergeNamespace(a Namespace, b Namespace) Namespace {
+	return Namespace{Metrics: union(a.Metrics, b.Metrics), Dimensions: union(a.Dimensions, b.Dimensions)}
+}
+
+func union(a []string, b []string) []string {
+	result := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, values := range [][]string{a, b} {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
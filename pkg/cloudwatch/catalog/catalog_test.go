@@ -0,0 +1,189 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogServer serves the catalog body, after waiting for release when it is set
+func catalogServer(t *testing.T, body string, release chan struct{}) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if release != nil {
+			<-release
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestApply(t *testing.T) {
+	doc, err := parseDocument([]byte("version: v2\nnamespaces:\n  AWS/EC2:\n    metrics: [Custom]\n  Team/App:\n    metrics: [Requests]\n    dimensions: [Service]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ModeExtend, doc.Mode)
+
+	t.Run("extend merges the file into the embedded namespaces", func(t *testing.T) {
+		c := Default()
+		c.src.apply(doc, "file.yaml", ModeExtend)
+		metrics, ok := c.Metrics("AWS/EC2")
+		require.True(t, ok)
+		assert.Contains(t, metrics, "CPUCreditBalance")
+		assert.Contains(t, metrics, "Custom")
+		assert.True(t, c.HasNamespace("AWS/S3"))
+		assert.True(t, c.HasNamespace("Team/App"))
+		assert.Equal(t, "v2", c.Info().Version)
+	})
+	t.Run("override replaces the embedded namespaces", func(t *testing.T) {
+		c := Default()
+		c.src.apply(doc, "file.yaml", ModeOverride)
+		assert.Equal(t, []string{"AWS/EC2", "Team/App"}, c.Namespaces())
+		metrics, _ := c.Metrics("AWS/EC2")
+		assert.Equal(t, []string{"Custom"}, metrics)
+	})
+	t.Run("invalid modes are rejected", func(t *testing.T) {
+		_, err := parseDocument([]byte(`{"mode":"replace"}`))
+		require.Error(t, err)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("the embedded catalog is used without a source", func(t *testing.T) {
+		c := Get(context.Background(), Options{}, t.Name())
+		info := c.Info()
+		assert.Equal(t, SourceEmbedded, info.Source)
+		assert.Equal(t, defaultDocument.Version, info.Version)
+		assert.True(t, c.HasNamespace("AWS/EC2"))
+	})
+	t.Run("the first request waits for the source to load", func(t *testing.T) {
+		server, calls := catalogServer(t, `{"version":"remote","namespaces":{"Team/App":{"metrics":["Requests"]}}}`, nil)
+		c := Get(context.Background(), Options{URL: server.URL}, t.Name())
+		assert.Equal(t, "remote", c.Info().Version)
+		assert.True(t, c.HasNamespace("Team/App"))
+
+		Get(context.Background(), Options{URL: server.URL}, t.Name())
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("concurrent requests share one load which outlives the cancelled requests", func(t *testing.T) {
+		release := make(chan struct{})
+		server, calls := catalogServer(t, `{"version":"shared"}`, release)
+		opts := Options{URL: server.URL}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				Get(ctx, opts, t.Name())
+			}()
+		}
+		// cancelling the requests returns them with the embedded catalog
+		cancel()
+		wg.Wait()
+		assert.Equal(t, defaultDocument.Version, Get(ctx, opts, t.Name()).Info().Version)
+
+		close(release)
+		require.Eventually(t, func() bool {
+			return Get(context.Background(), opts, t.Name()).Info().Version == "shared"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("stale sources are reloaded in the background", func(t *testing.T) {
+		server, calls := catalogServer(t, `{"version":"stale"}`, nil)
+		opts := Options{URL: server.URL, RefreshInterval: time.Millisecond}
+		Get(context.Background(), opts, t.Name())
+		time.Sleep(5 * time.Millisecond)
+		Get(context.Background(), opts, t.Name())
+		require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 2 }, time.Second, 10*time.Millisecond)
+	})
+	t.Run("the last catalog is kept when the source fails", func(t *testing.T) {
+		var failing int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.LoadInt32(&failing) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `{"version":"good","namespaces":{"Team/App":{}}}`)
+		}))
+		t.Cleanup(server.Close)
+		opts := Options{URL: server.URL}
+		c := Get(context.Background(), opts, t.Name())
+		require.Equal(t, "good", c.Info().Version)
+
+		atomic.StoreInt32(&failing, 1)
+		Reload(context.Background(), c, opts)
+		info := c.Info()
+		assert.Equal(t, "good", info.Version)
+		assert.True(t, c.HasNamespace("Team/App"))
+		assert.Contains(t, info.Error, "unexpected status 500")
+	})
+	t.Run("the URL is fetched with the client of the options", func(t *testing.T) {
+		server, calls := catalogServer(t, `{"version":"restricted"}`, nil)
+		blocked := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("blocked by the datasource settings")
+		})}
+		c := Get(context.Background(), Options{URL: server.URL, HTTPClient: blocked}, t.Name())
+		info := c.Info()
+		assert.Equal(t, SourceEmbedded, info.Source)
+		assert.Contains(t, info.Error, "blocked by the datasource settings")
+		assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+	})
+	t.Run("unreadable files are reported", func(t *testing.T) {
+		c := Get(context.Background(), Options{Path: "/does/not/exist.yaml"}, t.Name())
+		info := c.Info()
+		assert.Equal(t, SourceEmbedded, info.Source)
+		assert.Contains(t, info.Error, "unable to load metric catalog from /does/not/exist.yaml")
+	})
+}
+
+func TestDiscoveredNamespaces(t *testing.T) {
+	// the overlays outlive the test, the accounts are made unique to each run
+	run := time.Now().UnixNano()
+	a := Get(context.Background(), Options{}, fmt.Sprintf("account-a-%d/eu-west-1", run))
+	b := Get(context.Background(), Options{}, fmt.Sprintf("account-b-%d/eu-west-1", run))
+
+	require.True(t, a.StartDiscovery(time.Hour, false))
+	assert.False(t, a.StartDiscovery(time.Hour, true), "a discovery is already running")
+	a.MergeDiscovered(map[string]Namespace{
+		"AWS/NewService": {Metrics: []string{"Invocations"}, Dimensions: []string{"Name"}},
+		"AWS/EC2":        {Metrics: []string{"Discovered"}},
+	})
+	assert.False(t, a.StartDiscovery(time.Hour, false))
+
+	assert.True(t, a.HasNamespace("AWS/NewService"))
+	metrics, _ := a.Metrics("AWS/EC2")
+	assert.Contains(t, metrics, "Discovered")
+	assert.Equal(t, []string{"AWS/NewService"}, a.Info().DiscoveredNamespaces)
+	assert.True(t, IsKnownNamespace("AWS/NewService"))
+
+	// the other accounts and the shared embedded catalog are left untouched
+	assert.False(t, b.HasNamespace("AWS/NewService"))
+	assert.False(t, Default().HasNamespace("AWS/NewService"))
+	metrics, _ = Get(context.Background(), Options{}, fmt.Sprintf("account-c-%d/eu-west-1", run)).Metrics("AWS/EC2")
+	assert.NotContains(t, metrics, "Discovered")
+
+	require.True(t, b.StartDiscovery(time.Hour, false))
+	b.FinishDiscovery()
+	assert.False(t, b.StartDiscovery(time.Hour, false))
+	assert.Empty(t, b.Info().DiscoveredNamespaces)
+	assert.NotNil(t, b.Info().DiscoveredAt)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,231 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshInterval is how long a loaded catalog and the namespaces discovered through ListMetrics are reused
+	DefaultRefreshInterval = time.Hour
+
+	// maxCatalogSize bounds the size of a catalog file or response
+	maxCatalogSize = 16 << 20
+)
+
+// Options tells where the catalog of a datasource is loaded from
+type Options struct {
+	// Path is a JSON or YAML catalog file on the plugin host
+	Path string
+	// URL is a JSON or YAML catalog served over HTTP(S). It takes precedence over Path.
+	URL string
+	// RefreshInterval is how often the source is reloaded
+	RefreshInterval time.Duration
+	// HTTPClient fetches the URL, with the restrictions of the datasource. Defaults to httpClient.
+	HTTPClient *http.Client
+}
+
+func (o Options) source() string {
+	switch {
+	case o.URL != "":
+		return o.URL
+	case o.Path != "":
+		return o.Path
+	}
+	return SourceEmbedded
+}
+
+func (o Options) refreshInterval() time.Duration {
+	if o.RefreshInterval <= 0 {
+		return DefaultRefreshInterval
+	}
+	return o.RefreshInterval
+}
+
+// catalogs holds the sources, shared by the datasources using the same source, and the namespaces discovered in
+// each account
+var catalogs = struct {
+	sync.Mutex
+	sources  map[string]*source
+	overlays map[string]*overlay
+}{sources: map[string]*source{SourceEmbedded: newSource()}, overlays: map[string]*overlay{}}
+
+// httpClient fetches catalogs configured by URL.
+//
+// Stubbable by tests.
+var httpClient = &http.Client{Timeout: loadTimeout}
+
+// loadTimeout bounds the loads of a source made in the background
+const loadTimeout = 10 * time.Second
+
+// Get returns the catalog for the options, extended by the namespaces discovered in the account identified by
+// overlayKey. The source is loaded in the background the first time and once the refresh interval has passed,
+// only the first request waits for it, as long as ctx allows. Until the source is loaded, or when it can't be
+// loaded, the last catalog loaded, or the embedded one, is used and the error is reported by Info.
+func Get(ctx context.Context, opts Options, overlayKey string) *Catalog {
+	name := opts.source()
+
+	catalogs.Lock()
+	src, ok := catalogs.sources[name]
+	if !ok {
+		src = newSource()
+		src.loadedAt = time.Time{}
+		catalogs.sources[name] = src
+	}
+	ov, ok := catalogs.overlays[overlayKey]
+	if !ok {
+		ov = newOverlay()
+		catalogs.overlays[overlayKey] = ov
+	}
+	catalogs.Unlock()
+
+	c := &Catalog{src: src, overlay: ov}
+	if name == SourceEmbedded {
+		return c
+	}
+
+	src.mu.RLock()
+	neverLoaded := src.loadedAt.IsZero()
+	stale := time.Since(src.loadedAt) >= opts.refreshInterval()
+	src.mu.RUnlock()
+	if !stale {
+		return c
+	}
+	done, started := src.startLoad()
+	if started {
+		go func() {
+			// the load isn't tied to the request which started it, the other requests may be waiting for it
+			loadCtx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+			defer cancel()
+			load(loadCtx, src, opts)
+			src.endLoad(done)
+		}()
+	}
+	if neverLoaded {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	return c
+}
+
+// Reload loads the source of the options into c, or waits for the load in progress
+func Reload(ctx context.Context, c *Catalog, opts Options) {
+	done, started := c.src.startLoad()
+	if !started {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		return
+	}
+	defer c.src.endLoad(done)
+	load(ctx, c.src, opts)
+}
+
+// startLoad returns the channel closed once the load of the source is done. started is false when a load was
+// already in progress.
+func (s *source) startLoad() (done chan struct{}, started bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loading != nil {
+		return s.loading, false
+	}
+	s.loading = make(chan struct{})
+	return s.loading, true
+}
+
+func (s *source) endLoad(done chan struct{}) {
+	s.mu.Lock()
+	s.loading = nil
+	s.mu.Unlock()
+	close(done)
+}
+
+func load(ctx context.Context, s *source, opts Options) {
+	name := opts.source()
+	if name == SourceEmbedded {
+		s.apply(defaultDocument, SourceEmbedded, ModeExtend)
+		return
+	}
+
+	b, err := readSource(ctx, opts)
+	if err != nil {
+		s.setError(fmt.Errorf("unable to load metric catalog from %s: %w", name, err))
+		return
+	}
+	doc, err := parseDocument(b)
+	if err != nil {
+		s.setError(fmt.Errorf("invalid metric catalog %s: %w", name, err))
+		return
+	}
+	s.apply(doc, name, doc.Mode)
+}
+
+func readSource(ctx context.Context, opts Options) ([]byte, error) {
+	if opts.URL == "" {
+		f, err := os.Open(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		return readLimited(f)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = httpClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return readLimited(res.Body)
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxCatalogSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxCatalogSize {
+		return nil, fmt.Errorf("catalog larger than %d bytes", maxCatalogSize)
+	}
+	return b, nil
+}
+
+// IsKnownNamespace reports whether any loaded catalog, or the namespaces discovered in any account, know the
+// namespace. Namespaces unknown to all catalogs are custom namespaces, whose metrics are only available through
+// ListMetrics.
+func IsKnownNamespace(namespace string) bool {
+	catalogs.Lock()
+	entries := make([]*Catalog, 0, len(catalogs.sources)+len(catalogs.overlays))
+	for _, src := range catalogs.sources {
+		entries = append(entries, &Catalog{src: src, overlay: newOverlay()})
+	}
+	for _, ov := range catalogs.overlays {
+		entries = append(entries, &Catalog{src: &source{}, overlay: ov})
+	}
+	catalogs.Unlock()
+
+	for _, c := range entries {
+		if c.HasNamespace(namespace) {
+			return true
+		}
+	}
+	return false
+}
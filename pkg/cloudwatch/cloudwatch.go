@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/clients"
 	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/costexplorer/costexploreriface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"github.com/aws/aws-sdk-go/service/xray/xrayiface"
 	"net/http"
 	"regexp"
 	"time"
@@ -33,6 +38,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
 )
 
 type DataQueryJson struct {
@@ -47,6 +53,14 @@ type DataQueryJson struct {
 	Period          string
 	ActionPrefix    string
 	AlarmNamePrefix string
+	HistoryItemType string
+	StateValue      string
+	InventoryType   string
+	Filters         map[string]interface{}
+	Tags            map[string]interface{}
+	ResourceTypes   []string
+	Limit           int64
+	NextToken       string
 }
 
 type DataSource struct {
@@ -62,23 +76,25 @@ const (
 	logIdentifierInternal       = "__log__grafana_internal__"
 	logStreamIdentifierInternal = "__logstream__grafana_internal__"
 
-	alertMaxAttempts = 8
-	alertPollPeriod  = 1000 * time.Millisecond
-	logsQueryMode    = "Logs"
+	logsQueryMode = "Logs"
 
 	// QueryTypes
-	annotationQuery = "annotationQuery"
-	logAction       = "logAction"
-	timeSeriesQuery = "timeSeriesQuery"
+	annotationQuery  = "annotationQuery"
+	logAction        = "logAction"
+	timeSeriesQuery  = "timeSeriesQuery"
+	alarmStatusQuery = "alarmStatus"
+	inventoryQuery   = "inventory"
+	costQuery        = "cost"
+	xrayQuery        = "xray"
 )
 
 var logger = log.New("tsdb.cloudwatch")
 var aliasFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
 
-func ProvideService(httpClientProvider httpclient.Provider, awsCreds *dsModels.AwsCredential) *CloudWatchService {
+func ProvideService(httpClientProvider httpclient.Provider, awsCreds *dsModels.AwsCredential, settings models2.CloudWatchSettings) *CloudWatchService {
 	logger.Debug("Initializing")
 
-	executor := newExecutor(awsds.NewSessionCache(), awsCreds)
+	executor := newExecutor(awsds.NewSessionCache(), awsCreds, settings)
 
 	return &CloudWatchService{
 		//Cfg:      cfg,
@@ -87,6 +103,14 @@ func ProvideService(httpClientProvider httpclient.Provider, awsCreds *dsModels.A
 	}
 }
 
+// ProvideServiceWithSession is ProvideService for credentials whose cross account role was already assumed
+func ProvideServiceWithSession(httpClientProvider httpclient.Provider, awsCreds *dsModels.AwsCredential, settings models2.CloudWatchSettings, assumeRoleSession *session.Session) *CloudWatchService {
+	return &CloudWatchService{
+		AwsCreds: awsCreds,
+		Executor: newExecutorWithSession(awsds.NewSessionCache(), awsCreds, settings, assumeRoleSession),
+	}
+}
+
 type CloudWatchService struct {
 	//Cfg      *setting.Cfg
 	AwsCreds *dsModels.AwsCredential
@@ -97,36 +121,49 @@ type SessionCache interface {
 	GetSession(c awsds.SessionConfig) (*session.Session, error)
 }
 
-func newExecutor(sessions SessionCache, awsCreds *dsModels.AwsCredential) *cloudWatchExecutor {
+// NewAssumeRoleSession returns a session for the cross account role of the credentials
+func NewAssumeRoleSession(awsCreds *dsModels.AwsCredential) (*session.Session, error) {
 	awsSession, err := getSessionByCreds(awsCreds)
 	if err != nil {
-		fmt.Errorf("error creating aws session with given credentials: %w", err)
-		return nil
+		return nil, fmt.Errorf("error creating aws session with given credentials: %w", err)
 	}
 	assumeRole, err := getAssumeRole(awsSession, awsCreds)
 	if err != nil {
-		fmt.Errorf("error creating aws assume role: %w", err)
-		return nil
+		return nil, fmt.Errorf("error creating aws assume role: %w", err)
 	}
 	awsAssumeRoleSession, err := getSessionFromAssumeRole(assumeRole, awsCreds)
 	if err != nil {
-		fmt.Errorf("error creating aws assume role session: %w", err)
+		return nil, fmt.Errorf("error creating aws assume role session: %w", err)
+	}
+	return awsAssumeRoleSession, nil
+}
+
+func newExecutor(sessions SessionCache, awsCreds *dsModels.AwsCredential, settings models2.CloudWatchSettings) *cloudWatchExecutor {
+	awsAssumeRoleSession, err := NewAssumeRoleSession(awsCreds)
+	if err != nil {
+		logger.Error("error creating cloudwatch executor", "error", err)
 		return nil
 	}
+	return newExecutorWithSession(sessions, awsCreds, settings, awsAssumeRoleSession)
+}
+
+func newExecutorWithSession(sessions SessionCache, awsCreds *dsModels.AwsCredential, settings models2.CloudWatchSettings, awsAssumeRoleSession *session.Session) *cloudWatchExecutor {
 	e := &cloudWatchExecutor{
 		//im: im,
 		//cfg:      cfg,
 		AwsCreds:          awsCreds,
+		settings:          settings,
 		sessions:          sessions,
 		assumeRoleSession: awsAssumeRoleSession,
 		//features: features,
 	}
 
-	e.resourceHandler = httpadapter.New(e.newResourceMux())
+	e.resourceMux = e.newResourceMux()
+	e.resourceHandler = httpadapter.New(e.resourceMux)
 	return e
 }
 
-func (e *cloudWatchExecutor) getRequestContext(pluginCtx backend.PluginContext, region string) (models2.RequestContext, error) {
+func (e *cloudWatchExecutor) getRequestContext(ctx context.Context, pluginCtx backend.PluginContext, region string) (models2.RequestContext, error) {
 	r := region
 	//instance, err := e.getInstance(pluginCtx)
 	if region == defaultRegion {
@@ -144,7 +181,9 @@ func (e *cloudWatchExecutor) getRequestContext(pluginCtx backend.PluginContext,
 		OAMClientProvider:     NewOAMAPI(sess),
 		MetricsClientProvider: clients.NewMetricsClient(NewMetricsAPI(sess)),
 		LogsAPIProvider:       NewLogsAPI(sess),
-		//Settings:              instance.Settings,
+		Settings:              e.settings,
+		AccountKey:            accountKey(e.AwsCreds, r),
+		MetricCatalog:         catalog.Get(ctx, e.settings.MetricCatalogOptions(), accountKey(e.AwsCreds, r)),
 		//Features:              e.features,
 	}, nil
 }
@@ -173,17 +212,32 @@ type cloudWatchExecutor struct {
 	im instancemgmt.InstanceManager
 	//cfg      *setting.Cfg
 	AwsCreds *dsModels.AwsCredential
+	settings models2.CloudWatchSettings
 	sessions SessionCache
 	//features featuremgmt.FeatureToggles
 	assumeRoleSession *session.Session
+	resourceMux       *http.ServeMux
 	resourceHandler   backend.CallResourceHandler
+	// elementTags are the tags of the cloud element the queries are for
+	elementTags map[string]string
+}
+
+// SetElementTags sets the tags of the cloud element the queries are for. Cost queries can be restricted to them.
+func (e *cloudWatchExecutor) SetElementTags(tags map[string]string) {
+	e.elementTags = tags
 }
 
 func (e *cloudWatchExecutor) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
 	return e.resourceHandler.CallResource(ctx, req, sender)
 }
 
-func (e *cloudWatchExecutor) checkHealthMetrics(pluginCtx backend.PluginContext) error {
+// ServeHTTP serves the CloudWatch resource routes for requests which are already routed through a plugin resource handler.
+func (e *cloudWatchExecutor) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	e.resourceMux.ServeHTTP(rw, req)
+}
+
+// CheckHealthMetrics checks that the CloudWatch metrics API can be queried
+func (e *cloudWatchExecutor) CheckHealthMetrics(pluginCtx backend.PluginContext) error {
 	namespace := "AWS/Billing"
 	metric := "EstimatedCharges"
 	params := &cloudwatch.ListMetricsInput{
@@ -200,7 +254,8 @@ func (e *cloudWatchExecutor) checkHealthMetrics(pluginCtx backend.PluginContext)
 	return err
 }
 
-func (e *cloudWatchExecutor) checkHealthLogs(pluginCtx backend.PluginContext) error {
+// CheckHealthLogs checks that the CloudWatch logs API can be queried
+func (e *cloudWatchExecutor) CheckHealthLogs(pluginCtx backend.PluginContext) error {
 	session, err := e.newSession(pluginCtx, defaultRegion)
 	if err != nil {
 		return err
@@ -215,13 +270,13 @@ func (e *cloudWatchExecutor) CheckHealth(ctx context.Context, req *backend.Check
 	metricsTest := "Successfully queried the CloudWatch metrics API."
 	logsTest := "Successfully queried the CloudWatch logs API."
 
-	err := e.checkHealthMetrics(req.PluginContext)
+	err := e.CheckHealthMetrics(req.PluginContext)
 	if err != nil {
 		status = backend.HealthStatusError
 		metricsTest = fmt.Sprintf("CloudWatch metrics query failed: %s", err.Error())
 	}
 
-	err = e.checkHealthLogs(req.PluginContext)
+	err = e.CheckHealthLogs(req.PluginContext)
 	if err != nil {
 		status = backend.HealthStatusError
 		logsTest = fmt.Sprintf("CloudWatch logs query failed: %s", err.Error())
@@ -233,6 +288,17 @@ func (e *cloudWatchExecutor) CheckHealth(ctx context.Context, req *backend.Check
 	}, nil
 }
 
+// accountKey identifies the account of the credentials by the cross account role, or else the access key, and the region
+func accountKey(awsCreds *dsModels.AwsCredential, region string) string {
+	if awsCreds == nil {
+		return region
+	}
+	if awsCreds.CrossAccountRoleArn != "" {
+		return awsCreds.CrossAccountRoleArn + "/" + region
+	}
+	return awsCreds.AccessKey + "/" + region
+}
+
 func (e *cloudWatchExecutor) newSession(pluginCtx backend.PluginContext, region string) (*session.Session, error) {
 	//instance, err := e.getInstance(pluginCtx)
 	//if err != nil {
@@ -281,6 +347,22 @@ func (e *cloudWatchExecutor) getCWClient(pluginCtx backend.PluginContext, region
 	return NewCWClient(sess), nil
 }
 
+func (e *cloudWatchExecutor) getCEClient(pluginCtx backend.PluginContext) (costexploreriface.CostExplorerAPI, error) {
+	sess, err := e.newSession(pluginCtx, costExplorerRegion)
+	if err != nil {
+		return nil, err
+	}
+	return NewCEClient(sess), nil
+}
+
+func (e *cloudWatchExecutor) getXRayClient(pluginCtx backend.PluginContext, region string) (xrayiface.XRayAPI, error) {
+	sess, err := e.newSession(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+	return NewXRayClient(sess), nil
+}
+
 func (e *cloudWatchExecutor) getCWLogsClient(pluginCtx backend.PluginContext, region string) (cloudwatchlogsiface.CloudWatchLogsAPI, error) {
 	sess, err := e.newSession(pluginCtx, region)
 	if err != nil {
@@ -323,26 +405,40 @@ func (e *cloudWatchExecutor) alertQuery(ctx context.Context, logsClient cloudwat
 		QueryId: *startQueryOutput.QueryId,
 	}
 
-	ticker := time.NewTicker(alertPollPeriod)
+	maxWait := e.settings.LogsTimeout.Duration
+	if maxWait <= 0 {
+		maxWait = models2.DefaultLogsTimeout
+	}
+	pollInterval := e.settings.LogsPollInterval.Duration
+	if pollInterval <= 0 {
+		pollInterval = models2.DefaultLogsPollInterval
+	}
+
+	deadline := time.NewTimer(maxWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	attemptCount := 1
-	for range ticker.C {
-		res, err := e.executeGetQueryResults(ctx, logsClient, requestParams)
-		if err != nil {
-			return nil, err
-		}
-		if isTerminated(*res.Status) {
-			return res, err
-		}
-		if attemptCount >= alertMaxAttempts {
-			return res, fmt.Errorf("fetching of query results exceeded max number of attempts")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			// the query is abandoned, so there is no point in letting it use up the concurrent query quota
+			if _, err := e.executeStopQuery(context.Background(), logsClient, requestParams); err != nil {
+				logger.Warn("failed to stop timed out logs alert query", "queryId", requestParams.QueryId, "error", err)
+			}
+			return nil, fmt.Errorf("fetching of query results exceeded max wait of %s", maxWait)
+		case <-ticker.C:
+			res, err := e.executeGetQueryResults(ctx, logsClient, requestParams)
+			if err != nil {
+				return nil, err
+			}
+			if isTerminated(*res.Status) {
+				return res, nil
+			}
 		}
-
-		attemptCount++
 	}
-
-	return nil, nil
 }
 
 func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDataRequest, q backend.DataQuery, region string) (*backend.QueryDataResponse, error) {
@@ -360,12 +456,9 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 	if err != nil {
 		return nil, err
 	}
-	model.Region = region // assigning region exp
-	// licitly. Change for appkube datasource
-	_, fromAlert := req.Headers["FromAlert"]
-	isLogAlertQuery := fromAlert && model.QueryMode == logsQueryMode
+	model.Region = region // assigning region explicitly. Change for appkube datasource
 
-	if isLogAlertQuery {
+	if IsLogAlertQuery(req, q) {
 		return e.executeLogAlertQuery(ctx, req)
 	}
 
@@ -375,6 +468,14 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 		result, err = e.executeAnnotationQuery(req.PluginContext, model, q)
 	case logAction:
 		result, err = e.executeLogActions(ctx, logger, req)
+	case alarmStatusQuery:
+		result, err = e.executeAlarmStatusQuery(req.PluginContext, model, q)
+	case inventoryQuery:
+		result, err = e.executeInventoryQuery(req.PluginContext, model, q)
+	case costQuery:
+		result, err = e.executeCostQuery(ctx, req.PluginContext, q)
+	case xrayQuery:
+		result, err = e.executeXRayQuery(ctx, req.PluginContext, model.Region, q)
 	case timeSeriesQuery:
 		fallthrough
 	default:
@@ -384,59 +485,103 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 	return result, err
 }
 
+// IsCostQuery reports whether q is a cost query, which may use the tags of its cloud element
+func IsCostQuery(q backend.DataQuery) bool {
+	var model DataQueryJson
+	if err := json.Unmarshal(q.JSON, &model); err != nil {
+		return false
+	}
+	return model.QueryType == costQuery
+}
+
+// IsLogAlertQuery reports whether q is a logs query sent by Grafana alerting. Alerting runs on the
+// backend only, so polling for the results of such queries has to be done here.
+func IsLogAlertQuery(req *backend.QueryDataRequest, q backend.DataQuery) bool {
+	if _, fromAlert := req.Headers["FromAlert"]; !fromAlert {
+		return false
+	}
+	var model DataQueryJson
+	if err := json.Unmarshal(q.JSON, &model); err != nil {
+		return false
+	}
+	return model.QueryMode == logsQueryMode
+}
+
+// executeLogAlertQuery runs every query of req concurrently, each under its own RefID. A failing
+// query only fails its own response.
 func (e *cloudWatchExecutor) executeLogAlertQuery(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
+	resultChan := make(chan backend.Responses, len(req.Queries))
+	eg, ectx := errgroup.WithContext(ctx)
+
 	for _, q := range req.Queries {
 		var model LogQueryJson
-		err := json.Unmarshal(q.JSON, &model)
-		if err != nil {
+		if err := json.Unmarshal(q.JSON, &model); err != nil {
+			resultChan <- backend.Responses{
+				q.RefID: backend.DataResponse{Error: fmt.Errorf("%v: %w", "failed to parse the logs query", err)},
+			}
 			continue
 		}
 
-		model.Subtype = "StartQuery"
-		model.QueryString = model.Expression
-
-		region := model.Region
-		if model.Region == "" || region == defaultRegion {
-			//instance, err := e.getInstance(req.PluginContext)
-			if err != nil {
-				return nil, err
+		query := q
+		eg.Go(func() error {
+			frames, err := e.executeSingleLogAlertQuery(ectx, req.PluginContext, model, query)
+			resultChan <- backend.Responses{
+				query.RefID: backend.DataResponse{Frames: frames, Error: err},
 			}
-			model.Region = e.AwsCreds.Region //instance.Settings.Region
-		}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
 
-		logsClient, err := e.getCWLogsClient(req.PluginContext, region)
-		if err != nil {
-			return nil, err
-		}
+	close(resultChan)
 
-		getQueryResultsOutput, err := e.alertQuery(ctx, logsClient, q, model)
-		if err != nil {
-			return nil, err
+	for result := range resultChan {
+		for refID, response := range result {
+			resp.Responses[refID] = response
 		}
+	}
 
-		dataframe, err := LogsResultsToDataframes(getQueryResultsOutput)
-		if err != nil {
-			return nil, err
-		}
+	return resp, nil
+}
 
-		var frames []*data.Frame
-		if len(model.StatsGroups) > 0 && len(dataframe.Fields) > 0 {
-			frames, err = groupResults(dataframe, model.StatsGroups)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			frames = data.Frames{dataframe}
-		}
+func (e *cloudWatchExecutor) executeSingleLogAlertQuery(ctx context.Context, pluginCtx backend.PluginContext, model LogQueryJson,
+	query backend.DataQuery) (data.Frames, error) {
+	model.Subtype = "StartQuery"
+	if model.Expression != "" {
+		model.QueryString = model.Expression
+	}
 
-		respD := resp.Responses["A"]
-		respD.Frames = frames
-		resp.Responses["A"] = respD
+	if model.Region == "" || model.Region == defaultRegion {
+		model.Region = e.AwsCreds.Region
 	}
 
-	return resp, nil
+	logsClient, err := e.getCWLogsClient(pluginCtx, model.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	getQueryResultsOutput, err := e.alertQuery(ctx, logsClient, query, model)
+	if err != nil {
+		return nil, err
+	}
+
+	dataframe, err := LogsResultsToDataframes(getQueryResultsOutput)
+	if err != nil {
+		return nil, err
+	}
+	dataframe.Name = query.RefID
+	dataframe.RefID = query.RefID
+
+	frames, err := logsFrameToAlertFrames(dataframe, model.StatsGroups)
+	if err != nil {
+		return nil, err
+	}
+	addLogsAlertDeepLinks(frames, model, query.TimeRange)
+	return frames, nil
 }
 
 //func (e *cloudWatchExecutor) getInstance(pluginCtx backend.PluginContext) (*DataSource, error) {
@@ -482,6 +627,20 @@ var NewCWClient = func(sess *session.Session) cloudwatchiface.CloudWatchAPI {
 	return cloudwatch.New(sess)
 }
 
+// NewCEClient is a Cost Explorer client factory. Cost Explorer has a single endpoint in us-east-1.
+//
+// Stubbable by tests.
+var NewCEClient = func(sess *session.Session) costexploreriface.CostExplorerAPI {
+	return costexplorer.New(sess, aws.NewConfig().WithRegion(costExplorerRegion))
+}
+
+// NewXRayClient is an X-Ray client factory.
+//
+// Stubbable by tests.
+var NewXRayClient = func(sess *session.Session) xrayiface.XRayAPI {
+	return xray.New(sess)
+}
+
 // newCWLogsClient is a CloudWatch logs client factory.
 // Stubbable by tests.
 var newCWLogsClient = func(sess *session.Session) cloudwatchlogsiface.CloudWatchLogsAPI {
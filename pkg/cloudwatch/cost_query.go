@@ -0,0 +1,436 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/costexplorer/costexploreriface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Cost query modes
+const (
+	costModeUsage    = "usage"
+	costModeForecast = "forecast"
+)
+
+const (
+	// costExplorerRegion is the region of the Cost Explorer endpoint, which is global
+	costExplorerRegion = "us-east-1"
+
+	defaultCostMetric      = "UnblendedCost"
+	defaultCostGranularity = costexplorer.GranularityDaily
+	// maxCostGroupBy is the number of group by definitions accepted by GetCostAndUsage
+	maxCostGroupBy = 2
+
+	costDateFormat     = "2006-01-02"
+	costDateTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// costGroupByKeys maps the group by names of the query editor to the Cost Explorer dimensions
+var costGroupByKeys = map[string]string{
+	"service":       costexplorer.DimensionService,
+	"linkedAccount": costexplorer.DimensionLinkedAccount,
+	"region":        costexplorer.DimensionRegion,
+	"usageType":     costexplorer.DimensionUsageType,
+}
+
+// CostQueryJson is the model of a cost query
+type CostQueryJson struct {
+	// CostMode is usage (GetCostAndUsage) or forecast (GetCostForecast)
+	CostMode string `json:"costMode"`
+	// Granularity is DAILY, MONTHLY or HOURLY
+	Granularity string `json:"granularity"`
+	// CostMetrics are the Cost Explorer metrics, such as UnblendedCost or UsageQuantity. A forecast uses the first one.
+	CostMetrics []string `json:"costMetrics"`
+	// GroupBy holds up to two of service, linkedAccount, region, usageType, another Cost Explorer dimension or
+	// tag:<key>
+	GroupBy []string `json:"groupBy"`
+	// Filters restrict the costs to Cost Explorer dimension values, such as {"SERVICE": ["Amazon Elastic Compute Cloud - Compute"]}
+	Filters map[string][]string `json:"filters"`
+	// Tags restrict the costs to resources with the tag values
+	Tags map[string][]string `json:"tags"`
+	// ElementTags restricts the costs to resources tagged like the cloud element of the query
+	ElementTags bool `json:"elementTags"`
+	// PredictionIntervalLevel is the confidence of the forecast bounds, between 51 and 99
+	PredictionIntervalLevel int64 `json:"predictionIntervalLevel"`
+}
+
+// executeCostQuery returns the costs of the account over the time range of the query, one time series per metric and group
+func (e *cloudWatchExecutor) executeCostQuery(ctx context.Context, pluginCtx backend.PluginContext, query backend.DataQuery) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+
+	var model CostQueryJson
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return nil, fmt.Errorf("invalid cost query: %w", err)
+	}
+
+	client, err := e.getCEClient(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames data.Frames
+	switch model.CostMode {
+	case "", costModeUsage:
+		frames, err = e.costAndUsage(ctx, client, model, query)
+	case costModeForecast:
+		frames, err = e.costForecast(ctx, client, model, query)
+	default:
+		return result, fmt.Errorf("invalid cost query: unknown cost mode %q", model.CostMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	respD := result.Responses[query.RefID]
+	respD.Frames = append(respD.Frames, frames...)
+	result.Responses[query.RefID] = respD
+
+	return result, nil
+}
+
+func (e *cloudWatchExecutor) costAndUsage(ctx context.Context, client costexploreriface.CostExplorerAPI, model CostQueryJson, query backend.DataQuery) (data.Frames, error) {
+	granularity, err := costGranularity(model.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	groupBy, err := costGroupBy(model.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	metrics := model.CostMetrics
+	if len(metrics) == 0 {
+		metrics = []string{defaultCostMetric}
+	}
+
+	input := &costexplorer.GetCostAndUsageInput{
+		Granularity: aws.String(granularity),
+		Metrics:     aws.StringSlice(metrics),
+		GroupBy:     groupBy,
+		TimePeriod:  costTimePeriod(query.TimeRange.From, query.TimeRange.To, granularity),
+		Filter:      costFilter(model, e.elementTags),
+	}
+
+	series := newCostSeries(query.RefID)
+	for {
+		output, err := client.GetCostAndUsageWithContext(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "failed to call cost explorer", err)
+		}
+		for _, byTime := range output.ResultsByTime {
+			timestamp, err := costTime(byTime.TimePeriod)
+			if err != nil {
+				return nil, err
+			}
+			if len(groupBy) == 0 {
+				for _, metric := range metrics {
+					series.add(metric, nil, nil, timestamp, byTime.Total[metric])
+				}
+				continue
+			}
+			for _, group := range byTime.Groups {
+				for _, metric := range metrics {
+					series.add(metric, groupBy, group.Keys, timestamp, group.Metrics[metric])
+				}
+			}
+		}
+		if output.NextPageToken == nil || *output.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	return series.frames(), nil
+}
+
+func (e *cloudWatchExecutor) costForecast(ctx context.Context, client costexploreriface.CostExplorerAPI, model CostQueryJson, query backend.DataQuery) (data.Frames, error) {
+	granularity, err := costGranularity(model.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	if granularity == costexplorer.GranularityHourly {
+		return nil, fmt.Errorf("invalid cost query: a forecast is only available with DAILY or MONTHLY granularity")
+	}
+	if len(model.GroupBy) > 0 {
+		return nil, fmt.Errorf("invalid cost query: a forecast can't be grouped")
+	}
+	metric := defaultCostMetric
+	if len(model.CostMetrics) > 0 {
+		metric = model.CostMetrics[0]
+	}
+
+	// a forecast starts today at the earliest
+	from := query.TimeRange.From
+	if today := time.Now().UTC().Truncate(24 * time.Hour); from.Before(today) {
+		from = today
+	}
+	if !from.Before(query.TimeRange.To) {
+		return data.Frames{}, nil
+	}
+
+	input := &costexplorer.GetCostForecastInput{
+		Granularity: aws.String(granularity),
+		Metric:      aws.String(forecastMetric(metric)),
+		TimePeriod:  costTimePeriod(from, query.TimeRange.To, granularity),
+		Filter:      costFilter(model, e.elementTags),
+	}
+	if model.PredictionIntervalLevel > 0 {
+		input.PredictionIntervalLevel = aws.Int64(model.PredictionIntervalLevel)
+	}
+
+	output, err := client.GetCostForecastWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call cost explorer", err)
+	}
+
+	var unit string
+	if output.Total != nil {
+		unit = aws.StringValue(output.Total.Unit)
+	}
+	times := make([]time.Time, 0, len(output.ForecastResultsByTime))
+	means := make([]*float64, 0, len(output.ForecastResultsByTime))
+	lower := make([]*float64, 0, len(output.ForecastResultsByTime))
+	upper := make([]*float64, 0, len(output.ForecastResultsByTime))
+	for _, forecast := range output.ForecastResultsByTime {
+		timestamp, err := costTime(forecast.TimePeriod)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, timestamp)
+		means = append(means, parseCostAmount(forecast.MeanValue))
+		lower = append(lower, parseCostAmount(forecast.PredictionIntervalLowerBound))
+		upper = append(upper, parseCostAmount(forecast.PredictionIntervalUpperBound))
+	}
+
+	frame := data.NewFrame(metric,
+		data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+		data.NewField("mean", nil, means).SetConfig(&data.FieldConfig{DisplayNameFromDS: metric + " forecast", Unit: costUnit(unit)}),
+		data.NewField("lower", nil, lower).SetConfig(&data.FieldConfig{DisplayNameFromDS: metric + " forecast lower bound", Unit: costUnit(unit)}),
+		data.NewField("upper", nil, upper).SetConfig(&data.FieldConfig{DisplayNameFromDS: metric + " forecast upper bound", Unit: costUnit(unit)}),
+	)
+	frame.RefID = query.RefID
+	if output.Total != nil {
+		frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{"total": parseCostAmount(output.Total.Amount)}}
+	}
+	return data.Frames{frame}, nil
+}
+
+func costGranularity(granularity string) (string, error) {
+	if granularity == "" {
+		return defaultCostGranularity, nil
+	}
+	granularity = strings.ToUpper(granularity)
+	for _, g := range costexplorer.Granularity_Values() {
+		if g == granularity {
+			return g, nil
+		}
+	}
+	return "", fmt.Errorf("invalid cost query: unknown granularity %q", granularity)
+}
+
+func costGroupBy(groupBy []string) ([]*costexplorer.GroupDefinition, error) {
+	if len(groupBy) > maxCostGroupBy {
+		return nil, fmt.Errorf("invalid cost query: at most %d group by are allowed", maxCostGroupBy)
+	}
+	definitions := make([]*costexplorer.GroupDefinition, 0, len(groupBy))
+	for _, key := range groupBy {
+		if tag := strings.TrimPrefix(key, "tag:"); tag != key {
+			definitions = append(definitions, &costexplorer.GroupDefinition{Type: aws.String(costexplorer.GroupDefinitionTypeTag), Key: aws.String(tag)})
+			continue
+		}
+		if dimension, ok := costGroupByKeys[key]; ok {
+			key = dimension
+		}
+		definitions = append(definitions, &costexplorer.GroupDefinition{Type: aws.String(costexplorer.GroupDefinitionTypeDimension), Key: aws.String(key)})
+	}
+	return definitions, nil
+}
+
+// costFilter combines the dimension and tag filters of the query with the tags of the cloud element
+func costFilter(model CostQueryJson, elementTags map[string]string) *costexplorer.Expression {
+	expressions := make([]*costexplorer.Expression, 0)
+	for _, key := range sortedFilterKeys(model.Filters) {
+		expressions = append(expressions, &costexplorer.Expression{Dimensions: &costexplorer.DimensionValues{
+			Key:    aws.String(key),
+			Values: aws.StringSlice(model.Filters[key]),
+		}})
+	}
+	tags := make(map[string][]string, len(model.Tags)+len(elementTags))
+	for key, values := range model.Tags {
+		tags[key] = values
+	}
+	if model.ElementTags {
+		for key, value := range elementTags {
+			tags[key] = []string{value}
+		}
+	}
+	for _, key := range sortedFilterKeys(tags) {
+		expressions = append(expressions, &costexplorer.Expression{Tags: &costexplorer.TagValues{
+			Key:          aws.String(key),
+			Values:       aws.StringSlice(tags[key]),
+			MatchOptions: aws.StringSlice([]string{costexplorer.MatchOptionEquals}),
+		}})
+	}
+
+	switch len(expressions) {
+	case 0:
+		return nil
+	case 1:
+		return expressions[0]
+	}
+	return &costexplorer.Expression{And: expressions}
+}
+
+func sortedFilterKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key, values := range m {
+		if len(values) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// costTimePeriod returns the period from start up to end. The end date is exclusive, so the day of end is included
+// unless end is midnight, and a day is added when it falls on the start date.
+func costTimePeriod(from time.Time, to time.Time, granularity string) *costexplorer.DateInterval {
+	from, to = from.UTC(), to.UTC()
+	if granularity == costexplorer.GranularityHourly {
+		return &costexplorer.DateInterval{
+			Start: aws.String(from.Truncate(time.Hour).Format(costDateTimeFormat)),
+			End:   aws.String(to.Truncate(time.Hour).Add(time.Hour).Format(costDateTimeFormat)),
+		}
+	}
+	start := from.Format(costDateFormat)
+	endDay := to.Truncate(24 * time.Hour)
+	if !endDay.Equal(to) {
+		endDay = endDay.AddDate(0, 0, 1)
+	}
+	end := endDay.Format(costDateFormat)
+	if end <= start {
+		end = from.AddDate(0, 0, 1).Format(costDateFormat)
+	}
+	return &costexplorer.DateInterval{Start: aws.String(start), End: aws.String(end)}
+}
+
+func costTime(period *costexplorer.DateInterval) (time.Time, error) {
+	if period == nil || period.Start == nil {
+		return time.Time{}, fmt.Errorf("cost explorer result without time period")
+	}
+	start := *period.Start
+	if t, err := time.Parse(costDateFormat, start); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, start)
+}
+
+// forecastMetric converts a GetCostAndUsage metric name, such as UnblendedCost, to the name GetCostForecast expects,
+// such as UNBLENDED_COST
+func forecastMetric(metric string) string {
+	var sb strings.Builder
+	for i, r := range metric {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			sb.WriteRune('_')
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToUpper(sb.String())
+}
+
+func parseCostAmount(amount *string) *float64 {
+	if amount == nil {
+		return nil
+	}
+	v, err := strconv.ParseFloat(*amount, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// costUnit maps Cost Explorer units to Grafana units
+func costUnit(unit string) string {
+	switch unit {
+	case "USD":
+		return "currencyUSD"
+	case "EUR":
+		return "currencyEUR"
+	}
+	return unit
+}
+
+// costSeries collects the amounts of each metric and group into time series
+type costSeries struct {
+	refID  string
+	keys   []string
+	series map[string]*costSerie
+}
+
+type costSerie struct {
+	name   string
+	labels data.Labels
+	unit   string
+	times  []time.Time
+	values []*float64
+}
+
+func newCostSeries(refID string) *costSeries {
+	return &costSeries{refID: refID, series: make(map[string]*costSerie)}
+}
+
+func (s *costSeries) add(metric string, groupBy []*costexplorer.GroupDefinition, groupKeys []*string, timestamp time.Time, value *costexplorer.MetricValue) {
+	labels := data.Labels{"metric": metric}
+	nameParts := []string{metric}
+	for i, definition := range groupBy {
+		if i >= len(groupKeys) {
+			break
+		}
+		key := aws.StringValue(groupKeys[i])
+		if aws.StringValue(definition.Type) == costexplorer.GroupDefinitionTypeTag {
+			// tag groups are returned as <tag key>$<tag value>
+			key = strings.TrimPrefix(key, aws.StringValue(definition.Key)+"$")
+		}
+		labels[strings.ToLower(aws.StringValue(definition.Key))] = key
+		nameParts = append(nameParts, key)
+	}
+	name := strings.Join(nameParts, " ")
+
+	serie, ok := s.series[name]
+	if !ok {
+		serie = &costSerie{name: name, labels: labels}
+		s.series[name] = serie
+		s.keys = append(s.keys, name)
+	}
+	var amount *float64
+	if value != nil {
+		amount = parseCostAmount(value.Amount)
+		if serie.unit == "" {
+			serie.unit = aws.StringValue(value.Unit)
+		}
+	}
+	serie.times = append(serie.times, timestamp)
+	serie.values = append(serie.values, amount)
+}
+
+func (s *costSeries) frames() data.Frames {
+	frames := make(data.Frames, 0, len(s.keys))
+	for _, key := range s.keys {
+		serie := s.series[key]
+		valueField := data.NewField(data.TimeSeriesValueFieldName, serie.labels, serie.values)
+		valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: serie.name, Unit: costUnit(serie.unit)})
+		frame := data.NewFrame(serie.name, data.NewField(data.TimeSeriesTimeFieldName, nil, serie.times), valueField)
+		frame.RefID = s.refID
+		frames = append(frames, frame)
+	}
+	return frames
+}
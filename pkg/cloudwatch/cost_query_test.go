@@ -0,0 +1,253 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	dsModels "github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/costexplorer/costexploreriface"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSessionCache struct{}
+
+func (fakeSessionCache) GetSession(awsds.SessionConfig) (*session.Session, error) {
+	return &session.Session{}, nil
+}
+
+func newTestExecutor() *cloudWatchExecutor {
+	return &cloudWatchExecutor{
+		AwsCreds: &dsModels.AwsCredential{Region: "eu-west-1"},
+		sessions: fakeSessionCache{},
+	}
+}
+
+type fakeCEClient struct {
+	costexploreriface.CostExplorerAPI
+	usageInputs    []*costexplorer.GetCostAndUsageInput
+	usageOutputs   []*costexplorer.GetCostAndUsageOutput
+	forecastInput  *costexplorer.GetCostForecastInput
+	forecastOutput *costexplorer.GetCostForecastOutput
+}
+
+func (c *fakeCEClient) GetCostAndUsageWithContext(_ aws.Context, input *costexplorer.GetCostAndUsageInput, _ ...request.Option) (*costexplorer.GetCostAndUsageOutput, error) {
+	copied := *input
+	c.usageInputs = append(c.usageInputs, &copied)
+	output := c.usageOutputs[0]
+	c.usageOutputs = c.usageOutputs[1:]
+	return output, nil
+}
+
+func (c *fakeCEClient) GetCostForecastWithContext(_ aws.Context, input *costexplorer.GetCostForecastInput, _ ...request.Option) (*costexplorer.GetCostForecastOutput, error) {
+	c.forecastInput = input
+	return c.forecastOutput, nil
+}
+
+func stubCEClient(t *testing.T, client *fakeCEClient) {
+	t.Helper()
+	origNewCEClient := NewCEClient
+	t.Cleanup(func() { NewCEClient = origNewCEClient })
+	NewCEClient = func(*session.Session) costexploreriface.CostExplorerAPI {
+		return client
+	}
+}
+
+func newCostQuery(t *testing.T, model map[string]interface{}, from, to time.Time) backend.DataQuery {
+	t.Helper()
+	model["queryType"] = costQuery
+	b, err := json.Marshal(model)
+	require.NoError(t, err)
+	return backend.DataQuery{RefID: "A", JSON: b, TimeRange: backend.TimeRange{From: from, To: to}}
+}
+
+func metricValue(amount string) *costexplorer.MetricValue {
+	return &costexplorer.MetricValue{Amount: aws.String(amount), Unit: aws.String("USD")}
+}
+
+func TestExecuteCostQuery_GroupedUsage(t *testing.T) {
+	client := &fakeCEClient{usageOutputs: []*costexplorer.GetCostAndUsageOutput{
+		{
+			ResultsByTime: []*costexplorer.ResultByTime{{
+				TimePeriod: &costexplorer.DateInterval{Start: aws.String("2023-03-01"), End: aws.String("2023-03-02")},
+				Groups: []*costexplorer.Group{
+					{Keys: aws.StringSlice([]string{"Amazon EC2", "team$payments"}), Metrics: map[string]*costexplorer.MetricValue{"UnblendedCost": metricValue("1.5")}},
+					{Keys: aws.StringSlice([]string{"Amazon S3", "team$payments"}), Metrics: map[string]*costexplorer.MetricValue{"UnblendedCost": metricValue("0.25")}},
+				},
+			}},
+			NextPageToken: aws.String("next"),
+		},
+		{
+			ResultsByTime: []*costexplorer.ResultByTime{{
+				TimePeriod: &costexplorer.DateInterval{Start: aws.String("2023-03-02"), End: aws.String("2023-03-03")},
+				Groups: []*costexplorer.Group{
+					{Keys: aws.StringSlice([]string{"Amazon EC2", "team$payments"}), Metrics: map[string]*costexplorer.MetricValue{"UnblendedCost": metricValue("2")}},
+				},
+			}},
+		},
+	}}
+	stubCEClient(t, client)
+
+	e := newTestExecutor()
+	e.SetElementTags(map[string]string{"env": "prod"})
+	from := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 3, 3, 0, 0, 0, 0, time.UTC)
+	query := newCostQuery(t, map[string]interface{}{
+		"granularity": "daily",
+		"groupBy":     []string{"service", "tag:team"},
+		"filters":     map[string][]string{"REGION": {"eu-west-1"}},
+		"elementTags": true,
+	}, from, to)
+
+	res, err := e.executeCostQuery(context.Background(), backend.PluginContext{}, query)
+	require.NoError(t, err)
+
+	require.Len(t, client.usageInputs, 2)
+	input := client.usageInputs[0]
+	assert.Equal(t, "DAILY", *input.Granularity)
+	assert.Equal(t, "2023-03-01", *input.TimePeriod.Start)
+	assert.Equal(t, "2023-03-03", *input.TimePeriod.End)
+	assert.Equal(t, []string{"UnblendedCost"}, aws.StringValueSlice(input.Metrics))
+	require.Len(t, input.GroupBy, 2)
+	assert.Equal(t, "SERVICE", *input.GroupBy[0].Key)
+	assert.Equal(t, "TAG", *input.GroupBy[1].Type)
+	assert.Equal(t, "team", *input.GroupBy[1].Key)
+	require.Len(t, input.Filter.And, 2)
+	assert.Equal(t, "REGION", *input.Filter.And[0].Dimensions.Key)
+	assert.Equal(t, "env", *input.Filter.And[1].Tags.Key)
+	assert.Equal(t, []string{"prod"}, aws.StringValueSlice(input.Filter.And[1].Tags.Values))
+	assert.Equal(t, "next", *client.usageInputs[1].NextPageToken)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 2)
+	assert.Equal(t, "UnblendedCost Amazon EC2 payments", frames[0].Name)
+	assert.Equal(t, data.Labels{"metric": "UnblendedCost", "service": "Amazon EC2", "team": "payments"}, frames[0].Fields[1].Labels)
+	assert.Equal(t, "currencyUSD", frames[0].Fields[1].Config.Unit)
+	require.Equal(t, 2, frames[0].Rows())
+	assert.Equal(t, 1.5, *frames[0].Fields[1].At(0).(*float64))
+	assert.Equal(t, 2.0, *frames[0].Fields[1].At(1).(*float64))
+	assert.Equal(t, time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC), frames[0].Fields[0].At(1))
+	assert.Equal(t, 1, frames[1].Rows())
+}
+
+func TestExecuteCostQuery_TotalUsage(t *testing.T) {
+	client := &fakeCEClient{usageOutputs: []*costexplorer.GetCostAndUsageOutput{{
+		ResultsByTime: []*costexplorer.ResultByTime{{
+			TimePeriod: &costexplorer.DateInterval{Start: aws.String("2023-03-01"), End: aws.String("2023-04-01")},
+			Total: map[string]*costexplorer.MetricValue{
+				"UnblendedCost": metricValue("100"),
+				"UsageQuantity": {Amount: aws.String("42"), Unit: aws.String("N/A")},
+			},
+		}},
+	}}}
+	stubCEClient(t, client)
+
+	day := time.Date(2023, 3, 15, 10, 0, 0, 0, time.UTC)
+	query := newCostQuery(t, map[string]interface{}{
+		"granularity": "MONTHLY",
+		"costMetrics": []string{"UnblendedCost", "UsageQuantity"},
+	}, day, day.Add(time.Hour))
+
+	res, err := newTestExecutor().executeCostQuery(context.Background(), backend.PluginContext{}, query)
+	require.NoError(t, err)
+
+	input := client.usageInputs[0]
+	assert.Nil(t, input.Filter)
+	assert.Equal(t, "2023-03-15", *input.TimePeriod.Start)
+	assert.Equal(t, "2023-03-16", *input.TimePeriod.End)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 2)
+	assert.Equal(t, "UnblendedCost", frames[0].Name)
+	assert.Equal(t, 100.0, *frames[0].Fields[1].At(0).(*float64))
+	assert.Equal(t, "UsageQuantity", frames[1].Name)
+	assert.Equal(t, 42.0, *frames[1].Fields[1].At(0).(*float64))
+}
+
+func TestExecuteCostQuery_Forecast(t *testing.T) {
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	client := &fakeCEClient{forecastOutput: &costexplorer.GetCostForecastOutput{
+		Total: metricValue("30"),
+		ForecastResultsByTime: []*costexplorer.ForecastResult{{
+			TimePeriod:                   &costexplorer.DateInterval{Start: aws.String(tomorrow.Format("2006-01-02"))},
+			MeanValue:                    aws.String("10"),
+			PredictionIntervalLowerBound: aws.String("8"),
+			PredictionIntervalUpperBound: aws.String("12"),
+		}},
+	}}
+	stubCEClient(t, client)
+
+	query := newCostQuery(t, map[string]interface{}{
+		"costMode":                "forecast",
+		"costMetrics":             []string{"NetAmortizedCost"},
+		"predictionIntervalLevel": 80,
+	}, tomorrow.AddDate(0, 0, -10), tomorrow.AddDate(0, 0, 3))
+
+	res, err := newTestExecutor().executeCostQuery(context.Background(), backend.PluginContext{}, query)
+	require.NoError(t, err)
+
+	input := client.forecastInput
+	assert.Equal(t, "NET_AMORTIZED_COST", *input.Metric)
+	assert.Equal(t, int64(80), *input.PredictionIntervalLevel)
+	assert.Equal(t, tomorrow.AddDate(0, 0, -1).Format("2006-01-02"), *input.TimePeriod.Start)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 1)
+	require.Len(t, frames[0].Fields, 4)
+	assert.Equal(t, tomorrow, frames[0].Fields[0].At(0))
+	assert.Equal(t, 10.0, *frames[0].Fields[1].At(0).(*float64))
+	assert.Equal(t, 8.0, *frames[0].Fields[2].At(0).(*float64))
+	assert.Equal(t, 12.0, *frames[0].Fields[3].At(0).(*float64))
+}
+
+func TestCostTimePeriod(t *testing.T) {
+	day := func(d int, hour int) time.Time { return time.Date(2023, 3, d, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		granularity string
+		start, end  string
+	}{
+		{name: "up to midnight", from: day(1, 0), to: day(3, 0), granularity: costexplorer.GranularityDaily, start: "2023-03-01", end: "2023-03-03"},
+		{name: "up to the middle of a day", from: day(1, 0), to: day(3, 15), granularity: costexplorer.GranularityDaily, start: "2023-03-01", end: "2023-03-04"},
+		{name: "within a day", from: day(15, 10), to: day(15, 11), granularity: costexplorer.GranularityMonthly, start: "2023-03-15", end: "2023-03-16"},
+		{name: "up to the next midnight", from: day(15, 10), to: day(16, 0), granularity: costexplorer.GranularityDaily, start: "2023-03-15", end: "2023-03-16"},
+		{name: "in another time zone", from: day(1, 0).In(time.FixedZone("UTC+2", 2*3600)), to: day(2, 23).In(time.FixedZone("UTC+2", 2*3600)),
+			granularity: costexplorer.GranularityDaily, start: "2023-03-01", end: "2023-03-03"},
+		{name: "hourly", from: day(1, 0).Add(10 * time.Minute), to: day(1, 2).Add(10 * time.Minute), granularity: costexplorer.GranularityHourly,
+			start: "2023-03-01T00:00:00Z", end: "2023-03-01T03:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := costTimePeriod(tt.from, tt.to, tt.granularity)
+			assert.Equal(t, tt.start, aws.StringValue(period.Start))
+			assert.Equal(t, tt.end, aws.StringValue(period.End))
+		})
+	}
+}
+
+func TestExecuteCostQuery_InvalidQueries(t *testing.T) {
+	stubCEClient(t, &fakeCEClient{})
+	now := time.Now()
+	tests := map[string]map[string]interface{}{
+		"unknown mode":        {"costMode": "budget"},
+		"unknown granularity": {"granularity": "WEEKLY"},
+		"too many group by":   {"groupBy": []string{"service", "region", "linkedAccount"}},
+		"grouped forecast":    {"costMode": "forecast", "groupBy": []string{"service"}},
+	}
+	for name, model := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTestExecutor().executeCostQuery(context.Background(), backend.PluginContext{}, newCostQuery(t, model, now.Add(-time.Hour), now.Add(48*time.Hour)))
+			assert.Error(t, err)
+		})
+	}
+}
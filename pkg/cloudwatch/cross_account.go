@@ -0,0 +1,139 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/services"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Labels added to the series of cross-account queries
+const (
+	accountIdLabel    = "account_id"
+	accountLabelLabel = "account_label"
+)
+
+const allAccounts = "all"
+
+// accountsCacheTTL is how long the accounts of a monitoring account are reused
+const accountsCacheTTL = 5 * time.Minute
+
+type accountsCacheEntry struct {
+	accounts []resources.Account
+	expires  time.Time
+}
+
+// accountsCache holds the accounts listed for each datasource and region
+var accountsCache = struct {
+	sync.Mutex
+	entries map[string]accountsCacheEntry
+}{entries: make(map[string]accountsCacheEntry)}
+
+// listAccounts returns the monitoring account and its linked source accounts.
+//
+// Stubbable by tests.
+var listAccounts = func(e *cloudWatchExecutor, pluginCtx backend.PluginContext, region string) ([]resources.Account, error) {
+	sess, err := e.newSession(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := services.NewAccountsService(NewOAMAPI(sess)).GetAccountsForCurrentUserOrRole()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]resources.Account, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, account.Value)
+	}
+	return result, nil
+}
+
+// cachedAccounts returns the accounts of the monitoring account, listing them once per TTL for each datasource
+// and region
+func (e *cloudWatchExecutor) cachedAccounts(pluginCtx backend.PluginContext, region string) ([]resources.Account, error) {
+	if region == "" || region == defaultRegion {
+		region = e.AwsCreds.Region
+	}
+	datasource := ""
+	if pluginCtx.DataSourceInstanceSettings != nil {
+		datasource = pluginCtx.DataSourceInstanceSettings.UID
+	}
+	key := datasource + "|" + accountKey(e.AwsCreds, region)
+
+	accountsCache.Lock()
+	entry, ok := accountsCache.entries[key]
+	accountsCache.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.accounts, nil
+	}
+
+	accounts, err := listAccounts(e, pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	accountsCache.Lock()
+	for k, e := range accountsCache.entries {
+		if now.After(e.expires) {
+			delete(accountsCache.entries, k)
+		}
+	}
+	accountsCache.entries[key] = accountsCacheEntry{accounts: accounts, expires: now.Add(accountsCacheTTL)}
+	accountsCache.Unlock()
+	return accounts, nil
+}
+
+// expandAccountQueries makes cross-account queries account aware. A search across all accounts is split into one
+// search per account of the monitoring account, so that each series can be labelled with the account it comes from.
+// Queries for a single account get the label of the account.
+func (e *cloudWatchExecutor) expandAccountQueries(pluginCtx backend.PluginContext, query *models.CloudWatchQuery) []*models.CloudWatchQuery {
+	if query.AccountId == nil || *query.AccountId == "" {
+		return []*models.CloudWatchQuery{query}
+	}
+
+	accounts, err := e.cachedAccounts(pluginCtx, query.Region)
+	if err != nil {
+		// the query still runs, its series just miss the account labels
+		logger.Warn("unable to list the accounts of the monitoring account", "error", err)
+		return []*models.CloudWatchQuery{query}
+	}
+
+	if *query.AccountId != allAccounts {
+		for _, account := range accounts {
+			if account.Id == *query.AccountId {
+				query.AccountLabel = account.Label
+			}
+		}
+		return []*models.CloudWatchQuery{query}
+	}
+
+	if len(accounts) == 0 || !query.IsInferredSearchExpression() {
+		return []*models.CloudWatchQuery{query}
+	}
+	queries := make([]*models.CloudWatchQuery, 0, len(accounts))
+	for i, account := range accounts {
+		accountQuery := *query
+		accountQuery.Id = fmt.Sprintf("%s_account%d", query.Id, i)
+		accountQuery.AccountId = aws.String(account.Id)
+		accountQuery.AccountLabel = account.Label
+		queries = append(queries, &accountQuery)
+	}
+	return queries
+}
+
+// addAccountLabels adds the account the series of a cross-account query come from to its labels
+func addAccountLabels(labels data.Labels, query *models.CloudWatchQuery) {
+	if query.AccountId == nil || *query.AccountId == "" || *query.AccountId == allAccounts {
+		return
+	}
+	labels[accountIdLabel] = *query.AccountId
+	if query.AccountLabel != "" {
+		labels[accountLabelLabel] = query.AccountLabel
+	}
+}
@@ -0,0 +1,173 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandAccountQueries(t *testing.T) {
+	calls := map[string]int{}
+	fail := false
+	origListAccounts := listAccounts
+	t.Cleanup(func() { listAccounts = origListAccounts })
+	listAccounts = func(e *cloudWatchExecutor, pluginCtx backend.PluginContext, region string) ([]resources.Account, error) {
+		calls[pluginCtx.DataSourceInstanceSettings.UID+"/"+region]++
+		if fail {
+			return nil, errors.New("access denied")
+		}
+		return []resources.Account{{Id: "111", Label: "monitoring"}, {Id: "222", Label: "source"}}, nil
+	}
+	pluginCtx := func(uid string) backend.PluginContext {
+		return backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: uid}}
+	}
+	executor := newTestExecutor()
+	accountsCache.Lock()
+	accountsCache.entries = make(map[string]accountsCacheEntry)
+	accountsCache.Unlock()
+
+	query := &models.CloudWatchQuery{Id: "a", Region: "default", AccountId: aws.String("222")}
+	queries := executor.expandAccountQueries(pluginCtx("ds1"), query)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "source", queries[0].AccountLabel)
+
+	executor.expandAccountQueries(pluginCtx("ds1"), &models.CloudWatchQuery{Id: "b", Region: "eu-west-1", AccountId: aws.String("111")})
+	executor.expandAccountQueries(pluginCtx("ds2"), &models.CloudWatchQuery{Id: "c", Region: "eu-west-1", AccountId: aws.String("111")})
+	executor.expandAccountQueries(pluginCtx("ds1"), &models.CloudWatchQuery{Id: "d", Region: "us-east-1", AccountId: aws.String("111")})
+	assert.Equal(t, map[string]int{"ds1/eu-west-1": 1, "ds2/eu-west-1": 1, "ds1/us-east-1": 1}, calls)
+
+	fail = true
+	queries = executor.expandAccountQueries(pluginCtx("ds3"), &models.CloudWatchQuery{Id: "e", Region: "eu-west-1", AccountId: aws.String("222")})
+	require.Len(t, queries, 1)
+	assert.Empty(t, queries[0].AccountLabel)
+	executor.expandAccountQueries(pluginCtx("ds3"), &models.CloudWatchQuery{Id: "f", Region: "eu-west-1", AccountId: aws.String("222")})
+	assert.Equal(t, 2, calls["ds3/eu-west-1"], "failures aren't cached")
+}
+
+func TestAddAccountLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  *models.CloudWatchQuery
+		labels data.Labels
+	}{
+		{name: "without account", query: &models.CloudWatchQuery{}, labels: data.Labels{"InstanceId": "i-1"}},
+		{name: "all accounts", query: &models.CloudWatchQuery{AccountId: aws.String("all")}, labels: data.Labels{"InstanceId": "i-1"}},
+		{name: "account without label", query: &models.CloudWatchQuery{AccountId: aws.String("222")}, labels: data.Labels{"InstanceId": "i-1", "account_id": "222"}},
+		{name: "account with label", query: &models.CloudWatchQuery{AccountId: aws.String("222"), AccountLabel: "source"},
+			labels: data.Labels{"InstanceId": "i-1", "account_id": "222", "account_label": "source"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := data.Labels{"InstanceId": "i-1"}
+			addAccountLabels(labels, tt.query)
+			assert.Equal(t, tt.labels, labels)
+		})
+	}
+}
+
+func TestFormatAliasAccountTokens(t *testing.T) {
+	query := func(accountId *string) *models.CloudWatchQuery {
+		return &models.CloudWatchQuery{
+			Region: "us-east-1", Namespace: "AWS/EC2", MetricName: "CPUUtilization", Period: 300, MatchExact: true,
+			MetricQueryType: models.MetricQueryTypeSearch, MetricEditorMode: models.MetricEditorModeBuilder,
+			Dimensions: map[string][]string{"InstanceId": {"i-1"}}, AccountId: accountId, AccountLabel: "source",
+			Alias: "{{metric}} {{accountId}} {{accountLabel}}",
+		}
+	}
+	assert.Equal(t, "CPUUtilization 222 source", formatAlias(query(aws.String("222")), "Average", map[string]string{"InstanceId": "i-1"}, "label"))
+	// the account of the series of a search across all accounts isn't known to the alias
+	assert.Equal(t, "CPUUtilization {{accountId}} {{accountLabel}}", formatAlias(query(aws.String("all")), "Average", map[string]string{"InstanceId": "i-1"}, "label"))
+}
+
+// fakeMetricDataClient returns a series for each query of the input, labelled with the id of the query. The series
+// of the arithmeticError query reports an arithmetic error.
+type fakeMetricDataClient struct {
+	cloudwatchiface.CloudWatchAPI
+	arithmeticError string
+	inputs          []*cloudwatch.GetMetricDataInput
+}
+
+func (c *fakeMetricDataClient) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	c.inputs = append(c.inputs, input)
+	output := &cloudwatch.GetMetricDataOutput{}
+	for _, query := range input.MetricDataQueries {
+		result := &cloudwatch.MetricDataResult{
+			Id:         query.Id,
+			Label:      aws.String(aws.StringValue(query.Id) + " CPUUtilization"),
+			StatusCode: aws.String(cloudwatch.StatusCodeComplete),
+			Timestamps: []*time.Time{aws.Time(time.Unix(0, 0))},
+			Values:     []*float64{aws.Float64(1)},
+		}
+		if aws.StringValue(query.Id) == c.arithmeticError {
+			result.Messages = []*cloudwatch.MessageData{{Code: aws.String("ArithmeticError"), Value: aws.String("division by zero")}}
+		}
+		output.MetricDataResults = append(output.MetricDataResults, result)
+	}
+	return output, nil
+}
+
+func TestExecuteTimeSeriesQueryAcrossAccounts(t *testing.T) {
+	origListAccounts, origNewCWClient := listAccounts, NewCWClient
+	t.Cleanup(func() { listAccounts, NewCWClient = origListAccounts, origNewCWClient })
+	listAccounts = func(e *cloudWatchExecutor, pluginCtx backend.PluginContext, region string) ([]resources.Account, error) {
+		return []resources.Account{{Id: "111", Label: "monitoring"}, {Id: "222", Label: "source"}}, nil
+	}
+	client := &fakeMetricDataClient{}
+	NewCWClient = func(sess *session.Session) cloudwatchiface.CloudWatchAPI { return client }
+
+	query := backend.DataQuery{
+		RefID:     "A",
+		TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)},
+		JSON: []byte(`{"queryType":"timeSeriesQuery","id":"a","region":"us-east-1","namespace":"AWS/EC2","metricName":"CPUUtilization",
+			"statistic":"Average","period":"300","dimensions":{"InstanceId":"*"},"metricQueryType":0,"metricEditorMode":0,"accountId":"all"}`),
+	}
+	execute := func(t *testing.T) backend.DataResponse {
+		pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: t.Name()}}
+		res, err := newTestExecutor().executeTimeSeriesQuery(context.Background(), logger, &backend.QueryDataRequest{PluginContext: pluginCtx, Queries: []backend.DataQuery{query}}, query)
+		require.NoError(t, err)
+		require.Len(t, res.Responses, 1)
+		return res.Responses["A"]
+	}
+
+	t.Run("the series of the accounts are merged into the response of the query", func(t *testing.T) {
+		client.inputs, client.arithmeticError = nil, ""
+		response := execute(t)
+		require.NoError(t, response.Error)
+		require.Len(t, client.inputs, 1)
+		ids := []string{}
+		for _, q := range client.inputs[0].MetricDataQueries {
+			ids = append(ids, aws.StringValue(q.Id))
+		}
+		assert.ElementsMatch(t, []string{"a_account0", "a_account1"}, ids)
+
+		require.Len(t, response.Frames, 2)
+		accounts := map[string]string{}
+		for _, frame := range response.Frames {
+			labels := frame.Fields[1].Labels
+			accounts[labels["account_id"]] = labels["account_label"]
+		}
+		assert.Equal(t, map[string]string{"111": "monitoring", "222": "source"}, accounts)
+	})
+	for _, failing := range []string{"a_account0", "a_account1"} {
+		t.Run("the error of one account is kept when merging "+failing, func(t *testing.T) {
+			client.inputs, client.arithmeticError = nil, failing
+			response := execute(t)
+			require.Error(t, response.Error)
+			assert.Contains(t, response.Error.Error(), "division by zero")
+			assert.Len(t, response.Frames, 2)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Inventory types
+const (
+	inventoryTypeEC2       = "ec2"
+	inventoryTypeResources = "resources"
+)
+
+const (
+	defaultInventoryLimit = int64(1000)
+	maxInventoryLimit     = int64(10000)
+	// ec2PageLimit and rgtaPageLimit are the page size limits of DescribeInstances and GetResources
+	ec2PageLimit  = int64(1000)
+	rgtaPageLimit = int64(100)
+)
+
+// executeInventoryQuery returns the EC2 instances or the tagged resources of the account as a table. At most Limit
+// rows are returned; the token to continue with is reported as nextToken in the frame meta.
+func (e *cloudWatchExecutor) executeInventoryQuery(pluginCtx backend.PluginContext, model DataQueryJson, query backend.DataQuery) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+
+	limit := model.Limit
+	if limit <= 0 {
+		limit = defaultInventoryLimit
+	}
+	if limit > maxInventoryLimit {
+		return result, fmt.Errorf("invalid inventory query: limit must not be greater than %d", maxInventoryLimit)
+	}
+
+	var frame *data.Frame
+	var nextToken *string
+	var err error
+	switch model.InventoryType {
+	case "", inventoryTypeEC2:
+		frame, nextToken, err = e.ec2Inventory(pluginCtx, model, limit, query.RefID)
+	case inventoryTypeResources:
+		frame, nextToken, err = e.resourcesInventory(pluginCtx, model, limit, query.RefID)
+	default:
+		return result, fmt.Errorf("invalid inventory query: unknown inventory type %q", model.InventoryType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rowCount, _ := frame.RowLen()
+	custom := map[string]interface{}{
+		"rowCount": rowCount,
+	}
+	if nextToken != nil {
+		custom["nextToken"] = *nextToken
+	}
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTable,
+		Custom:                 custom,
+	}
+
+	respD := result.Responses[query.RefID]
+	respD.Frames = append(respD.Frames, frame)
+	result.Responses[query.RefID] = respD
+
+	return result, nil
+}
+
+func (e *cloudWatchExecutor) ec2Inventory(pluginCtx backend.PluginContext, model DataQueryJson, limit int64, refID string) (*data.Frame, *string, error) {
+	client, err := e.getEC2Client(pluginCtx, model.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("instanceId", nil, []string{}),
+		data.NewField("name", nil, []string{}),
+		data.NewField("state", nil, []string{}),
+		data.NewField("instanceType", nil, []string{}),
+		data.NewField("availabilityZone", nil, []string{}),
+		data.NewField("launchTime", nil, []*time.Time{}),
+		data.NewField("tags", nil, []string{}),
+	)
+
+	params := &ec2.DescribeInstancesInput{
+		Filters: ec2Filters(model.Filters),
+	}
+	if model.NextToken != "" {
+		params.NextToken = aws.String(model.NextToken)
+	}
+
+	rows := int64(0)
+	for {
+		// DescribeInstances returns whole reservations, so a page may hold a few more instances than asked for
+		params.MaxResults = aws.Int64(clampPageSize(limit-rows, 5, ec2PageLimit))
+		page, err := client.DescribeInstances(params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to call ec2:DescribeInstances, %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				tags := ec2TagMap(instance.Tags)
+				state := ""
+				if instance.State != nil {
+					state = aws.StringValue(instance.State.Name)
+				}
+				az := ""
+				if instance.Placement != nil {
+					az = aws.StringValue(instance.Placement.AvailabilityZone)
+				}
+				frame.AppendRow(
+					aws.StringValue(instance.InstanceId),
+					tags["Name"],
+					state,
+					aws.StringValue(instance.InstanceType),
+					az,
+					instance.LaunchTime,
+					formatTags(tags),
+				)
+				rows++
+			}
+		}
+		if aws.StringValue(page.NextToken) == "" {
+			return frame, nil, nil
+		}
+		if rows >= limit {
+			return frame, page.NextToken, nil
+		}
+		params.NextToken = page.NextToken
+	}
+}
+
+func (e *cloudWatchExecutor) resourcesInventory(pluginCtx backend.PluginContext, model DataQueryJson, limit int64, refID string) (*data.Frame, *string, error) {
+	client, err := e.getRGTAClient(pluginCtx, model.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("arn", nil, []string{}),
+		data.NewField("name", nil, []string{}),
+		data.NewField("resourceType", nil, []string{}),
+		data.NewField("region", nil, []string{}),
+		data.NewField("tags", nil, []string{}),
+	)
+
+	params := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: aws.StringSlice(model.ResourceTypes),
+		TagFilters:          tagFilters(model.Tags),
+	}
+	if model.NextToken != "" {
+		params.PaginationToken = aws.String(model.NextToken)
+	}
+
+	rows := int64(0)
+	for {
+		params.ResourcesPerPage = aws.Int64(clampPageSize(limit-rows, 1, rgtaPageLimit))
+		page, err := client.GetResources(params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to call tag:GetResources, %w", err)
+		}
+		for _, resource := range page.ResourceTagMappingList {
+			tags := make(map[string]string, len(resource.Tags))
+			for _, tag := range resource.Tags {
+				tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			resourceARN := aws.StringValue(resource.ResourceARN)
+			resourceType, region := "", ""
+			if parsed, err := arn.Parse(resourceARN); err == nil {
+				resourceType = parsed.Service
+				if i := strings.IndexAny(parsed.Resource, ":/"); i > 0 {
+					resourceType += ":" + parsed.Resource[:i]
+				}
+				region = parsed.Region
+			}
+			frame.AppendRow(resourceARN, tags["Name"], resourceType, region, formatTags(tags))
+			rows++
+		}
+		if aws.StringValue(page.PaginationToken) == "" {
+			return frame, nil, nil
+		}
+		if rows >= limit {
+			return frame, page.PaginationToken, nil
+		}
+		params.PaginationToken = page.PaginationToken
+	}
+}
+
+func clampPageSize(size int64, min int64, max int64) int64 {
+	if size < min {
+		return min
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
+
+func ec2TagMap(ec2Tags []*ec2.Tag) map[string]string {
+	tags := make(map[string]string, len(ec2Tags))
+	for _, tag := range ec2Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags
+}
+
+// formatTags returns the tags as a sorted list of key=value pairs
+func formatTags(tags map[string]string) string {
+	formatted := make([]string, 0, len(tags))
+	for k, v := range tags {
+		formatted = append(formatted, k+"="+v)
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,179 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEC2Client serves total instances, in pages of the requested size
+type fakeEC2Client struct {
+	ec2iface.EC2API
+	total  int
+	inputs []ec2.DescribeInstancesInput
+}
+
+func (c *fakeEC2Client) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	c.inputs = append(c.inputs, *input)
+	from, _ := strconv.Atoi(aws.StringValue(input.NextToken))
+	to := from + int(aws.Int64Value(input.MaxResults))
+	reservation := &ec2.Reservation{}
+	for i := from; i < to && i < c.total; i++ {
+		reservation.Instances = append(reservation.Instances, &ec2.Instance{
+			InstanceId:   aws.String(fmt.Sprintf("i-%d", i)),
+			InstanceType: aws.String("t3.micro"),
+			State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			Placement:    &ec2.Placement{AvailabilityZone: aws.String("eu-west-1a")},
+			LaunchTime:   aws.Time(time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)),
+			Tags:         []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("web-%d", i))}, {Key: aws.String("Env"), Value: aws.String("prod")}},
+		})
+	}
+	output := &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}
+	if to < c.total {
+		output.NextToken = aws.String(strconv.Itoa(to))
+	}
+	return output, nil
+}
+
+// fakeRGTAClient serves total resources, in pages of the requested size
+type fakeRGTAClient struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	total  int
+	inputs []resourcegroupstaggingapi.GetResourcesInput
+}
+
+func (c *fakeRGTAClient) GetResources(input *resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	c.inputs = append(c.inputs, *input)
+	from, _ := strconv.Atoi(aws.StringValue(input.PaginationToken))
+	to := from + int(aws.Int64Value(input.ResourcesPerPage))
+	output := &resourcegroupstaggingapi.GetResourcesOutput{}
+	for i := from; i < to && i < c.total; i++ {
+		output.ResourceTagMappingList = append(output.ResourceTagMappingList, &resourcegroupstaggingapi.ResourceTagMapping{
+			ResourceARN: aws.String(fmt.Sprintf("arn:aws:lambda:eu-west-1:111:function:fn-%d", i)),
+			Tags:        []*resourcegroupstaggingapi.Tag{{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("fn-%d", i))}},
+		})
+	}
+	if to < c.total {
+		output.PaginationToken = aws.String(strconv.Itoa(to))
+	}
+	return output, nil
+}
+
+func stubInventoryClients(t *testing.T, ec2Client *fakeEC2Client, rgtaClient *fakeRGTAClient) {
+	t.Helper()
+	origNewEC2Client, origNewRGTAClient := newEC2Client, newRGTAClient
+	t.Cleanup(func() {
+		newEC2Client, newRGTAClient = origNewEC2Client, origNewRGTAClient
+	})
+	newEC2Client = func(client.ConfigProvider) ec2iface.EC2API { return ec2Client }
+	newRGTAClient = func(client.ConfigProvider) resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+		return rgtaClient
+	}
+}
+
+func TestTagFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   map[string]interface{}
+		values []string
+	}{
+		{name: "list of values", tags: map[string]interface{}{"Env": []interface{}{"prod", "dev"}}, values: []string{"prod", "dev"}},
+		{name: "single value", tags: map[string]interface{}{"Env": "prod"}, values: []string{"prod"}},
+		{name: "empty list", tags: map[string]interface{}{"Env": []interface{}{}}},
+		{name: "null", tags: map[string]interface{}{"Env": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := tagFilters(tt.tags)
+			require.Len(t, filters, 1)
+			assert.Equal(t, "Env", aws.StringValue(filters[0].Key))
+			if tt.values == nil {
+				assert.Nil(t, filters[0].Values)
+			} else {
+				assert.Equal(t, tt.values, aws.StringValueSlice(filters[0].Values))
+			}
+		})
+	}
+	t.Run("ec2 filters without values are left out", func(t *testing.T) {
+		filters := ec2Filters(map[string]interface{}{"instance-state-name": "running", "tag:Env": []interface{}{}, "tag:Team": nil})
+		require.Len(t, filters, 1)
+		assert.Equal(t, "instance-state-name", aws.StringValue(filters[0].Name))
+		assert.Equal(t, []string{"running"}, aws.StringValueSlice(filters[0].Values))
+	})
+}
+
+func TestExecuteInventoryQuery(t *testing.T) {
+	query := backend.DataQuery{RefID: "A"}
+	fieldNames := func(res *backend.QueryDataResponse) []string {
+		names := []string{}
+		for _, field := range res.Responses["A"].Frames[0].Fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	t.Run("ec2 instances", func(t *testing.T) {
+		ec2Client := &fakeEC2Client{total: 12}
+		stubInventoryClients(t, ec2Client, &fakeRGTAClient{})
+		res, err := newTestExecutor().executeInventoryQuery(backend.PluginContext{}, DataQueryJson{
+			Region: "eu-west-1", Limit: 7, Filters: map[string]interface{}{"instance-state-name": []interface{}{"running"}},
+		}, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"instanceId", "name", "state", "instanceType", "availabilityZone", "launchTime", "tags"}, fieldNames(res))
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 7, frame.Rows())
+		assert.Equal(t, "i-0", frame.Fields[0].At(0))
+		assert.Equal(t, "web-0", frame.Fields[1].At(0))
+		assert.Equal(t, ec2.InstanceStateNameRunning, frame.Fields[2].At(0))
+		assert.Equal(t, "eu-west-1a", frame.Fields[4].At(0))
+		assert.Equal(t, "Env=prod, Name=web-0", frame.Fields[6].At(0))
+		assert.Equal(t, map[string]interface{}{"rowCount": 7, "nextToken": "7"}, frame.Meta.Custom)
+		assert.Equal(t, []string{"running"}, aws.StringValueSlice(ec2Client.inputs[0].Filters[0].Values))
+		assert.Equal(t, int64(7), aws.Int64Value(ec2Client.inputs[0].MaxResults))
+
+		res, err = newTestExecutor().executeInventoryQuery(backend.PluginContext{}, DataQueryJson{Region: "eu-west-1", Limit: 7, NextToken: "7"}, query)
+		require.NoError(t, err)
+		frame = res.Responses["A"].Frames[0]
+		require.Equal(t, 5, frame.Rows())
+		assert.Equal(t, "i-7", frame.Fields[0].At(0))
+		assert.Equal(t, map[string]interface{}{"rowCount": 5}, frame.Meta.Custom)
+	})
+	t.Run("tagged resources", func(t *testing.T) {
+		rgtaClient := &fakeRGTAClient{total: 250}
+		stubInventoryClients(t, &fakeEC2Client{}, rgtaClient)
+		res, err := newTestExecutor().executeInventoryQuery(backend.PluginContext{}, DataQueryJson{
+			Region: "eu-west-1", InventoryType: inventoryTypeResources, Limit: 150, ResourceTypes: []string{"lambda:function"},
+			Tags: map[string]interface{}{"Env": "prod", "Team": nil},
+		}, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"arn", "name", "resourceType", "region", "tags"}, fieldNames(res))
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 150, frame.Rows())
+		assert.Equal(t, []interface{}{"arn:aws:lambda:eu-west-1:111:function:fn-0", "fn-0", "lambda:function", "eu-west-1", "Name=fn-0"},
+			[]interface{}{frame.Fields[0].At(0), frame.Fields[1].At(0), frame.Fields[2].At(0), frame.Fields[3].At(0), frame.Fields[4].At(0)})
+		assert.Equal(t, map[string]interface{}{"rowCount": 150, "nextToken": "150"}, frame.Meta.Custom)
+
+		require.Len(t, rgtaClient.inputs, 2)
+		assert.Equal(t, []int64{100, 50}, []int64{aws.Int64Value(rgtaClient.inputs[0].ResourcesPerPage), aws.Int64Value(rgtaClient.inputs[1].ResourcesPerPage)})
+		assert.Equal(t, []string{"lambda:function"}, aws.StringValueSlice(rgtaClient.inputs[0].ResourceTypeFilters))
+		assert.Len(t, rgtaClient.inputs[0].TagFilters, 2)
+	})
+	t.Run("invalid queries", func(t *testing.T) {
+		stubInventoryClients(t, &fakeEC2Client{}, &fakeRGTAClient{})
+		_, err := newTestExecutor().executeInventoryQuery(backend.PluginContext{}, DataQueryJson{InventoryType: "s3"}, query)
+		assert.ErrorContains(t, err, `unknown inventory type "s3"`)
+		_, err = newTestExecutor().executeInventoryQuery(backend.PluginContext{}, DataQueryJson{Limit: maxInventoryLimit + 1}, query)
+		assert.ErrorContains(t, err, "limit must not be greater than 10000")
+	})
+}
@@ -52,6 +52,18 @@ type LogQueryJson struct {
 	StatsGroups        []string
 	Subtype            string
 	Expression         string
+	// optional post-processing of GetQueryResults frames, see ProcessLogsFrame
+	MessageFormat      string
+	MaxExtractedFields int
+	DeriveLevel        bool
+}
+
+func (q LogQueryJson) processingOptions() LogsProcessingOptions {
+	return LogsProcessingOptions{
+		MessageFormat: q.MessageFormat,
+		MaxFields:     q.MaxExtractedFields,
+		DeriveLevel:   q.DeriveLevel,
+	}
 }
 
 func (e *AWSError) Error() string {
@@ -129,6 +141,10 @@ func (e *cloudWatchExecutor) executeLogAction(ctx context.Context, logger log.Lo
 		return nil, err
 	}
 
+	if model.Region == "" || model.Region == defaultRegion {
+		model.Region = region
+	}
+
 	var data *data.Frame = nil
 	switch model.SubType {
 	case "GetLogGroupFields":
@@ -138,7 +154,7 @@ func (e *cloudWatchExecutor) executeLogAction(ctx context.Context, logger log.Lo
 	case "StopQuery":
 		data, err = e.handleStopQuery(ctx, logsClient, model)
 	case "GetQueryResults":
-		data, err = e.handleGetQueryResults(ctx, logsClient, model, query.RefID)
+		data, err = e.handleGetQueryResults(ctx, logsClient, model, query.TimeRange, query.RefID)
 	case "GetLogEvents":
 		data, err = e.handleGetLogEvents(ctx, logsClient, model)
 	}
@@ -268,6 +284,8 @@ func (e *cloudWatchExecutor) handleStartQuery(ctx context.Context, logger log.Lo
 		return nil, err
 	}
 
+	rememberStartedQuery(*startQueryResponse.QueryId, model)
+
 	dataFrame := data.NewFrame(refID, data.NewField("queryId", nil, []string{*startQueryResponse.QueryId}))
 	dataFrame.RefID = refID
 
@@ -327,7 +345,7 @@ func (e *cloudWatchExecutor) executeGetQueryResults(ctx context.Context, logsCli
 }
 
 func (e *cloudWatchExecutor) handleGetQueryResults(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI,
-	parameters LogQueryJson, refID string) (*data.Frame, error) {
+	parameters LogQueryJson, timeRange backend.TimeRange, refID string) (*data.Frame, error) {
 	getQueryResultsOutput, err := e.executeGetQueryResults(ctx, logsClient, parameters)
 	if err != nil {
 		return nil, err
@@ -337,6 +355,8 @@ func (e *cloudWatchExecutor) handleGetQueryResults(ctx context.Context, logsClie
 	if err != nil {
 		return nil, err
 	}
+	ProcessLogsFrame(dataFrame, parameters.processingOptions())
+	addLogsDeepLinks(dataFrame, withStartedQuery(parameters), timeRange)
 
 	dataFrame.Name = refID
 	dataFrame.RefID = refID
@@ -0,0 +1,290 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	logStreamField = "@logStream"
+	logField       = "@log"
+
+	// logStreamLinkInternal holds the console link of each row's log stream. Grafana interpolates it into the row's data link.
+	logStreamLinkInternal = "__logstream_link__grafana_internal__"
+)
+
+// BuildLogsDeepLink returns the CloudWatch Logs Insights console URL which runs queryString against the log groups
+// for the given time range
+func BuildLogsDeepLink(queryString string, logGroups []string, region string, timeRange backend.TimeRange) string {
+	sources := make([]interface{}, 0, len(logGroups))
+	for _, logGroup := range logGroups {
+		sources = append(sources, logGroup)
+	}
+	queryDetail := jsurlStringify(jsurlObject{
+		{"end", timeRange.To.UTC().Format(time.RFC3339Nano)},
+		{"start", timeRange.From.UTC().Format(time.RFC3339Nano)},
+		{"timeType", "ABSOLUTE"},
+		{"tz", "UTC"},
+		{"editorString", queryString},
+		{"isLiveTail", false},
+		{"source", sources},
+	})
+
+	// the JSURL notation only uses characters which are safe in a URL fragment
+	return fmt.Sprintf("%s#logs-insights:queryDetail=%s", consoleHome(region), queryDetail)
+}
+
+// buildLogStreamDeepLink returns the console URL of a log stream. logGroup may be prefixed with the
+// account id, as in the @log field.
+func buildLogStreamDeepLink(logGroup string, logStream string, region string) string {
+	if i := strings.Index(logGroup, ":"); i >= 0 {
+		logGroup = logGroup[i+1:]
+	}
+	return fmt.Sprintf("%s#logsV2:log-groups/log-group/%s/log-events/%s", consoleHome(region),
+		consolePathSegment(logGroup), consolePathSegment(logStream))
+}
+
+// startedQueryTTL is how long the query of a started Logs Insights query is kept, the longest a query may run
+const startedQueryTTL = time.Hour
+
+type startedQuery struct {
+	queryString   string
+	logGroupNames []string
+	logGroups     []suggestData
+	expires       time.Time
+}
+
+// startedQueries keeps the query string and log groups of the started queries by query id. The GetQueryResults
+// requests only carry the query id, the console link of their results is built from the started query.
+var startedQueries = struct {
+	sync.Mutex
+	entries map[string]startedQuery
+}{entries: make(map[string]startedQuery)}
+
+func rememberStartedQuery(queryId string, parameters LogQueryJson) {
+	now := time.Now()
+	startedQueries.Lock()
+	defer startedQueries.Unlock()
+	for k, e := range startedQueries.entries {
+		if now.After(e.expires) {
+			delete(startedQueries.entries, k)
+		}
+	}
+	startedQueries.entries[queryId] = startedQuery{
+		queryString:   parameters.QueryString,
+		logGroupNames: parameters.LogGroupNames,
+		logGroups:     parameters.LogGroups,
+		expires:       now.Add(startedQueryTTL),
+	}
+}
+
+// withStartedQuery fills the query string and log groups of GetQueryResults parameters from the started query
+func withStartedQuery(parameters LogQueryJson) LogQueryJson {
+	if parameters.QueryString != "" || parameters.QueryId == "" {
+		return parameters
+	}
+	startedQueries.Lock()
+	started, ok := startedQueries.entries[parameters.QueryId]
+	startedQueries.Unlock()
+	if !ok || time.Now().After(started.expires) {
+		return parameters
+	}
+	parameters.QueryString = started.queryString
+	if len(parameters.LogGroupNames) == 0 && len(parameters.LogGroups) == 0 {
+		parameters.LogGroupNames, parameters.LogGroups = started.logGroupNames, started.logGroups
+	}
+	return parameters
+}
+
+// addLogsDeepLinks links the frame to the Logs Insights console and each row to its log stream
+func addLogsDeepLinks(frame *data.Frame, parameters LogQueryJson, timeRange backend.TimeRange) {
+	if frame == nil || parameters.Region == "" {
+		return
+	}
+
+	if parameters.QueryString != "" {
+		if field := linkedLogsField(frame); field != nil {
+			addFieldLink(field, logsInsightsLink(parameters, timeRange))
+		}
+	}
+
+	logGroups := firstField(frame, logField, logIdentifierInternal)
+	logStreams := firstField(frame, logStreamField, logStreamIdentifierInternal)
+	if logGroups == nil || logStreams == nil {
+		return
+	}
+	links := make([]*string, logStreams.Len())
+	for i := range links {
+		logGroup, logStream := stringValueAt(logGroups, i), stringValueAt(logStreams, i)
+		if logGroup == "" || logStream == "" {
+			continue
+		}
+		link := buildLogStreamDeepLink(logGroup, logStream, parameters.Region)
+		links[i] = &link
+	}
+	linkField := data.NewField(logStreamLinkInternal, nil, links)
+	linkField.SetConfig(&data.FieldConfig{Custom: map[string]interface{}{"hidden": true}})
+	frame.Fields = append(frame.Fields, linkField)
+
+	addFieldLink(logStreams, data.DataLink{
+		Title:       "View log stream in CloudWatch console",
+		TargetBlank: true,
+		URL:         "${__data.fields." + logStreamLinkInternal + "}",
+	})
+}
+
+// addLogsAlertDeepLinks links the value fields of the frames built from a logs alert query to the Logs Insights console
+func addLogsAlertDeepLinks(frames data.Frames, parameters LogQueryJson, timeRange backend.TimeRange) {
+	if parameters.QueryString == "" || parameters.Region == "" {
+		return
+	}
+	link := logsInsightsLink(parameters, timeRange)
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Type().Numeric() {
+				addFieldLink(field, link)
+			}
+		}
+	}
+}
+
+func logsInsightsLink(parameters LogQueryJson, timeRange backend.TimeRange) data.DataLink {
+	return data.DataLink{
+		Title:       "View in CloudWatch Logs Insights",
+		TargetBlank: true,
+		URL:         BuildLogsDeepLink(parameters.QueryString, queryLogGroups(parameters), parameters.Region, timeRange),
+	}
+}
+
+func queryLogGroups(parameters LogQueryJson) []string {
+	if len(parameters.LogGroups) > 0 {
+		logGroups := make([]string, 0, len(parameters.LogGroups))
+		for _, lg := range parameters.LogGroups {
+			logGroups = append(logGroups, strings.TrimSuffix(lg.Value, "*"))
+		}
+		return logGroups
+	}
+	if len(parameters.LogGroupNames) > 0 {
+		return parameters.LogGroupNames
+	}
+	if parameters.LogGroupName != "" {
+		return []string{parameters.LogGroupName}
+	}
+	return nil
+}
+
+// linkedLogsField returns the field the Logs Insights link is attached to: @message, or else the first visible field
+func linkedLogsField(frame *data.Frame) *data.Field {
+	if idx := fieldIndex(frame, logMessageField); idx >= 0 {
+		return frame.Fields[idx]
+	}
+	for _, field := range frame.Fields {
+		if field.Config == nil || field.Config.Custom == nil || field.Config.Custom["hidden"] != true {
+			return field
+		}
+	}
+	return nil
+}
+
+func firstField(frame *data.Frame, names ...string) *data.Field {
+	for _, name := range names {
+		if idx := fieldIndex(frame, name); idx >= 0 {
+			return frame.Fields[idx]
+		}
+	}
+	return nil
+}
+
+func addFieldLink(field *data.Field, link data.DataLink) {
+	if field.Config == nil {
+		field.SetConfig(&data.FieldConfig{})
+	}
+	field.Config.Links = append(field.Config.Links, link)
+}
+
+func consoleHome(region string) string {
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s", region, url.QueryEscape(region))
+}
+
+// consolePathSegment encodes a log group or log stream name the way the console expects it in the URL fragment
+func consolePathSegment(s string) string {
+	return strings.ReplaceAll(encodeURIComponent(encodeURIComponent(s)), "%", "$")
+}
+
+// encodeURIComponent escapes s like javascript's encodeURIComponent
+func encodeURIComponent(s string) string {
+	escaped := url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "%21", "!", "%27", "'", "%28", "(", "%29", ")", "%2A", "*").Replace(escaped)
+}
+
+type jsurlPair struct {
+	key   string
+	value interface{}
+}
+
+// jsurlObject is an object with ordered keys
+type jsurlObject []jsurlPair
+
+// jsurlStringify encodes v in the JSURL notation used in the state of CloudWatch console URLs
+func jsurlStringify(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "~null"
+	case bool:
+		return "~" + strconv.FormatBool(value)
+	case int:
+		return "~" + strconv.Itoa(value)
+	case int64:
+		return "~" + strconv.FormatInt(value, 10)
+	case float64:
+		return "~" + strconv.FormatFloat(value, 'f', -1, 64)
+	case string:
+		return "~'" + jsurlEncode(value)
+	case []interface{}:
+		var sb strings.Builder
+		sb.WriteString("~(")
+		if len(value) == 0 {
+			sb.WriteString("~")
+		}
+		for _, item := range value {
+			sb.WriteString(jsurlStringify(item))
+		}
+		sb.WriteString(")")
+		return sb.String()
+	case jsurlObject:
+		pairs := make([]string, 0, len(value))
+		for _, pair := range value {
+			pairs = append(pairs, jsurlEncode(pair.key)+jsurlStringify(pair.value))
+		}
+		return "~(" + strings.Join(pairs, "~") + ")"
+	}
+	return "~null"
+}
+
+func jsurlEncode(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			sb.WriteRune(r)
+		case r == '$':
+			sb.WriteString("!")
+		case r < 0x100:
+			fmt.Fprintf(&sb, "*%02x", r)
+		case r < 0x10000:
+			fmt.Fprintf(&sb, "**%04x", r)
+		default:
+			// characters outside the BMP are encoded as a utf-16 surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(&sb, "**%04x**%04x", 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package cloudwatch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsurlStringify(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: "plain string", value: "abc_1-2.3", expected: "~'abc_1-2.3"},
+		{name: "reserved characters", value: "a b|c'$", expected: "~'a*20b*7cc*27!"},
+		{name: "latin-1 characters", value: "é", expected: "~'*e9"},
+		{name: "bmp characters", value: "€", expected: "~'**20ac"},
+		{name: "surrogate pairs", value: "😀", expected: "~'**d83d**de00"},
+		{name: "scalars", value: []interface{}{nil, true, 2, int64(3), 1.5}, expected: "~(~null~true~2~3~1.5)"},
+		{name: "empty array", value: []interface{}{}, expected: "~(~)"},
+		{name: "object", value: jsurlObject{{"a b", "x"}, {"list", []interface{}{"y"}}}, expected: "~(a*20b~'x~list~(~'y))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, jsurlStringify(tt.value))
+		})
+	}
+}
+
+func TestConsolePathSegment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "/aws/lambda/fn", expected: "$252Faws$252Flambda$252Ffn"},
+		{input: "2023/01/01/[$LATEST]abc", expected: "2023$252F01$252F01$252F$255B$2524LATEST$255Dabc"},
+		{input: "a b", expected: "a$2520b"},
+		{input: "keep-_.!*'()", expected: "keep-_.!*'()"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, consolePathSegment(tt.input))
+		})
+	}
+
+	assert.Equal(t, "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logsV2:log-groups/log-group/$252Fapp/log-events/stream",
+		buildLogStreamDeepLink("123456789012:/app", "stream", "eu-west-1"))
+}
+
+func TestGetQueryResultsDeepLinks(t *testing.T) {
+	client := &fakeLogsClient{results: [][]*cloudwatchlogs.ResultField{
+		resultRow("@message", "started", logIdentifierInternal, "/app", logStreamIdentifierInternal, "stream-1"),
+	}}
+	executor := newTestExecutor()
+	timeRange := backend.TimeRange{From: time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC), To: time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	_, err := executor.handleStartQuery(context.Background(), logger, client,
+		LogQueryJson{QueryString: "fields @message", LogGroupNames: []string{"/app"}, Region: "eu-west-1"}, timeRange, "A")
+	require.NoError(t, err)
+
+	frame, err := executor.handleGetQueryResults(context.Background(), client,
+		LogQueryJson{QueryId: "query-1", Region: "eu-west-1"}, timeRange, "A")
+	require.NoError(t, err)
+
+	message := frame.Fields[fieldIndex(frame, logMessageField)]
+	require.NotNil(t, message.Config)
+	require.Len(t, message.Config.Links, 1)
+	link := message.Config.Links[0].URL
+	assert.True(t, strings.HasPrefix(link, "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logs-insights:queryDetail="), link)
+	assert.Contains(t, link, "~editorString~'fields*20*40message~")
+	assert.Contains(t, link, "~source~(~'*2fapp)")
+
+	streamLinks := frame.Fields[fieldIndex(frame, logStreamLinkInternal)]
+	assert.Equal(t, "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logsV2:log-groups/log-group/$252Fapp/log-events/stream-1",
+		stringValueAt(streamLinks, 0))
+}
@@ -0,0 +1,258 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	logMessageField = "@message"
+	logLevelField   = "level"
+
+	// frameTypeLogLines marks a frame as log lines. The plugin SDK in use doesn't define it yet.
+	frameTypeLogLines data.FrameType = "log-lines"
+
+	defaultMaxExtractedFields = 50
+)
+
+// Supported message formats for structured field extraction
+const (
+	messageFormatJSON   = "json"
+	messageFormatLogfmt = "logfmt"
+	messageFormatAuto   = "auto"
+)
+
+// levelKeys are checked in order when deriving the level of a log line from its fields
+var levelKeys = []string{"level", "severity", "log.level"}
+
+var levelPrefixRegex = regexp.MustCompile(`(?i)^\s*[\[(]?(trace|debug|info|notice|warn|warning|error|err|fatal|critical|crit|panic|emerg|alert)[\])]?(?:[\s:|\-]|$)`)
+
+// LogsProcessingOptions controls the optional post-processing of Logs Insights results
+type LogsProcessingOptions struct {
+	// MessageFormat is the format @message is parsed as: json, logfmt or auto. Empty disables extraction.
+	MessageFormat string
+	// MaxFields limits the number of top level fields extracted from @message
+	MaxFields int
+	// DeriveLevel adds a level field used by Grafana's log volume histogram
+	DeriveLevel bool
+}
+
+func (o LogsProcessingOptions) enabled() bool {
+	return o.MessageFormat != "" || o.DeriveLevel
+}
+
+// ProcessLogsFrame parses the structured content of @message into top level fields, derives a
+// level field and tags the frame as log lines. Frames without a @message field are left untouched.
+func ProcessLogsFrame(frame *data.Frame, opts LogsProcessingOptions) {
+	if frame == nil || !opts.enabled() {
+		return
+	}
+	messageIdx := fieldIndex(frame, logMessageField)
+	if messageIdx < 0 {
+		return
+	}
+	messages := frame.Fields[messageIdx]
+	rowCount := messages.Len()
+
+	parsed := make([]map[string]string, rowCount)
+	if opts.MessageFormat != "" {
+		for i := 0; i < rowCount; i++ {
+			if msg := stringValueAt(messages, i); msg != "" {
+				parsed[i] = parseLogMessage(msg, opts.MessageFormat)
+			}
+		}
+		addExtractedFields(frame, parsed, opts.MaxFields)
+	}
+
+	if opts.DeriveLevel {
+		// an existing level field goes through the same mapping, its empty values are derived
+		levels := make([]*string, rowCount)
+		for i := 0; i < rowCount; i++ {
+			levels[i] = deriveLogLevel(frame, parsed[i], messages, i)
+		}
+		level := data.NewField(logLevelField, nil, levels)
+		if idx := fieldIndex(frame, logLevelField); idx >= 0 {
+			level.Labels, level.Config = frame.Fields[idx].Labels, frame.Fields[idx].Config
+			frame.Fields[idx] = level
+		} else {
+			frame.Fields = append(frame.Fields, level)
+		}
+	}
+
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	frame.Meta.Type = frameTypeLogLines
+	frame.Meta.PreferredVisualization = data.VisTypeLogs
+}
+
+// addExtractedFields adds a string field for each extracted key, ordered by the row the key was first seen in.
+// Keys which already exist as fields are skipped.
+func addExtractedFields(frame *data.Frame, parsed []map[string]string, maxFields int) {
+	if maxFields <= 0 {
+		maxFields = defaultMaxExtractedFields
+	}
+
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, row := range parsed {
+		for _, key := range sortedKeys(row) {
+			if seen[key] || len(keys) >= maxFields {
+				continue
+			}
+			seen[key] = true
+			if fieldIndex(frame, key) < 0 {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		values := make([]*string, len(parsed))
+		for i, row := range parsed {
+			if v, ok := row[key]; ok {
+				v := v
+				values[i] = &v
+			}
+		}
+		frame.Fields = append(frame.Fields, data.NewField(key, nil, values))
+	}
+}
+
+func deriveLogLevel(frame *data.Frame, parsed map[string]string, messages *data.Field, row int) *string {
+	for _, key := range levelKeys {
+		if idx := fieldIndex(frame, key); idx >= 0 {
+			if v := stringValueAt(frame.Fields[idx], row); v != "" {
+				return normalizeLogLevel(v)
+			}
+		}
+		if v, ok := parsed[key]; ok && v != "" {
+			return normalizeLogLevel(v)
+		}
+	}
+
+	if match := levelPrefixRegex.FindStringSubmatch(stringValueAt(messages, row)); match != nil {
+		return normalizeLogLevel(match[1])
+	}
+	return nil
+}
+
+// normalizeLogLevel maps common level spellings to the levels understood by Grafana
+func normalizeLogLevel(level string) *string {
+	normalized := strings.ToLower(strings.TrimSpace(level))
+	switch normalized {
+	case "emerg", "alert", "crit", "critical", "fatal", "panic", "f", "c":
+		normalized = "critical"
+	case "err", "error", "e":
+		normalized = "error"
+	case "warn", "warning", "w":
+		normalized = "warning"
+	case "notice", "info", "information", "i":
+		normalized = "info"
+	case "debug", "dbug", "d":
+		normalized = "debug"
+	case "trace":
+		normalized = "trace"
+	}
+	return &normalized
+}
+
+// parseLogMessage returns the flattened fields of a JSON or logfmt message. Messages which aren't
+// structured in the requested format give nil.
+func parseLogMessage(msg string, format string) map[string]string {
+	trimmed := strings.TrimSpace(msg)
+	switch format {
+	case messageFormatJSON:
+		return parseJSONMessage(trimmed)
+	case messageFormatLogfmt:
+		return parseLogfmtMessage(trimmed)
+	case messageFormatAuto:
+		if strings.HasPrefix(trimmed, "{") {
+			return parseJSONMessage(trimmed)
+		}
+		return parseLogfmtMessage(trimmed)
+	}
+	return nil
+}
+
+func parseJSONMessage(msg string) map[string]string {
+	var obj map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(msg))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		return nil
+	}
+	fields := make(map[string]string)
+	flattenJSON("", obj, fields)
+	return fields
+}
+
+func flattenJSON(prefix string, obj map[string]interface{}, fields map[string]string) {
+	for key, value := range obj {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenJSON(key, v, fields)
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		case bool:
+			fields[key] = strconv.FormatBool(v)
+		case nil:
+			fields[key] = ""
+		default:
+			b, err := json.Marshal(v)
+			if err == nil {
+				fields[key] = string(b)
+			}
+		}
+	}
+}
+
+func parseLogfmtMessage(msg string) map[string]string {
+	decoder := logfmt.NewDecoder(bytes.NewBufferString(msg))
+	fields := make(map[string]string)
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			fields[string(decoder.Key())] = string(decoder.Value())
+		}
+	}
+	if decoder.Err() != nil {
+		return nil
+	}
+	// a plain text message decodes as a series of keys without values
+	for _, v := range fields {
+		if v != "" {
+			return fields
+		}
+	}
+	return nil
+}
+
+func fieldIndex(frame *data.Frame, name string) int {
+	for i, field := range frame.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
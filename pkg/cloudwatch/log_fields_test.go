@@ -0,0 +1,127 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messagesFrame(fields ...*data.Field) *data.Frame {
+	return data.NewFrame("logs", fields...)
+}
+
+func stringValues(t *testing.T, frame *data.Frame, name string) []string {
+	t.Helper()
+	idx := fieldIndex(frame, name)
+	require.GreaterOrEqual(t, idx, 0, "missing field %q", name)
+	values := make([]string, frame.Fields[idx].Len())
+	for i := range values {
+		values[i] = stringValueAt(frame.Fields[idx], i)
+	}
+	return values
+}
+
+func TestProcessLogsFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		frame    *data.Frame
+		opts     LogsProcessingOptions
+		expected map[string][]string
+		missing  []string
+	}{
+		{
+			name: "json messages are flattened",
+			frame: messagesFrame(data.NewField(logMessageField, nil, []*string{
+				aws.String(`{"msg":"started","http":{"status":200},"ok":true}`),
+				aws.String("plain text"),
+			})),
+			opts: LogsProcessingOptions{MessageFormat: messageFormatJSON},
+			expected: map[string][]string{
+				"msg":         {"started", ""},
+				"http.status": {"200", ""},
+				"ok":          {"true", ""},
+			},
+		},
+		{
+			name: "logfmt messages are parsed",
+			frame: messagesFrame(data.NewField(logMessageField, nil, []*string{
+				aws.String(`level=warn msg="disk full" path=/var`),
+			})),
+			opts: LogsProcessingOptions{MessageFormat: messageFormatLogfmt, DeriveLevel: true},
+			expected: map[string][]string{
+				"msg":  {"disk full"},
+				"path": {"/var"},
+			},
+		},
+		{
+			name: "auto detects the format of each message",
+			frame: messagesFrame(data.NewField(logMessageField, nil, []*string{
+				aws.String(`{"user":"a"}`),
+				aws.String(`user=b`),
+			})),
+			opts:     LogsProcessingOptions{MessageFormat: messageFormatAuto},
+			expected: map[string][]string{"user": {"a", "b"}},
+		},
+		{
+			name: "existing fields are not overwritten",
+			frame: messagesFrame(
+				data.NewField(logMessageField, nil, []*string{aws.String(`{"@logStream":"other","a":"1"}`)}),
+				data.NewField("@logStream", nil, []*string{aws.String("stream")}),
+			),
+			opts:     LogsProcessingOptions{MessageFormat: messageFormatJSON},
+			expected: map[string][]string{"@logStream": {"stream"}, "a": {"1"}},
+		},
+		{
+			name: "extracted fields are limited to max fields",
+			frame: messagesFrame(data.NewField(logMessageField, nil, []*string{
+				aws.String(`{"c":"3","a":"1","b":"2"}`),
+			})),
+			opts:     LogsProcessingOptions{MessageFormat: messageFormatJSON, MaxFields: 2},
+			expected: map[string][]string{"a": {"1"}, "b": {"2"}},
+			missing:  []string{"c"},
+		},
+		{
+			name: "the level is derived from the fields and the message prefix",
+			frame: messagesFrame(data.NewField(logMessageField, nil, []*string{
+				aws.String(`{"severity":"ERR"}`),
+				aws.String("[WARNING] retrying"),
+				aws.String("E something"),
+				aws.String("no level"),
+			})),
+			opts:     LogsProcessingOptions{MessageFormat: messageFormatJSON, DeriveLevel: true},
+			expected: map[string][]string{logLevelField: {"error", "warning", "", ""}},
+		},
+		{
+			name: "an existing level field is normalized",
+			frame: messagesFrame(
+				data.NewField(logMessageField, nil, []*string{aws.String("a"), aws.String("b"), aws.String("ERROR: c"), aws.String("d")}),
+				data.NewField(logLevelField, nil, []*string{aws.String("WARNING"), aws.String("err"), nil, aws.String("E")}),
+			),
+			opts:     LogsProcessingOptions{DeriveLevel: true},
+			expected: map[string][]string{logLevelField: {"warning", "error", "error", "error"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ProcessLogsFrame(tt.frame, tt.opts)
+			for name, values := range tt.expected {
+				assert.Equal(t, values, stringValues(t, tt.frame, name), name)
+			}
+			for _, name := range tt.missing {
+				assert.Less(t, fieldIndex(tt.frame, name), 0, name)
+			}
+			require.NotNil(t, tt.frame.Meta)
+			assert.Equal(t, frameTypeLogLines, tt.frame.Meta.Type)
+		})
+	}
+
+	t.Run("frames without messages are left untouched", func(t *testing.T) {
+		frame := messagesFrame(data.NewField("count", nil, []*float64{aws.Float64(1)}))
+		ProcessLogsFrame(frame, LogsProcessingOptions{MessageFormat: messageFormatAuto, DeriveLevel: true})
+		assert.Len(t, frame.Fields, 1)
+		assert.Nil(t, frame.Meta)
+	})
+}
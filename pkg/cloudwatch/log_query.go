@@ -190,6 +190,146 @@ func groupResults(results *data.Frame, groupingFieldNames []string) ([]*data.Fra
 	return newDataFrames, nil
 }
 
+// logsFrameToAlertFrames converts a Logs Insights result into frames Grafana alerting can evaluate.
+// Results with a time field become wide time series, results without one become numeric frames
+// with one row each. Fields listed in statsGroups become labels, all other non-numeric fields are dropped.
+func logsFrameToAlertFrames(results *data.Frame, statsGroups []string) (data.Frames, error) {
+	isGroupingField := make(map[string]bool, len(statsGroups))
+	for _, name := range statsGroups {
+		isGroupingField[name] = true
+	}
+
+	var timeField *data.Field
+	groupingFields := make([]*data.Field, 0)
+	valueFields := make([]*data.Field, 0)
+	for _, field := range results.Fields {
+		switch {
+		case field.Name == logIdentifierInternal || field.Name == logStreamIdentifierInternal:
+			continue
+		case field.Type() == data.FieldTypeNullableTime:
+			if timeField == nil {
+				timeField = field
+			}
+		case isGroupingField[field.Name]:
+			if field.Type().Numeric() {
+				stringField, err := numericFieldToStringField(field)
+				if err != nil {
+					return nil, err
+				}
+				field = stringField
+			}
+			groupingFields = append(groupingFields, field)
+		case field.Type().Numeric():
+			valueFields = append(valueFields, field)
+		}
+	}
+
+	if len(valueFields) == 0 {
+		return nil, fmt.Errorf("logs alert query must return at least one numeric field")
+	}
+
+	if timeField == nil {
+		return logsRowsToNumericFrames(results, groupingFields, valueFields)
+	}
+	return logsRowsToWideFrame(results, timeField, groupingFields, valueFields)
+}
+
+func logsRowsToNumericFrames(results *data.Frame, groupingFields []*data.Field, valueFields []*data.Field) (data.Frames, error) {
+	rowLength, err := results.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make(data.Frames, 0, rowLength)
+	for i := 0; i < rowLength; i++ {
+		labels := groupingLabels(groupingFields, i)
+		frame := data.NewFrame(results.Name)
+		frame.RefID = results.RefID
+		frame.Meta = results.Meta
+		for _, valueField := range valueFields {
+			value, err := valueField.NullableFloatAt(i)
+			if err != nil {
+				return nil, err
+			}
+			frame.Fields = append(frame.Fields, data.NewField(valueField.Name, labels, []*float64{value}))
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+func logsRowsToWideFrame(results *data.Frame, timeField *data.Field, groupingFields []*data.Field, valueFields []*data.Field) (data.Frames, error) {
+	rows := make([]int, 0, timeField.Len())
+	for i := 0; i < timeField.Len(); i++ {
+		if t, ok := timeField.At(i).(*time.Time); ok && t != nil {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) == 0 {
+		return data.Frames{}, nil
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return timeField.At(rows[i]).(*time.Time).Before(*timeField.At(rows[j]).(*time.Time))
+	})
+
+	times := make([]time.Time, 0, len(rows))
+	for _, row := range rows {
+		times = append(times, *timeField.At(row).(*time.Time))
+	}
+	longFrame := data.NewFrame(results.Name, data.NewField(timeField.Name, nil, times))
+	longFrame.RefID = results.RefID
+	longFrame.Meta = results.Meta
+
+	for _, groupingField := range groupingFields {
+		values := make([]string, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, stringValueAt(groupingField, row))
+		}
+		longFrame.Fields = append(longFrame.Fields, data.NewField(groupingField.Name, nil, values))
+	}
+	for _, valueField := range valueFields {
+		values := make([]*float64, 0, len(rows))
+		for _, row := range rows {
+			value, err := valueField.NullableFloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		longFrame.Fields = append(longFrame.Fields, data.NewField(valueField.Name, nil, values))
+	}
+
+	if len(groupingFields) == 0 {
+		return data.Frames{longFrame}, nil
+	}
+
+	wideFrame, err := data.LongToWide(longFrame, &data.FillMissing{Mode: data.FillModeNull})
+	if err != nil {
+		return nil, err
+	}
+	wideFrame.RefID = results.RefID
+	return data.Frames{wideFrame}, nil
+}
+
+func groupingLabels(groupingFields []*data.Field, row int) data.Labels {
+	labels := data.Labels{}
+	for _, field := range groupingFields {
+		labels[field.Name] = stringValueAt(field, row)
+	}
+	return labels
+}
+
+func stringValueAt(field *data.Field, row int) string {
+	if value, ok := field.At(row).(*string); ok && value != nil {
+		return *value
+	}
+	if value, ok := field.At(row).(string); ok {
+		return value
+	}
+	return ""
+}
+
 func generateGroupKey(fields []*data.Field, row int) string {
 	groupKey := ""
 	for _, field := range fields {
@@ -0,0 +1,166 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cwModels "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogsClient answers every query with the same results. Queries containing "fail" are rejected.
+type fakeLogsClient struct {
+	cloudwatchlogsiface.CloudWatchLogsAPI
+	results [][]*cloudwatchlogs.ResultField
+}
+
+func (c *fakeLogsClient) StartQueryWithContext(_ aws.Context, input *cloudwatchlogs.StartQueryInput, _ ...request.Option) (*cloudwatchlogs.StartQueryOutput, error) {
+	if strings.Contains(*input.QueryString, "fail") {
+		return nil, errors.New("malformed query")
+	}
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("query-1")}, nil
+}
+
+func (c *fakeLogsClient) GetQueryResultsWithContext(aws.Context, *cloudwatchlogs.GetQueryResultsInput, ...request.Option) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	return &cloudwatchlogs.GetQueryResultsOutput{Status: aws.String("Complete"), Results: c.results}, nil
+}
+
+func resultRow(fields ...string) []*cloudwatchlogs.ResultField {
+	row := make([]*cloudwatchlogs.ResultField, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		row = append(row, &cloudwatchlogs.ResultField{Field: aws.String(fields[i]), Value: aws.String(fields[i+1])})
+	}
+	return row
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestLogsFrameToAlertFrames(t *testing.T) {
+	t0 := time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	tests := []struct {
+		name        string
+		frame       *data.Frame
+		statsGroups []string
+		expected    data.Frames
+		err         string
+	}{
+		{
+			name: "numeric results without time become one frame per row",
+			frame: data.NewFrame("A",
+				data.NewField("host", nil, []*string{aws.String("a"), aws.String("b")}),
+				data.NewField("count", nil, []*float64{aws.Float64(3), aws.Float64(5)}),
+			),
+			statsGroups: []string{"host"},
+			expected: data.Frames{
+				data.NewFrame("A", data.NewField("count", data.Labels{"host": "a"}, []*float64{aws.Float64(3)})),
+				data.NewFrame("A", data.NewField("count", data.Labels{"host": "b"}, []*float64{aws.Float64(5)})),
+			},
+		},
+		{
+			name: "results with time become a wide time series",
+			frame: data.NewFrame("A",
+				data.NewField("bin(1m)", nil, []*time.Time{timePtr(t1), timePtr(t0), timePtr(t0)}),
+				data.NewField("host", nil, []*string{aws.String("a"), aws.String("a"), aws.String("b")}),
+				data.NewField("count", nil, []*float64{aws.Float64(2), aws.Float64(1), aws.Float64(4)}),
+			),
+			statsGroups: []string{"host"},
+			expected: data.Frames{
+				data.NewFrame("A",
+					data.NewField("bin(1m)", nil, []time.Time{t0, t1}),
+					data.NewField("count", data.Labels{"host": "a"}, []*float64{aws.Float64(1), aws.Float64(2)}),
+					data.NewField("count", data.Labels{"host": "b"}, []*float64{aws.Float64(4), nil}),
+				),
+			},
+		},
+		{
+			name: "results with time and without groups stay a single series",
+			frame: data.NewFrame("A",
+				data.NewField("bin(1m)", nil, []*time.Time{timePtr(t0), nil}),
+				data.NewField("count", nil, []*float64{aws.Float64(1), aws.Float64(2)}),
+			),
+			expected: data.Frames{
+				data.NewFrame("A",
+					data.NewField("bin(1m)", nil, []time.Time{t0}),
+					data.NewField("count", nil, []*float64{aws.Float64(1)}),
+				),
+			},
+		},
+		{
+			name: "non numeric results are rejected",
+			frame: data.NewFrame("A",
+				data.NewField("@message", nil, []*string{aws.String("error")}),
+			),
+			err: "logs alert query must return at least one numeric field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := logsFrameToAlertFrames(tt.frame, tt.statsGroups)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, frames, len(tt.expected))
+			for i, frame := range frames {
+				assert.Equal(t, tt.expected[i].Fields, frame.Fields)
+			}
+		})
+	}
+}
+
+func TestExecuteLogAlertQuery(t *testing.T) {
+	client := &fakeLogsClient{results: [][]*cloudwatchlogs.ResultField{
+		resultRow("bin(1m)", "2023-03-01 11:00:00.000", "count", "3"),
+		resultRow("bin(1m)", "2023-03-01 11:01:00.000", "count", "5"),
+	}}
+	origNewCWLogsClient := newCWLogsClient
+	t.Cleanup(func() { newCWLogsClient = origNewCWLogsClient })
+	newCWLogsClient = func(*session.Session) cloudwatchlogsiface.CloudWatchLogsAPI {
+		return client
+	}
+
+	executor := newTestExecutor()
+	executor.settings = cwModels.CloudWatchSettings{LogsPollInterval: cwModels.Duration{Duration: time.Millisecond}, LogsTimeout: cwModels.Duration{Duration: time.Second}}
+	to := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	query := func(refID string, queryString string) backend.DataQuery {
+		b, err := json.Marshal(map[string]interface{}{"queryMode": logsQueryMode, "expression": queryString, "logGroupNames": []string{"app"}})
+		require.NoError(t, err)
+		return backend.DataQuery{RefID: refID, JSON: b, TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to}}
+	}
+	res, err := executor.executeLogAlertQuery(context.Background(), &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{query("A", "stats count(*) by bin(1m)"), query("B", "fail"), {RefID: "C", JSON: []byte(`{"queryMode":`)}},
+	})
+	require.NoError(t, err)
+
+	a := res.Responses["A"]
+	require.NoError(t, a.Error)
+	require.Len(t, a.Frames, 1)
+	assert.Equal(t, "A", a.Frames[0].RefID)
+	require.Equal(t, 2, a.Frames[0].Rows())
+	assert.Equal(t, 5.0, *a.Frames[0].Fields[1].At(1).(*float64))
+
+	b := res.Responses["B"]
+	require.Error(t, b.Error)
+	assert.Contains(t, b.Error.Error(), "malformed query")
+
+	c := res.Responses["C"]
+	require.Error(t, c.Error)
+	assert.Contains(t, c.Error.Error(), "failed to parse the logs query")
+}
@@ -126,23 +126,7 @@ func (e *cloudWatchExecutor) handleGetEc2InstanceAttribute(pluginCtx backend.Plu
 		return nil, fmt.Errorf("error unmarshaling filter: %v", err)
 	}
 
-	var filters []*ec2.Filter
-	for k, v := range filterMap {
-		if vv, ok := v.([]interface{}); ok {
-			var values []*string
-			for _, vvv := range vv {
-				if vvvv, ok := vvv.(string); ok {
-					values = append(values, &vvvv)
-				}
-			}
-			filters = append(filters, &ec2.Filter{
-				Name:   aws.String(k),
-				Values: values,
-			})
-		}
-	}
-
-	instances, err := e.ec2DescribeInstances(pluginCtx, region, filters, nil)
+	instances, err := e.ec2DescribeInstances(pluginCtx, region, ec2Filters(filterMap), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -211,26 +195,10 @@ func (e *cloudWatchExecutor) handleGetResourceArns(pluginCtx backend.PluginConte
 		return nil, fmt.Errorf("error unmarshaling filter: %v", err)
 	}
 
-	var filters []*resourcegroupstaggingapi.TagFilter
-	for k, v := range tagsMap {
-		if vv, ok := v.([]interface{}); ok {
-			var values []*string
-			for _, vvv := range vv {
-				if vvvv, ok := vvv.(string); ok {
-					values = append(values, &vvvv)
-				}
-			}
-			filters = append(filters, &resourcegroupstaggingapi.TagFilter{
-				Key:    aws.String(k),
-				Values: values,
-			})
-		}
-	}
-
 	var resourceTypes []*string
 	resourceTypes = append(resourceTypes, &resourceType)
 
-	resources, err := e.resourceGroupsGetResources(pluginCtx, region, filters, resourceTypes)
+	resources, err := e.resourceGroupsGetResources(pluginCtx, region, tagFilters(tagsMap), resourceTypes)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +212,53 @@ func (e *cloudWatchExecutor) handleGetResourceArns(pluginCtx backend.PluginConte
 	return result, nil
 }
 
+// ec2Filters converts a filter map of names to value lists, as sent by the query editor, to DescribeInstances filters.
+// Filters without values are left out.
+func ec2Filters(filterMap map[string]interface{}) []*ec2.Filter {
+	var filters []*ec2.Filter
+	for k, v := range filterMap {
+		if values := filterValues(v); len(values) > 0 {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(k),
+				Values: values,
+			})
+		}
+	}
+	return filters
+}
+
+// tagFilters converts a map of tag keys to value lists to GetResources tag filters. A key without values matches
+// any resource with the tag.
+func tagFilters(tagsMap map[string]interface{}) []*resourcegroupstaggingapi.TagFilter {
+	var filters []*resourcegroupstaggingapi.TagFilter
+	for k, v := range tagsMap {
+		filter := &resourcegroupstaggingapi.TagFilter{Key: aws.String(k)}
+		if values := filterValues(v); len(values) > 0 {
+			filter.Values = values
+		}
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// filterValues returns the values of a filter, given either as a single value or a list. It returns nil when the
+// filter has no values.
+func filterValues(v interface{}) []*string {
+	switch vv := v.(type) {
+	case string:
+		return []*string{aws.String(vv)}
+	case []interface{}:
+		values := make([]*string, 0, len(vv))
+		for _, vvv := range vv {
+			if vvvv, ok := vvv.(string); ok {
+				values = append(values, aws.String(vvvv))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 func (e *cloudWatchExecutor) ec2DescribeInstances(pluginCtx backend.PluginContext, region string, filters []*ec2.Filter, instanceIds []*string) (*ec2.DescribeInstancesOutput, error) {
 	params := &ec2.DescribeInstancesInput{
 		Filters:     filters,
@@ -294,6 +309,10 @@ func (e *cloudWatchExecutor) handleGetLogGroups(pluginCtx backend.PluginContext,
 	region := parameters.Get("region")
 	limit := parameters.Get("limit")
 	logGroupNamePrefix := parameters.Get("logGroupNamePrefix")
+	logGroupNamePattern := parameters.Get("logGroupNamePattern")
+	if len(logGroupNamePrefix) > 0 && len(logGroupNamePattern) > 0 {
+		return nil, fmt.Errorf("cannot set both log group name prefix and pattern")
+	}
 
 	logsClient, err := e.getCWLogsClient(pluginCtx, region)
 	if err != nil {
@@ -311,6 +330,9 @@ func (e *cloudWatchExecutor) handleGetLogGroups(pluginCtx backend.PluginContext,
 	if len(logGroupNamePrefix) > 0 {
 		input.LogGroupNamePrefix = aws.String(logGroupNamePrefix)
 	}
+	if len(logGroupNamePattern) > 0 {
+		input.LogGroupNamePattern = aws.String(logGroupNamePattern)
+	}
 	response, err = logsClient.DescribeLogGroups(input)
 	if err != nil || response == nil {
 		return nil, err
@@ -327,6 +349,10 @@ func (e *cloudWatchExecutor) handleGetAllLogGroups(pluginCtx backend.PluginConte
 	var nextToken *string
 
 	logGroupNamePrefix := parameters.Get("logGroupNamePrefix")
+	logGroupNamePattern := parameters.Get("logGroupNamePattern")
+	if len(logGroupNamePrefix) > 0 && len(logGroupNamePattern) > 0 {
+		return nil, fmt.Errorf("cannot set both log group name prefix and pattern")
+	}
 
 	var err error
 	logsClient, err := e.getCWLogsClient(pluginCtx, parameters.Get("region"))
@@ -344,6 +370,9 @@ func (e *cloudWatchExecutor) handleGetAllLogGroups(pluginCtx backend.PluginConte
 		if len(logGroupNamePrefix) > 0 {
 			input.LogGroupNamePrefix = aws.String(logGroupNamePrefix)
 		}
+		if len(logGroupNamePattern) > 0 {
+			input.LogGroupNamePattern = aws.String(logGroupNamePattern)
+		}
 		response, err = logsClient.DescribeLogGroups(input)
 
 		if err != nil || response == nil {
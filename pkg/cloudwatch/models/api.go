@@ -1,6 +1,8 @@
 package models
 
 import (
+	"context"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 	resources2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
 	"net/url"
 
@@ -12,13 +14,24 @@ import (
 
 type RequestContextFactoryFunc func(pluginCtx backend.PluginContext, region string) (reqCtx RequestContext, err error)
 
+// RequestContextProviderFunc builds the request context of a resource request. The middlewares bind it to the
+// context of the request to get a RequestContextFactoryFunc.
+type RequestContextProviderFunc func(ctx context.Context, pluginCtx backend.PluginContext, region string) (reqCtx RequestContext, err error)
+
 type RouteHandlerFunc func(pluginCtx backend.PluginContext, reqContextFactory RequestContextFactoryFunc, parameters url.Values) ([]byte, *HttpError)
 
+// WriteRouteHandlerFunc handles resource requests which may modify resources and therefore need the method and body of the request
+type WriteRouteHandlerFunc func(pluginCtx backend.PluginContext, reqContextFactory RequestContextFactoryFunc, method string, parameters url.Values, body []byte) ([]byte, *HttpError)
+
 type RequestContext struct {
 	MetricsClientProvider MetricsClientProvider
 	LogsAPIProvider       CloudWatchLogsAPIProvider
 	OAMClientProvider     OAMClientProvider
 	Settings              CloudWatchSettings
+	// AccountKey identifies the AWS account the clients are for. Used to cache account specific data.
+	AccountKey string
+	// MetricCatalog holds the namespaces, metrics and dimension keys known without calling ListMetrics
+	MetricCatalog *catalog.Catalog
 	//Features              featuremgmt.FeatureToggles
 }
 
@@ -28,6 +41,12 @@ type ListMetricsProvider interface {
 	GetMetricsByNamespace(r resources2.MetricsRequest) ([]resources2.ResourceResponse[resources2.Metric], error)
 }
 
+// CustomMetricsProvider discovers the metrics of custom namespaces through ListMetrics
+type CustomMetricsProvider interface {
+	GetMetricsByNamespace(r resources2.MetricsRequest) ([]resources2.ResourceResponse[resources2.Metric], error)
+	GetDimensionKeysByNamespace(r resources2.DimensionKeysRequest) ([]resources2.ResourceResponse[string], error)
+}
+
 type MetricsClientProvider interface {
 	ListMetricsWithPageLimit(params *cloudwatch.ListMetricsInput) ([]resources2.MetricResponse, error)
 }
@@ -38,6 +57,9 @@ type CloudWatchMetricsAPIProvider interface {
 
 type CloudWatchLogsAPIProvider interface {
 	DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	DescribeQueryDefinitions(*cloudwatchlogs.DescribeQueryDefinitionsInput) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error)
+	PutQueryDefinition(*cloudwatchlogs.PutQueryDefinitionInput) (*cloudwatchlogs.PutQueryDefinitionOutput, error)
+	DeleteQueryDefinition(*cloudwatchlogs.DeleteQueryDefinitionInput) (*cloudwatchlogs.DeleteQueryDefinitionOutput, error)
 }
 
 type OAMClientProvider interface {
@@ -46,7 +68,13 @@ type OAMClientProvider interface {
 }
 
 type LogGroupsProvider interface {
-	GetLogGroups(request resources2.LogGroupsRequest) ([]resources2.ResourceResponse[resources2.LogGroup], error)
+	GetLogGroups(request resources2.LogGroupsRequest) (resources2.LogGroupsResponse, error)
+}
+
+type QueryDefinitionsProvider interface {
+	GetQueryDefinitions(request resources2.QueryDefinitionsRequest) (resources2.QueryDefinitionsResponse, error)
+	PutQueryDefinition(definition resources2.QueryDefinition) (string, error)
+	DeleteQueryDefinition(id string) error
 }
 
 type AccountsProvider interface {
@@ -63,6 +63,8 @@ type CloudWatchQuery struct {
 	MetricQueryType   MetricQueryType
 	MetricEditorMode  MetricEditorMode
 	AccountId         *string
+	// AccountLabel is the label of AccountId in the monitoring account
+	AccountLabel string
 }
 
 func (q *CloudWatchQuery) GetGMDAPIMode(logger log.Logger) GMDApiMode {
@@ -4,36 +4,84 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
-const defaultLogGroupLimit = int64(50)
+const (
+	defaultLogGroupLimit = int64(50)
+	maxLogGroupLimit     = int64(1000)
+)
 
 type LogGroupsRequest struct {
 	ResourceRequest
 	Limit                                   int64
 	LogGroupNamePrefix, LogGroupNamePattern *string
+	// AccountIds restricts the search to these linked accounts. It is only set when AccountId doesn't target all accounts.
+	AccountIds []string
+	NextToken  *string
 }
 
 func (r LogGroupsRequest) IsTargetingAllAccounts() bool {
-	return *r.AccountId == "all"
+	return r.AccountId != nil && *r.AccountId == useLinkedAccountsId
 }
 
 func ParseLogGroupsRequest(parameters url.Values) (LogGroupsRequest, error) {
 	logGroupNamePrefix := setIfNotEmptyString(parameters.Get("logGroupNamePrefix"))
-	logGroupPattern := setIfNotEmptyString(parameters.Get("logGroupPattern"))
+	logGroupPattern := setIfNotEmptyString(parameters.Get("logGroupNamePattern"))
+	if logGroupPattern == nil {
+		// logGroupPattern is the name used by earlier versions of the query editor
+		logGroupPattern = setIfNotEmptyString(parameters.Get("logGroupPattern"))
+	}
 	if logGroupNamePrefix != nil && logGroupPattern != nil {
 		return LogGroupsRequest{}, fmt.Errorf("cannot set both log group name prefix and pattern")
 	}
 
-	return LogGroupsRequest{
-		Limit: getLimit(parameters.Get("limit")),
+	limit, err := parseLogGroupLimit(parameters.Get("limit"))
+	if err != nil {
+		return LogGroupsRequest{}, err
+	}
+
+	request := LogGroupsRequest{
+		Limit: limit,
 		ResourceRequest: ResourceRequest{
-			Region:    parameters.Get("region"),
-			AccountId: setIfNotEmptyString(parameters.Get("accountId")),
+			Region: parameters.Get("region"),
 		},
 		LogGroupNamePrefix:  logGroupNamePrefix,
 		LogGroupNamePattern: logGroupPattern,
-	}, nil
+		NextToken:           setIfNotEmptyString(parameters.Get("nextToken")),
+	}
+
+	accountIds := parseAccountIds(parameters["accountId"])
+	switch {
+	case len(accountIds) == 1:
+		request.AccountId = &accountIds[0]
+		if !request.IsTargetingAllAccounts() {
+			request.AccountIds = accountIds
+		}
+	case len(accountIds) > 1:
+		for _, accountId := range accountIds {
+			if accountId == useLinkedAccountsId {
+				return LogGroupsRequest{}, fmt.Errorf("accountId %q cannot be combined with other account ids", useLinkedAccountsId)
+			}
+		}
+		request.AccountId = &accountIds[0]
+		request.AccountIds = accountIds
+	}
+
+	return request, nil
+}
+
+// parseAccountIds accepts both repeated and comma separated accountId parameters
+func parseAccountIds(values []string) []string {
+	accountIds := make([]string, 0)
+	for _, value := range values {
+		for _, accountId := range strings.Split(value, ",") {
+			if accountId = strings.TrimSpace(accountId); accountId != "" {
+				accountIds = append(accountIds, accountId)
+			}
+		}
+	}
+	return accountIds
 }
 
 func setIfNotEmptyString(paramValue string) *string {
@@ -51,3 +99,11 @@ func getLimit(limit string) int64 {
 	}
 	return logGroupLimit
 }
+
+func parseLogGroupLimit(limit string) (int64, error) {
+	logGroupLimit := getLimit(limit)
+	if logGroupLimit > maxLogGroupLimit {
+		return 0, fmt.Errorf("limit must not be greater than %d", maxLogGroupLimit)
+	}
+	return logGroupLimit, nil
+}
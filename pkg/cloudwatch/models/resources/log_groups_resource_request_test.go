@@ -0,0 +1,72 @@
+package resources
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAccountIds(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "no values", values: nil, want: []string{}},
+		{name: "repeated", values: []string{"111", "222"}, want: []string{"111", "222"}},
+		{name: "comma separated", values: []string{"111,222"}, want: []string{"111", "222"}},
+		{name: "mixed with blanks", values: []string{" 111 , ,222", "", "333"}, want: []string{"111", "222", "333"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseAccountIds(tt.values))
+		})
+	}
+}
+
+func TestParseLogGroupsRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters url.Values
+		wantErr    string
+		limit      int64
+		accountId  string
+		accountIds []string
+	}{
+		{name: "default limit", parameters: url.Values{}, limit: defaultLogGroupLimit},
+		{name: "invalid limits fall back to the default", parameters: url.Values{"limit": {"-1"}}, limit: defaultLogGroupLimit},
+		{name: "max limit", parameters: url.Values{"limit": {"1000"}}, limit: maxLogGroupLimit},
+		{name: "limit above the max", parameters: url.Values{"limit": {"1001"}}, wantErr: "limit must not be greater than 1000"},
+		{name: "prefix and pattern", parameters: url.Values{"logGroupNamePrefix": {"a"}, "logGroupNamePattern": {"b"}}, wantErr: "cannot set both log group name prefix and pattern"},
+		{name: "all accounts", parameters: url.Values{"accountId": {"all"}}, limit: defaultLogGroupLimit, accountId: "all"},
+		{name: "single account", parameters: url.Values{"accountId": {"111"}}, limit: defaultLogGroupLimit, accountId: "111", accountIds: []string{"111"}},
+		{name: "several accounts", parameters: url.Values{"accountId": {"111,222"}}, limit: defaultLogGroupLimit, accountId: "111", accountIds: []string{"111", "222"}},
+		{name: "all mixed with account ids", parameters: url.Values{"accountId": {"111", "all"}}, wantErr: `accountId "all" cannot be combined with other account ids`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := ParseLogGroupsRequest(tt.parameters)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.limit, request.Limit)
+			if tt.accountId == "" {
+				assert.Nil(t, request.AccountId)
+			} else {
+				require.NotNil(t, request.AccountId)
+				assert.Equal(t, tt.accountId, *request.AccountId)
+			}
+			assert.Equal(t, tt.accountIds, request.AccountIds)
+		})
+	}
+	t.Run("the legacy pattern parameter is accepted", func(t *testing.T) {
+		request, err := ParseLogGroupsRequest(url.Values{"logGroupPattern": {"app"}})
+		require.NoError(t, err)
+		require.NotNil(t, request.LogGroupNamePattern)
+		assert.Equal(t, "app", *request.LogGroupNamePattern)
+	})
+}
@@ -0,0 +1,57 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const maxQueryDefinitionsLimit = int64(1000)
+
+type QueryDefinitionsRequest struct {
+	Region                    string
+	Limit                     int64
+	QueryDefinitionNamePrefix *string
+	NextToken                 *string
+}
+
+func ParseQueryDefinitionsRequest(parameters url.Values) (QueryDefinitionsRequest, error) {
+	request := QueryDefinitionsRequest{
+		Region:                    parameters.Get("region"),
+		QueryDefinitionNamePrefix: setIfNotEmptyString(parameters.Get("queryDefinitionNamePrefix")),
+		NextToken:                 setIfNotEmptyString(parameters.Get("nextToken")),
+	}
+
+	if limit := parameters.Get("limit"); limit != "" {
+		intLimit, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || intLimit <= 0 || intLimit > maxQueryDefinitionsLimit {
+			return QueryDefinitionsRequest{}, fmt.Errorf("limit must be a number between 1 and %d", maxQueryDefinitionsLimit)
+		}
+		request.Limit = intLimit
+	}
+
+	return request, nil
+}
+
+// ParseQueryDefinition reads a query definition from the body of a create or update request.
+// The id of an existing definition can also be given with the id parameter.
+func ParseQueryDefinition(parameters url.Values, body []byte) (QueryDefinition, error) {
+	var definition QueryDefinition
+	if err := json.Unmarshal(body, &definition); err != nil {
+		return QueryDefinition{}, fmt.Errorf("invalid query definition: %w", err)
+	}
+	if id := parameters.Get("id"); id != "" {
+		if definition.Id != "" && definition.Id != id {
+			return QueryDefinition{}, fmt.Errorf("query definition id %q does not match id parameter %q", definition.Id, id)
+		}
+		definition.Id = id
+	}
+	if definition.Name == "" {
+		return QueryDefinition{}, fmt.Errorf("query definition name is required")
+	}
+	if definition.QueryString == "" {
+		return QueryDefinition{}, fmt.Errorf("query definition queryString is required")
+	}
+	return definition, nil
+}
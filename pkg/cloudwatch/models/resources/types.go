@@ -33,3 +33,21 @@ type LogGroup struct {
 	Arn  string `json:"arn"`
 	Name string `json:"name"`
 }
+
+type LogGroupsResponse struct {
+	LogGroups []ResourceResponse[LogGroup] `json:"logGroups"`
+	NextToken *string                      `json:"nextToken,omitempty"`
+}
+
+type QueryDefinition struct {
+	Id            string   `json:"id,omitempty"`
+	Name          string   `json:"name"`
+	QueryString   string   `json:"queryString"`
+	LogGroupNames []string `json:"logGroupNames,omitempty"`
+	LastModified  int64    `json:"lastModified,omitempty"`
+}
+
+type QueryDefinitionsResponse struct {
+	QueryDefinitions []QueryDefinition `json:"queryDefinitions"`
+	NextToken        *string           `json:"nextToken,omitempty"`
+}
@@ -3,7 +3,7 @@ package resources
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/appkube/cloud-datasource/pkg/cloudwatch/constants"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 )
 
 func parseDimensionFilter(dimensionFilter string) ([]*Dimension, error) {
@@ -44,9 +44,11 @@ func parseDimensionFilter(dimensionFilter string) ([]*Dimension, error) {
 	return dimensions, nil
 }
 
+// IsCustomNamespace reports whether the namespace isn't one of the known AWS namespaces
+func IsCustomNamespace(namespace string) bool {
+	return isCustomNamespace(namespace)
+}
+
 func isCustomNamespace(namespace string) bool {
-	if _, ok := constants.NamespaceMetricsMap[namespace]; ok {
-		return false
-	}
-	return true
+	return !catalog.IsKnownNamespace(namespace)
 }
@@ -3,14 +3,92 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+const (
+	// DefaultLogsTimeout is the maximum time an alert waits for a Logs Insights query to complete.
+	DefaultLogsTimeout = 8 * time.Second
+	// DefaultLogsPollInterval is the interval between GetQueryResults calls while an alert waits.
+	DefaultLogsPollInterval = 1 * time.Second
+)
+
 type CloudWatchSettings struct {
 	awsds.AWSDatasourceSettings
-	Namespace string `json:"customMetricsNamespaces"`
+	Namespace        string   `json:"customMetricsNamespaces"`
+	LogsTimeout      Duration `json:"logsTimeout"`
+	LogsPollInterval Duration `json:"logsPollInterval"`
+	// HealthCheckElementId is the cloud element whose credentials are used by the health check
+	HealthCheckElementId int64 `json:"healthCheckElementId"`
+	// MetricCatalogPath and MetricCatalogURL point to a JSON or YAML metric catalog extending or overriding the embedded one
+	MetricCatalogPath            string   `json:"metricCatalogPath"`
+	MetricCatalogURL             string   `json:"metricCatalogUrl"`
+	MetricCatalogRefreshInterval Duration `json:"metricCatalogRefreshInterval"`
+	// MetricCatalogHTTPClient fetches MetricCatalogURL. Set by the plugin host from the datasource settings.
+	MetricCatalogHTTPClient *http.Client `json:"-"`
+}
+
+// Duration is a time.Duration which can be unmarshalled from a duration string ("30s", "2m")
+// or from a number of seconds.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case nil:
+		d.Duration = 0
+	case float64:
+		d.Duration = time.Duration(value * float64(time.Second))
+	case string:
+		if value == "" {
+			d.Duration = 0
+			return nil
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		d.Duration = parsed
+	default:
+		return fmt.Errorf("invalid duration %v", value)
+	}
+	return nil
+}
+
+// CustomNamespaces returns the configured custom metrics namespaces, without duplicates
+func (s CloudWatchSettings) CustomNamespaces() []string {
+	namespaces := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, namespace := range strings.Split(s.Namespace, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// MetricCatalogOptions returns where the metric catalog of the datasource is loaded from
+func (s CloudWatchSettings) MetricCatalogOptions() catalog.Options {
+	return catalog.Options{
+		Path:            strings.TrimSpace(s.MetricCatalogPath),
+		URL:             strings.TrimSpace(s.MetricCatalogURL),
+		RefreshInterval: s.MetricCatalogRefreshInterval.Duration,
+		HTTPClient:      s.MetricCatalogHTTPClient,
+	}
 }
 
 func LoadCloudWatchSettings(config backend.DataSourceInstanceSettings) (CloudWatchSettings, error) {
@@ -29,6 +107,13 @@ func LoadCloudWatchSettings(config backend.DataSourceInstanceSettings) (CloudWat
 		instance.Profile = config.Database
 	}
 
+	if instance.LogsTimeout.Duration <= 0 {
+		instance.LogsTimeout = Duration{DefaultLogsTimeout}
+	}
+	if instance.LogsPollInterval.Duration <= 0 {
+		instance.LogsPollInterval = Duration{DefaultLogsPollInterval}
+	}
+
 	instance.AccessKey = config.DecryptedSecureJSONData["accessKey"]
 	instance.SecretKey = config.DecryptedSecureJSONData["secretKey"]
 
@@ -142,6 +142,7 @@ func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse
 						labels[key] = values[0]
 					}
 				}
+				addAccountLabels(labels, query)
 
 				timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{})
 				valueField := data.NewField(data.TimeSeriesValueFieldName, labels, []*float64{})
@@ -167,6 +168,7 @@ func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse
 		}
 
 		labels := getLabels(label, query)
+		addAccountLabels(labels, query)
 		timestamps := []*time.Time{}
 		points := []*float64{}
 		for j, t := range metric.Timestamps {
@@ -253,6 +255,10 @@ func formatAlias(query *models.CloudWatchQuery, stat string, dimensions map[stri
 	if len(label) != 0 {
 		commonFields["label"] = label
 	}
+	if query.AccountId != nil && *query.AccountId != allAccounts {
+		commonFields["accountId"] = *query.AccountId
+		commonFields["accountLabel"] = query.AccountLabel
+	}
 
 	// since the SQL query string is not (yet) parsed, we don't know what namespace, metric, statistic and labels it's using at this point
 	if query.MetricQueryType != models.MetricQueryTypeQuery {
@@ -17,17 +17,28 @@ func DimensionKeysHandler(pluginCtx backend.PluginContext, reqCtxFactory models2
 		return nil, models2.NewHttpError("error in DimensionKeyHandler", http.StatusBadRequest, err)
 	}
 
+	// both services share the clients and the catalog of the request context
+	reqCtxFactory = reuseRequestContext(reqCtxFactory)
 	service, err := newListMetricsService(pluginCtx, reqCtxFactory, dimensionKeysRequest.Region)
 	if err != nil {
 		return nil, models2.NewHttpError("error in DimensionKeyHandler", http.StatusInternalServerError, err)
 	}
 
+	customService, err := newCustomMetricsService(pluginCtx, reqCtxFactory, dimensionKeysRequest.Region)
+	if err != nil {
+		return nil, models2.NewHttpError("error in DimensionKeyHandler", http.StatusInternalServerError, err)
+	}
+
 	var response []resources2.ResourceResponse[string]
-	switch dimensionKeysRequest.Type() {
-	case resources2.FilterDimensionKeysRequest:
+	switch {
+	case len(dimensionKeysRequest.DimensionFilter) > 0:
 		response, err = service.GetDimensionKeysByDimensionFilter(dimensionKeysRequest)
+	case dimensionKeysRequest.Type() == resources2.FilterDimensionKeysRequest,
+		!customService.metricCatalog.HasNamespace(dimensionKeysRequest.Namespace):
+		// custom namespace, or one known to the catalog of another datasource only, served from the account's cached metrics
+		response, err = customService.GetDimensionKeysByNamespace(dimensionKeysRequest)
 	default:
-		response, err = services2.GetHardCodedDimensionKeysByNamespace(dimensionKeysRequest.Namespace)
+		response, err = services2.GetHardCodedDimensionKeysByNamespace(customService.metricCatalog, dimensionKeysRequest.Namespace)
 	}
 	if err != nil {
 		return nil, models2.NewHttpError("error in DimensionKeyHandler", http.StatusInternalServerError, err)
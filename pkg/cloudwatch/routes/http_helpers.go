@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
 	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 func respondWithError(rw http.ResponseWriter, httpError *models.HttpError) {
@@ -19,3 +21,21 @@ func respondWithError(rw http.ResponseWriter, httpError *models.HttpError) {
 		rw.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+// reuseRequestContext returns a factory which builds the request context of a region once, for handlers which
+// create several services
+func reuseRequestContext(reqCtxFactory models.RequestContextFactoryFunc) models.RequestContextFactoryFunc {
+	type result struct {
+		reqCtx models.RequestContext
+		err    error
+	}
+	built := map[string]result{}
+	return func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+		if r, ok := built[region]; ok {
+			return r.reqCtx, r.err
+		}
+		reqCtx, err := reqCtxFactory(pluginCtx, region)
+		built[region] = result{reqCtx: reqCtx, err: err}
+		return reqCtx, err
+	}
+}
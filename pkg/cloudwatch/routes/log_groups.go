@@ -2,25 +2,40 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/services"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/oam"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 func LogGroupsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models2.HttpError) {
 	request, err := resources.ParseLogGroupsRequest(parameters)
 	if err != nil {
-		return nil, models2.NewHttpError("cannot set both log group name prefix and pattern", http.StatusBadRequest, err)
+		return nil, models2.NewHttpError("invalid log groups request", http.StatusBadRequest, err)
+	}
+	if request.Region == "" {
+		request.Region = "default"
 	}
 
-	service, err := newLogGroupsService(pluginCtx, reqCtxFactory, request.Region)
+	service, err := newLogGroupsService(pluginCtx, reqCtxFactory, request.Region, request.AccountId != nil)
 	if err != nil {
 		return nil, models2.NewHttpError("newLogGroupsService error", http.StatusInternalServerError, err)
 	}
+	if request.AccountIds != nil && !service.isCrossAccountEnabled {
+		if !service.isMonitoringAccountKnown {
+			return nil, models2.NewHttpError("unable to filter the log groups by account", http.StatusBadGateway,
+				fmt.Errorf("the monitoring account sink couldn't be checked with OAM"))
+		}
+		return nil, models2.NewHttpError("invalid log groups request", http.StatusBadRequest,
+			fmt.Errorf("filtering by account requires a CloudWatch monitoring account"))
+	}
 
 	logGroups, err := service.GetLogGroups(request)
 	if err != nil {
@@ -35,14 +50,72 @@ func LogGroupsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.Req
 	return logGroupsResponse, nil
 }
 
-// newLogGroupsService is a describe log groups service factory.
+// monitoringAccountTTL is how long the result of the monitoring account check is reused
+const monitoringAccountTTL = 10 * time.Minute
+
+type monitoringAccountEntry struct {
+	isMonitoringAccount bool
+	expires             time.Time
+}
+
+// monitoringAccounts caches whether an account is a CloudWatch monitoring account, by account and region
+var monitoringAccounts = struct {
+	sync.Mutex
+	entries map[string]monitoringAccountEntry
+}{entries: make(map[string]monitoringAccountEntry)}
+
+type logGroupsService struct {
+	models2.LogGroupsProvider
+	isCrossAccountEnabled bool
+	// isMonitoringAccountKnown is false when OAM couldn't be asked whether the account is a monitoring account
+	isMonitoringAccountKnown bool
+}
+
+// newLogGroupsService is a describe log groups service factory. Searching linked accounts is enabled when
+// accounts are requested and OAM reports a sink, i.e. the account is a CloudWatch monitoring account.
 //
 // Stubbable by tests.
-var newLogGroupsService = func(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, region string) (models2.LogGroupsProvider, error) {
+var newLogGroupsService = func(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, region string, crossAccount bool) (logGroupsService, error) {
 	reqCtx, err := reqCtxFactory(pluginCtx, region)
 	if err != nil {
-		return nil, err
+		return logGroupsService{}, err
+	}
+	isCrossAccountEnabled, isMonitoringAccountKnown := false, true
+	if crossAccount {
+		isCrossAccountEnabled, err = isMonitoringAccount(reqCtx)
+		if err != nil {
+			backend.Logger.Warn("unable to check for a monitoring account sink", "error", err)
+			isMonitoringAccountKnown = false
+		}
+	}
+	return logGroupsService{
+		LogGroupsProvider:        services.NewLogGroupsService(reqCtx.LogsAPIProvider, isCrossAccountEnabled),
+		isCrossAccountEnabled:    isCrossAccountEnabled,
+		isMonitoringAccountKnown: isMonitoringAccountKnown,
+	}, nil
+}
+
+// isMonitoringAccount reports whether OAM has a sink in the account and region of the request context
+func isMonitoringAccount(reqCtx models2.RequestContext) (bool, error) {
+	monitoringAccounts.Lock()
+	entry, ok := monitoringAccounts.entries[reqCtx.AccountKey]
+	monitoringAccounts.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.isMonitoringAccount, nil
+	}
+
+	sinks, err := reqCtx.OAMClientProvider.ListSinks(&oam.ListSinksInput{})
+	if err != nil {
+		return false, fmt.Errorf("%v: %w", "error checking for a monitoring account sink", err)
+	}
+	now := time.Now()
+	monitoringAccounts.Lock()
+	for k, e := range monitoringAccounts.entries {
+		if now.After(e.expires) {
+			delete(monitoringAccounts.entries, k)
+		}
 	}
-	isCrossAccountEnabled := true
-	return services.NewLogGroupsService(reqCtx.LogsAPIProvider, isCrossAccountEnabled), nil
+	monitoringAccounts.entries[reqCtx.AccountKey] = monitoringAccountEntry{isMonitoringAccount: len(sinks.Items) > 0, expires: now.Add(monitoringAccountTTL)}
+	monitoringAccounts.Unlock()
+	return len(sinks.Items) > 0, nil
 }
@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOAM reports a sink when sink is set, and fails when err is set
+type fakeOAM struct {
+	sink  bool
+	err   error
+	calls int
+}
+
+func (f *fakeOAM) ListSinks(*oam.ListSinksInput) (*oam.ListSinksOutput, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	output := &oam.ListSinksOutput{}
+	if f.sink {
+		output.Items = []*oam.ListSinksItem{{}}
+	}
+	return output, nil
+}
+
+func (f *fakeOAM) ListAttachedLinks(*oam.ListAttachedLinksInput) (*oam.ListAttachedLinksOutput, error) {
+	return &oam.ListAttachedLinksOutput{}, nil
+}
+
+func TestLogGroupsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		oam        *fakeOAM
+		accountId  []string
+		limit      string
+		statusCode int
+	}{
+		{name: "without accounts", oam: &fakeOAM{err: errors.New("unreachable")}, statusCode: http.StatusOK},
+		{name: "accounts of a monitoring account", oam: &fakeOAM{sink: true}, accountId: []string{"111,222"}, statusCode: http.StatusOK},
+		{name: "accounts of an account which isn't a monitoring account", oam: &fakeOAM{}, accountId: []string{"111"}, statusCode: http.StatusBadRequest},
+		{name: "accounts when OAM can't be reached", oam: &fakeOAM{err: errors.New("unreachable")}, accountId: []string{"111"}, statusCode: http.StatusBadGateway},
+		{name: "all accounts when OAM can't be reached", oam: &fakeOAM{err: errors.New("unreachable")}, accountId: []string{"all"}, statusCode: http.StatusOK},
+		{name: "invalid limit", oam: &fakeOAM{}, limit: "5000", statusCode: http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the monitoring account check is cached by account, the accounts are made unique to each run
+			accountKey := fmt.Sprintf("%s-%d-%d", t.Name(), time.Now().UnixNano(), i)
+			reqCtxFactory := func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+				return models.RequestContext{LogsAPIProvider: &fakeLogsAPI{}, OAMClientProvider: tt.oam, AccountKey: accountKey}, nil
+			}
+			parameters := url.Values{"accountId": tt.accountId, "limit": {tt.limit}}
+			_, httpErr := LogGroupsHandler(backend.PluginContext{}, reqCtxFactory, parameters)
+			if tt.statusCode == http.StatusOK {
+				require.Nil(t, httpErr)
+				return
+			}
+			require.NotNil(t, httpErr)
+			assert.Equal(t, tt.statusCode, httpErr.StatusCode)
+		})
+	}
+	t.Run("the monitoring account check is cached by account", func(t *testing.T) {
+		api := &fakeOAM{sink: true}
+		accountKey := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+		reqCtxFactory := func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+			return models.RequestContext{LogsAPIProvider: &fakeLogsAPI{}, OAMClientProvider: api, AccountKey: accountKey}, nil
+		}
+		for i := 0; i < 2; i++ {
+			_, httpErr := LogGroupsHandler(backend.PluginContext{}, reqCtxFactory, url.Values{"accountId": {"111"}})
+			require.Nil(t, httpErr)
+		}
+		assert.Equal(t, 1, api.calls)
+	})
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
+	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/services"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// catalogReloadTimeout bounds the reload of the catalog source requested through the metric catalog endpoint
+const catalogReloadTimeout = 10 * time.Second
+
+// MetricCatalogHandler reports the version and source of the datasource's metric catalog. With refresh=true the
+// source is reloaded and the namespaces seen through ListMetrics are merged in first.
+func MetricCatalogHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, parameters url.Values) ([]byte, *models2.HttpError) {
+	region := parameters.Get("region")
+	if region == "" {
+		region = "default"
+	}
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, models2.NewHttpError("error in MetricCatalogHandler", http.StatusInternalServerError, err)
+	}
+
+	if parameters.Get("refresh") == "true" {
+		ctx, cancel := context.WithTimeout(context.Background(), catalogReloadTimeout)
+		defer cancel()
+		catalog.Reload(ctx, reqCtx.MetricCatalog, reqCtx.Settings.MetricCatalogOptions())
+		if reqCtx.MetricCatalog.StartDiscovery(0, true) {
+			if err := discoverNamespaces(reqCtx.MetricCatalog, reqCtx.MetricsClientProvider); err != nil {
+				return nil, models2.NewHttpError("error in MetricCatalogHandler", http.StatusBadGateway, err)
+			}
+		}
+	}
+
+	response, err := json.Marshal(reqCtx.MetricCatalog.Info())
+	if err != nil {
+		return nil, models2.NewHttpError("error in MetricCatalogHandler", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+// refreshMetricCatalog merges the namespaces seen through ListMetrics into the catalog of the account in the background, once per
+// refresh interval
+func refreshMetricCatalog(reqCtx models2.RequestContext) {
+	if reqCtx.MetricCatalog == nil || reqCtx.MetricsClientProvider == nil {
+		return
+	}
+	if !reqCtx.MetricCatalog.StartDiscovery(reqCtx.Settings.MetricCatalogOptions().RefreshInterval, false) {
+		return
+	}
+	go func() {
+		if err := discoverNamespaces(reqCtx.MetricCatalog, reqCtx.MetricsClientProvider); err != nil {
+			backend.Logger.Warn("unable to discover namespaces for the metric catalog", "error", err)
+		}
+	}()
+}
+
+// discoverNamespaces is a metric catalog discovery function.
+//
+// Stubbable by tests.
+var discoverNamespaces = services.DiscoverNamespaces
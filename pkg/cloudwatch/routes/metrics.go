@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/json"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
 	resources2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/services"
@@ -17,7 +18,7 @@ func MetricsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.Reque
 		return nil, models2.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
 	}
 
-	service, err := newListMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
+	service, err := newCustomMetricsService(pluginCtx, reqCtxFactory, metricsRequest.Region)
 	if err != nil {
 		return nil, models2.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
 	}
@@ -25,9 +26,24 @@ func MetricsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.Reque
 	var response []resources2.ResourceResponse[resources2.Metric]
 	switch metricsRequest.Type() {
 	case resources2.AllMetricsRequestType:
-		response = services.GetAllHardCodedMetrics()
+		response = services.GetAllHardCodedMetrics(service.metricCatalog)
+		for _, namespace := range service.namespaces {
+			if service.metricCatalog.HasNamespace(namespace) {
+				continue
+			}
+			customMetrics, err := service.GetMetricsByNamespace(resources2.MetricsRequest{ResourceRequest: metricsRequest.ResourceRequest, Namespace: namespace})
+			if err != nil {
+				return nil, models2.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
+			}
+			response = append(response, customMetrics...)
+		}
 	case resources2.MetricsByNamespaceRequestType:
-		response, err = services.GetHardCodedMetricsByNamespace(metricsRequest.Namespace)
+		if !service.metricCatalog.HasNamespace(metricsRequest.Namespace) {
+			// known to the catalog of another datasource only
+			response, err = service.GetMetricsByNamespace(metricsRequest)
+			break
+		}
+		response, err = services.GetHardCodedMetricsByNamespace(service.metricCatalog, metricsRequest.Namespace)
 	case resources2.CustomNamespaceRequestType:
 		response, err = service.GetMetricsByNamespace(metricsRequest)
 	}
@@ -42,3 +58,26 @@ func MetricsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.Reque
 
 	return metricsResponse, nil
 }
+
+type customMetricsService struct {
+	models2.CustomMetricsProvider
+	// namespaces are the custom namespaces configured for the datasource
+	namespaces    []string
+	metricCatalog *catalog.Catalog
+}
+
+// newCustomMetricsService is a custom namespace metrics service factory.
+//
+// Stubbable by tests.
+var newCustomMetricsService = func(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, region string) (customMetricsService, error) {
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return customMetricsService{}, err
+	}
+
+	return customMetricsService{
+		CustomMetricsProvider: services.NewCustomMetricsService(reqCtx.MetricsClientProvider, reqCtx.AccountKey),
+		namespaces:            reqCtx.Settings.CustomNamespaces(),
+		metricCatalog:         reqCtx.MetricCatalog,
+	}, nil
+}
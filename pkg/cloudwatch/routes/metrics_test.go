@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetricsClient lists the metrics of the namespace of the input and records the namespaces it was asked for
+type fakeMetricsClient struct {
+	mu         sync.Mutex
+	metrics    map[string][]resources.MetricResponse
+	namespaces []string
+}
+
+func (f *fakeMetricsClient) ListMetricsWithPageLimit(input *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.namespaces = append(f.namespaces, aws.StringValue(input.Namespace))
+	return f.metrics[aws.StringValue(input.Namespace)], nil
+}
+
+func newFakeMetricsClient() *fakeMetricsClient {
+	metric := func(namespace string, name string, dimension string) resources.MetricResponse {
+		return resources.MetricResponse{Metric: &cloudwatch.Metric{
+			Namespace:  aws.String(namespace),
+			MetricName: aws.String(name),
+			Dimensions: []*cloudwatch.Dimension{{Name: aws.String(dimension), Value: aws.String("value")}},
+		}}
+	}
+	return &fakeMetricsClient{metrics: map[string][]resources.MetricResponse{
+		"Custom/App":    {metric("Custom/App", "Requests", "Service"), metric("Custom/App", "Latency", "Stage")},
+		"Custom/Worker": {metric("Custom/Worker", "Jobs", "Queue")},
+	}}
+}
+
+// metricsRequestContext serves the custom namespaces from the client. The account key is unique to the test, the
+// metrics of custom namespaces are cached by account.
+func metricsRequestContext(t *testing.T, client *fakeMetricsClient, namespaces string) models.RequestContextFactoryFunc {
+	accountKey := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+	metricCatalog := catalog.Default()
+	return func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+		return models.RequestContext{
+			MetricsClientProvider: client,
+			Settings:              models.CloudWatchSettings{Namespace: namespaces},
+			AccountKey:            accountKey,
+			MetricCatalog:         metricCatalog,
+		}, nil
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	metricsOf := func(t *testing.T, res []byte) map[string][]string {
+		var response []resources.ResourceResponse[resources.Metric]
+		require.NoError(t, json.Unmarshal(res, &response))
+		metrics := map[string][]string{}
+		for _, metric := range response {
+			metrics[metric.Value.Namespace] = append(metrics[metric.Value.Namespace], metric.Value.Name)
+		}
+		return metrics
+	}
+
+	t.Run("all metrics include the metrics of the custom namespaces", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		res, httpErr := MetricsHandler(backend.PluginContext{}, metricsRequestContext(t, client, "Custom/App, AWS/EC2, Custom/Worker"), url.Values{"region": {"us-east-1"}})
+		require.Nil(t, httpErr)
+		metrics := metricsOf(t, res)
+		assert.Equal(t, []string{"Latency", "Requests"}, metrics["Custom/App"])
+		assert.Equal(t, []string{"Jobs"}, metrics["Custom/Worker"])
+		assert.Contains(t, metrics["AWS/EC2"], "CPUUtilization")
+		// namespaces of the catalog aren't listed, even when configured
+		assert.ElementsMatch(t, []string{"Custom/App", "Custom/Worker"}, client.namespaces)
+	})
+	t.Run("metrics of a custom namespace", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		reqCtxFactory := metricsRequestContext(t, client, "")
+		for i := 0; i < 2; i++ {
+			res, httpErr := MetricsHandler(backend.PluginContext{}, reqCtxFactory, url.Values{"region": {"us-east-1"}, "namespace": {"Custom/App"}})
+			require.Nil(t, httpErr)
+			assert.Equal(t, map[string][]string{"Custom/App": {"Latency", "Requests"}}, metricsOf(t, res))
+		}
+		// the metrics of the account are cached
+		assert.Equal(t, []string{"Custom/App"}, client.namespaces)
+	})
+	t.Run("metrics of a namespace of the catalog", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		res, httpErr := MetricsHandler(backend.PluginContext{}, metricsRequestContext(t, client, ""), url.Values{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.Nil(t, httpErr)
+		assert.Contains(t, metricsOf(t, res)["AWS/EC2"], "CPUUtilization")
+		assert.Empty(t, client.namespaces)
+	})
+}
+
+func TestDimensionKeysHandler(t *testing.T) {
+	keysOf := func(t *testing.T, res []byte) []string {
+		var response []resources.ResourceResponse[string]
+		require.NoError(t, json.Unmarshal(res, &response))
+		keys := []string{}
+		for _, key := range response {
+			keys = append(keys, key.Value)
+		}
+		return keys
+	}
+
+	t.Run("dimension keys of a custom namespace", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		reqCtxFactory := metricsRequestContext(t, client, "Custom/App")
+		res, httpErr := DimensionKeysHandler(backend.PluginContext{}, reqCtxFactory, url.Values{"region": {"us-east-1"}, "namespace": {"Custom/App"}})
+		require.Nil(t, httpErr)
+		assert.Equal(t, []string{"Service", "Stage"}, keysOf(t, res))
+
+		res, httpErr = DimensionKeysHandler(backend.PluginContext{}, reqCtxFactory, url.Values{"region": {"us-east-1"}, "namespace": {"Custom/App"}, "metricName": {"Latency"}})
+		require.Nil(t, httpErr)
+		assert.Equal(t, []string{"Stage"}, keysOf(t, res))
+		assert.Equal(t, []string{"Custom/App"}, client.namespaces)
+	})
+	t.Run("dimension keys of a namespace of the catalog", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		res, httpErr := DimensionKeysHandler(backend.PluginContext{}, metricsRequestContext(t, client, ""), url.Values{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}})
+		require.Nil(t, httpErr)
+		assert.Contains(t, keysOf(t, res), "InstanceId")
+		assert.Empty(t, client.namespaces)
+	})
+}
+
+func TestNamespacesHandler(t *testing.T) {
+	// without a metrics client the namespaces of the account aren't discovered in the background
+	reqCtxFactory := func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+		return models.RequestContext{
+			Settings:      models.CloudWatchSettings{Namespace: "Custom/Worker, AWS/EC2, Custom/App"},
+			MetricCatalog: catalog.Default(),
+		}, nil
+	}
+	res, httpErr := NamespacesHandler(backend.PluginContext{}, reqCtxFactory, url.Values{})
+	require.Nil(t, httpErr)
+	var response []resources.ResourceResponse[string]
+	require.NoError(t, json.Unmarshal(res, &response))
+	namespaces := []string{}
+	for _, namespace := range response {
+		namespaces = append(namespaces, namespace.Value)
+	}
+	assert.Contains(t, namespaces, "Custom/App")
+	assert.Contains(t, namespaces, "Custom/Worker")
+	count := 0
+	for _, namespace := range namespaces {
+		if namespace == "AWS/EC2" {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+	assert.IsIncreasing(t, namespaces)
+}
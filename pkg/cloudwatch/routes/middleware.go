@@ -1,15 +1,18 @@
 package routes
 
 import (
+	"context"
 	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"io"
 	"net/http"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 
 	"github.com/appkube/cloud-datasource/pkg/infra/log"
 )
 
-func ResourceRequestMiddleware(handleFunc models2.RouteHandlerFunc, logger log.Logger, reqCtxFactory models2.RequestContextFactoryFunc) func(rw http.ResponseWriter, req *http.Request) {
+func ResourceRequestMiddleware(handleFunc models2.RouteHandlerFunc, logger log.Logger, reqCtxProvider models2.RequestContextProviderFunc) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		if req.Method != "GET" {
 			respondWithError(rw, models2.NewHttpError("Invalid method", http.StatusMethodNotAllowed, nil))
@@ -18,6 +21,7 @@ func ResourceRequestMiddleware(handleFunc models2.RouteHandlerFunc, logger log.L
 
 		ctx := req.Context()
 		pluginContext := httpadapter.PluginConfigFromContext(ctx)
+		reqCtxFactory := bindRequestContext(ctx, reqCtxProvider)
 		json, httpError := handleFunc(pluginContext, reqCtxFactory, req.URL.Query())
 		if httpError != nil {
 			logger.Error("error handling resource request", "error", httpError.Message)
@@ -33,3 +37,39 @@ func ResourceRequestMiddleware(handleFunc models2.RouteHandlerFunc, logger log.L
 		}
 	}
 }
+
+// ResourceWriteRequestMiddleware is like ResourceRequestMiddleware but also accepts requests which modify resources.
+// The handler gets the method and the body of the request.
+func ResourceWriteRequestMiddleware(handleFunc models2.WriteRouteHandlerFunc, logger log.Logger, reqCtxProvider models2.RequestContextProviderFunc) func(rw http.ResponseWriter, req *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			respondWithError(rw, models2.NewHttpError("error reading request body", http.StatusBadRequest, err))
+			return
+		}
+
+		ctx := req.Context()
+		pluginContext := httpadapter.PluginConfigFromContext(ctx)
+		reqCtxFactory := bindRequestContext(ctx, reqCtxProvider)
+		json, httpError := handleFunc(pluginContext, reqCtxFactory, req.Method, req.URL.Query(), body)
+		if httpError != nil {
+			logger.Error("error handling resource request", "error", httpError.Message)
+			respondWithError(rw, httpError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, err = rw.Write(json)
+		if err != nil {
+			logger.Error("error handling resource request", "error", err)
+			respondWithError(rw, models2.NewHttpError("error writing response in resource request middleware", http.StatusInternalServerError, err))
+		}
+	}
+}
+
+// bindRequestContext returns the request context factory of a resource request
+func bindRequestContext(ctx context.Context, reqCtxProvider models2.RequestContextProviderFunc) models2.RequestContextFactoryFunc {
+	return func(pluginCtx backend.PluginContext, region string) (models2.RequestContext, error) {
+		return reqCtxProvider(ctx, pluginCtx, region)
+	}
+}
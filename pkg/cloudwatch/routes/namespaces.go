@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
-	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
@@ -19,11 +18,11 @@ func NamespacesHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.Re
 		return nil, models2.NewHttpError("error in NamespacesHandler", http.StatusInternalServerError, err)
 	}
 
-	response := services.GetHardCodedNamespaces()
-	customNamespace := reqCtx.Settings.Namespace
-	if customNamespace != "" {
-		customNamespaces := strings.Split(customNamespace, ",")
-		for _, customNamespace := range customNamespaces {
+	refreshMetricCatalog(reqCtx)
+
+	response := services.GetHardCodedNamespaces(reqCtx.MetricCatalog)
+	for _, customNamespace := range reqCtx.Settings.CustomNamespaces() {
+		if !reqCtx.MetricCatalog.HasNamespace(customNamespace) {
 			response = append(response, resources.ResourceResponse[string]{Value: customNamespace})
 		}
 	}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	models2 "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/services"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// QueryDefinitionsHandler lists (GET), creates (POST), updates (PUT) and deletes (DELETE) saved Logs Insights query definitions
+func QueryDefinitionsHandler(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, method string, parameters url.Values, body []byte) ([]byte, *models2.HttpError) {
+	service, err := newQueryDefinitionsService(pluginCtx, reqCtxFactory, parameters.Get("region"))
+	if err != nil {
+		return nil, models2.NewHttpError("newQueryDefinitionsService error", http.StatusInternalServerError, err)
+	}
+
+	var result any
+	switch method {
+	case http.MethodGet:
+		request, err := resources.ParseQueryDefinitionsRequest(parameters)
+		if err != nil {
+			return nil, models2.NewHttpError("error in QueryDefinitionsHandler", http.StatusBadRequest, err)
+		}
+		result, err = service.GetQueryDefinitions(request)
+		if err != nil {
+			return nil, queryDefinitionsError("GetQueryDefinitions error", err)
+		}
+	case http.MethodPost, http.MethodPut:
+		definition, err := resources.ParseQueryDefinition(parameters, body)
+		if err != nil {
+			return nil, models2.NewHttpError("error in QueryDefinitionsHandler", http.StatusBadRequest, err)
+		}
+		if method == http.MethodPut && definition.Id == "" {
+			return nil, models2.NewHttpError("error in QueryDefinitionsHandler", http.StatusBadRequest, fmt.Errorf("id is required to update a query definition"))
+		}
+		id, err := service.PutQueryDefinition(definition)
+		if err != nil {
+			return nil, queryDefinitionsError("PutQueryDefinition error", err)
+		}
+		definition.Id = id
+		result = definition
+	case http.MethodDelete:
+		id := parameters.Get("id")
+		if id == "" {
+			return nil, models2.NewHttpError("error in QueryDefinitionsHandler", http.StatusBadRequest, fmt.Errorf("id is required"))
+		}
+		if err := service.DeleteQueryDefinition(id); err != nil {
+			return nil, queryDefinitionsError("DeleteQueryDefinition error", err)
+		}
+		result = map[string]bool{"success": true}
+	default:
+		return nil, models2.NewHttpError("Invalid method", http.StatusMethodNotAllowed, nil)
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		return nil, models2.NewHttpError("QueryDefinitionsHandler json error", http.StatusInternalServerError, err)
+	}
+
+	return response, nil
+}
+
+func queryDefinitionsError(msg string, err error) *models2.HttpError {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case cloudwatchlogs.ErrCodeResourceNotFoundException:
+			return models2.NewHttpError(msg, http.StatusNotFound, err)
+		case cloudwatchlogs.ErrCodeInvalidParameterException:
+			return models2.NewHttpError(msg, http.StatusBadRequest, err)
+		case cloudwatchlogs.ErrCodeLimitExceededException:
+			return models2.NewHttpError(msg, http.StatusTooManyRequests, err)
+		}
+	}
+	return models2.NewHttpError(msg, http.StatusInternalServerError, err)
+}
+
+// newQueryDefinitionsService is a query definitions service factory.
+//
+// Stubbable by tests.
+var newQueryDefinitionsService = func(pluginCtx backend.PluginContext, reqCtxFactory models2.RequestContextFactoryFunc, region string) (models2.QueryDefinitionsProvider, error) {
+	if region == "" {
+		region = "default"
+	}
+	reqCtx, err := reqCtxFactory(pluginCtx, region)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewQueryDefinitionsService(reqCtx.LogsAPIProvider), nil
+}
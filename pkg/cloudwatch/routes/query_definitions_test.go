@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogsAPI records the inputs it received. DeleteQueryDefinition fails for unknown ids.
+type fakeLogsAPI struct {
+	describeInputs []*cloudwatchlogs.DescribeQueryDefinitionsInput
+	putInputs      []*cloudwatchlogs.PutQueryDefinitionInput
+	deleteInputs   []*cloudwatchlogs.DeleteQueryDefinitionInput
+}
+
+func (f *fakeLogsAPI) DescribeLogGroups(input *cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &cloudwatchlogs.DescribeLogGroupsOutput{}, nil
+}
+
+func (f *fakeLogsAPI) DescribeQueryDefinitions(input *cloudwatchlogs.DescribeQueryDefinitionsInput) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	f.describeInputs = append(f.describeInputs, input)
+	return &cloudwatchlogs.DescribeQueryDefinitionsOutput{
+		QueryDefinitions: []*cloudwatchlogs.QueryDefinition{{
+			QueryDefinitionId: aws.String("def-1"),
+			Name:              aws.String("errors"),
+			QueryString:       aws.String("filter @message like /error/"),
+			LogGroupNames:     aws.StringSlice([]string{"app"}),
+			LastModified:      aws.Int64(1677668400000),
+		}},
+		NextToken: aws.String("next"),
+	}, nil
+}
+
+func (f *fakeLogsAPI) PutQueryDefinition(input *cloudwatchlogs.PutQueryDefinitionInput) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	f.putInputs = append(f.putInputs, input)
+	id := aws.StringValue(input.QueryDefinitionId)
+	if id == "" {
+		id = "created"
+	}
+	return &cloudwatchlogs.PutQueryDefinitionOutput{QueryDefinitionId: aws.String(id)}, nil
+}
+
+func (f *fakeLogsAPI) DeleteQueryDefinition(input *cloudwatchlogs.DeleteQueryDefinitionInput) (*cloudwatchlogs.DeleteQueryDefinitionOutput, error) {
+	f.deleteInputs = append(f.deleteInputs, input)
+	if aws.StringValue(input.QueryDefinitionId) != "def-1" {
+		return nil, awserr.New(cloudwatchlogs.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	return &cloudwatchlogs.DeleteQueryDefinitionOutput{Success: aws.Bool(true)}, nil
+}
+
+func logsRequestContext(api *fakeLogsAPI) models.RequestContextFactoryFunc {
+	return func(pluginCtx backend.PluginContext, region string) (models.RequestContext, error) {
+		return models.RequestContext{LogsAPIProvider: api, AccountKey: "account/" + region}, nil
+	}
+}
+
+func TestQueryDefinitionsHandler(t *testing.T) {
+	t.Run("lists the definitions", func(t *testing.T) {
+		api := &fakeLogsAPI{}
+		res, httpErr := QueryDefinitionsHandler(backend.PluginContext{}, logsRequestContext(api), http.MethodGet,
+			url.Values{"region": {"us-east-1"}, "queryDefinitionNamePrefix": {"err"}, "limit": {"10"}, "nextToken": {"token"}}, nil)
+		require.Nil(t, httpErr)
+		assert.JSONEq(t, `{"queryDefinitions":[{"id":"def-1","name":"errors","queryString":"filter @message like /error/","logGroupNames":["app"],"lastModified":1677668400000}],"nextToken":"next"}`, string(res))
+		require.Len(t, api.describeInputs, 1)
+		assert.Equal(t, &cloudwatchlogs.DescribeQueryDefinitionsInput{QueryDefinitionNamePrefix: aws.String("err"), MaxResults: aws.Int64(10), NextToken: aws.String("token")}, api.describeInputs[0])
+	})
+	t.Run("creates and updates definitions", func(t *testing.T) {
+		api := &fakeLogsAPI{}
+		res, httpErr := QueryDefinitionsHandler(backend.PluginContext{}, logsRequestContext(api), http.MethodPost, url.Values{},
+			[]byte(`{"name":"errors","queryString":"fields @message","logGroupNames":["app"]}`))
+		require.Nil(t, httpErr)
+		var created map[string]any
+		require.NoError(t, json.Unmarshal(res, &created))
+		assert.Equal(t, "created", created["id"])
+		assert.Nil(t, api.putInputs[0].QueryDefinitionId)
+		assert.Equal(t, []string{"app"}, aws.StringValueSlice(api.putInputs[0].LogGroupNames))
+
+		_, httpErr = QueryDefinitionsHandler(backend.PluginContext{}, logsRequestContext(api), http.MethodPut, url.Values{"id": {"def-1"}},
+			[]byte(`{"name":"errors","queryString":"fields @message"}`))
+		require.Nil(t, httpErr)
+		assert.Equal(t, "def-1", aws.StringValue(api.putInputs[1].QueryDefinitionId))
+		assert.Nil(t, api.putInputs[1].LogGroupNames)
+	})
+	t.Run("deletes definitions", func(t *testing.T) {
+		api := &fakeLogsAPI{}
+		res, httpErr := QueryDefinitionsHandler(backend.PluginContext{}, logsRequestContext(api), http.MethodDelete, url.Values{"id": {"def-1"}}, nil)
+		require.Nil(t, httpErr)
+		assert.JSONEq(t, `{"success":true}`, string(res))
+	})
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			method     string
+			parameters url.Values
+			body       string
+			statusCode int
+		}{
+			{name: "invalid limit", method: http.MethodGet, parameters: url.Values{"limit": {"0"}}, statusCode: http.StatusBadRequest},
+			{name: "missing name", method: http.MethodPost, body: `{"queryString":"fields @message"}`, statusCode: http.StatusBadRequest},
+			{name: "mismatched ids", method: http.MethodPut, parameters: url.Values{"id": {"def-1"}}, body: `{"id":"def-2","name":"a","queryString":"b"}`, statusCode: http.StatusBadRequest},
+			{name: "update without id", method: http.MethodPut, body: `{"name":"a","queryString":"b"}`, statusCode: http.StatusBadRequest},
+			{name: "delete without id", method: http.MethodDelete, statusCode: http.StatusBadRequest},
+			{name: "delete unknown id", method: http.MethodDelete, parameters: url.Values{"id": {"def-2"}}, statusCode: http.StatusNotFound},
+			{name: "unsupported method", method: http.MethodPatch, statusCode: http.StatusMethodNotAllowed},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				parameters := tt.parameters
+				if parameters == nil {
+					parameters = url.Values{}
+				}
+				_, httpErr := QueryDefinitionsHandler(backend.PluginContext{}, logsRequestContext(&fakeLogsAPI{}), tt.method, parameters, []byte(tt.body))
+				require.NotNil(t, httpErr)
+				assert.Equal(t, tt.statusCode, httpErr.StatusCode)
+			})
+		}
+	})
+}
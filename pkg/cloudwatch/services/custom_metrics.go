@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// customMetricsCacheTTL is how long the metrics listed for a custom namespace are reused
+const customMetricsCacheTTL = 5 * time.Minute
+
+type customMetricsCacheEntry struct {
+	metrics []resources.MetricResponse
+	expires time.Time
+}
+
+var customMetricsCache = struct {
+	sync.Mutex
+	entries map[string]customMetricsCacheEntry
+}{entries: make(map[string]customMetricsCacheEntry)}
+
+type CustomMetricsService struct {
+	models.MetricsClientProvider
+	accountKey string
+}
+
+func NewCustomMetricsService(metricsClient models.MetricsClientProvider, accountKey string) models.CustomMetricsProvider {
+	return &CustomMetricsService{MetricsClientProvider: metricsClient, accountKey: accountKey}
+}
+
+func (s *CustomMetricsService) GetMetricsByNamespace(r resources.MetricsRequest) ([]resources.ResourceResponse[resources.Metric], error) {
+	metrics, err := s.listNamespaceMetrics(r.Namespace, r.ResourceRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	response := []resources.ResourceResponse[resources.Metric]{}
+	dupCheck := make(map[string]struct{})
+	for _, metric := range metrics {
+		name := aws.StringValue(metric.MetricName)
+		if _, exists := dupCheck[name]; exists {
+			continue
+		}
+		dupCheck[name] = struct{}{}
+		response = append(response, resources.ResourceResponse[resources.Metric]{AccountId: metric.AccountId, Value: resources.Metric{Name: name, Namespace: r.Namespace}})
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Value.Name < response[j].Value.Name
+	})
+	return response, nil
+}
+
+// GetDimensionKeysByNamespace returns the dimension keys of the namespace, or of one of its metrics when a metric name is given
+func (s *CustomMetricsService) GetDimensionKeysByNamespace(r resources.DimensionKeysRequest) ([]resources.ResourceResponse[string], error) {
+	metrics, err := s.listNamespaceMetrics(r.Namespace, r.ResourceRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	response := []resources.ResourceResponse[string]{}
+	dupCheck := make(map[string]struct{})
+	for _, metric := range metrics {
+		if r.MetricName != "" && aws.StringValue(metric.MetricName) != r.MetricName {
+			continue
+		}
+		for _, dim := range metric.Dimensions {
+			name := aws.StringValue(dim.Name)
+			if _, exists := dupCheck[name]; exists {
+				continue
+			}
+			dupCheck[name] = struct{}{}
+			response = append(response, resources.ResourceResponse[string]{AccountId: metric.AccountId, Value: name})
+		}
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Value < response[j].Value
+	})
+	return response, nil
+}
+
+// listNamespaceMetrics lists all metrics of a namespace, reusing the result for the same account for a while
+func (s *CustomMetricsService) listNamespaceMetrics(namespace string, r *resources.ResourceRequest) ([]resources.MetricResponse, error) {
+	key := strings.Join([]string{s.accountKey, namespace, aws.StringValue(accountIdOf(r))}, "|")
+
+	customMetricsCache.Lock()
+	entry, ok := customMetricsCache.entries[key]
+	customMetricsCache.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.metrics, nil
+	}
+
+	input := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+	setAccount(input, r)
+	metrics, err := s.ListMetricsWithPageLimit(input)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	now := time.Now()
+	customMetricsCache.Lock()
+	for k, e := range customMetricsCache.entries {
+		if now.After(e.expires) {
+			delete(customMetricsCache.entries, k)
+		}
+	}
+	customMetricsCache.entries[key] = customMetricsCacheEntry{metrics: metrics, expires: now.Add(customMetricsCacheTTL)}
+	customMetricsCache.Unlock()
+	return metrics, nil
+}
+
+func accountIdOf(r *resources.ResourceRequest) *string {
+	if r == nil {
+		return nil
+	}
+	return r.AccountId
+}
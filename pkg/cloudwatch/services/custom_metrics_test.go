@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetricsClient lists the metrics of the namespace of the input
+type fakeMetricsClient struct {
+	metrics map[string][]resources.MetricResponse
+	inputs  []cloudwatch.ListMetricsInput
+}
+
+func (f *fakeMetricsClient) ListMetricsWithPageLimit(input *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	f.inputs = append(f.inputs, *input)
+	return f.metrics[aws.StringValue(input.Namespace)], nil
+}
+
+func customMetric(accountId string, name string, dimensions ...string) resources.MetricResponse {
+	metric := &cloudwatch.Metric{MetricName: aws.String(name), Namespace: aws.String("Custom/App")}
+	for _, dimension := range dimensions {
+		metric.Dimensions = append(metric.Dimensions, &cloudwatch.Dimension{Name: aws.String(dimension), Value: aws.String("value")})
+	}
+	return resources.MetricResponse{Metric: metric, AccountId: aws.String(accountId)}
+}
+
+func newFakeMetricsClient() *fakeMetricsClient {
+	return &fakeMetricsClient{metrics: map[string][]resources.MetricResponse{
+		"Custom/App": {
+			customMetric("111", "Requests", "Service", "Stage"),
+			customMetric("111", "Latency", "Service"),
+			customMetric("111", "Requests", "Service", "Host"),
+		},
+	}}
+}
+
+// uniqueAccountKey keeps the runs from sharing the entries of the package cache
+func uniqueAccountKey(t *testing.T) string {
+	return fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+}
+
+func TestCustomMetricsService(t *testing.T) {
+	t.Run("metrics of the namespace are deduplicated and sorted", func(t *testing.T) {
+		service := NewCustomMetricsService(newFakeMetricsClient(), uniqueAccountKey(t))
+		response, err := service.GetMetricsByNamespace(resources.MetricsRequest{ResourceRequest: &resources.ResourceRequest{}, Namespace: "Custom/App"})
+		require.NoError(t, err)
+		assert.Equal(t, []resources.ResourceResponse[resources.Metric]{
+			{AccountId: aws.String("111"), Value: resources.Metric{Name: "Latency", Namespace: "Custom/App"}},
+			{AccountId: aws.String("111"), Value: resources.Metric{Name: "Requests", Namespace: "Custom/App"}},
+		}, response)
+	})
+	t.Run("dimension keys of the namespace or of one of its metrics", func(t *testing.T) {
+		service := NewCustomMetricsService(newFakeMetricsClient(), uniqueAccountKey(t))
+		keys := func(metricName string) []string {
+			response, err := service.GetDimensionKeysByNamespace(resources.DimensionKeysRequest{ResourceRequest: &resources.ResourceRequest{}, Namespace: "Custom/App", MetricName: metricName})
+			require.NoError(t, err)
+			values := []string{}
+			for _, key := range response {
+				values = append(values, key.Value)
+			}
+			return values
+		}
+		assert.Equal(t, []string{"Host", "Service", "Stage"}, keys(""))
+		assert.Equal(t, []string{"Service"}, keys("Latency"))
+	})
+	t.Run("the metrics are listed once per account, namespace and linked account", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		accountKey := uniqueAccountKey(t)
+		linked := "222"
+		requests := []struct {
+			accountKey string
+			namespace  string
+			accountId  *string
+		}{
+			{accountKey: accountKey, namespace: "Custom/App"},
+			{accountKey: accountKey, namespace: "Custom/App"},
+			{accountKey: accountKey + "-other", namespace: "Custom/App"},
+			{accountKey: accountKey, namespace: "Custom/Other"},
+			{accountKey: accountKey, namespace: "Custom/App", accountId: &linked},
+			{accountKey: accountKey, namespace: "Custom/App", accountId: &linked},
+		}
+		for _, r := range requests {
+			_, err := NewCustomMetricsService(client, r.accountKey).GetMetricsByNamespace(resources.MetricsRequest{ResourceRequest: &resources.ResourceRequest{AccountId: r.accountId}, Namespace: r.namespace})
+			require.NoError(t, err)
+		}
+		require.Len(t, client.inputs, 4)
+		assert.Equal(t, "Custom/Other", aws.StringValue(client.inputs[2].Namespace))
+		assert.Equal(t, "222", aws.StringValue(client.inputs[3].OwningAccount))
+	})
+	t.Run("expired entries are listed again and evicted", func(t *testing.T) {
+		client := newFakeMetricsClient()
+		accountKey := uniqueAccountKey(t)
+		request := resources.MetricsRequest{ResourceRequest: &resources.ResourceRequest{}, Namespace: "Custom/App"}
+		_, err := NewCustomMetricsService(client, accountKey).GetMetricsByNamespace(request)
+		require.NoError(t, err)
+
+		staleKey := accountKey + "-stale"
+		customMetricsCache.Lock()
+		for key, entry := range customMetricsCache.entries {
+			if !strings.HasPrefix(key, accountKey+"|") {
+				continue
+			}
+			entry.expires = time.Now().Add(-time.Second)
+			customMetricsCache.entries[key] = entry
+		}
+		customMetricsCache.entries[staleKey] = customMetricsCacheEntry{expires: time.Now().Add(-time.Second)}
+		customMetricsCache.Unlock()
+
+		_, err = NewCustomMetricsService(client, accountKey).GetMetricsByNamespace(request)
+		require.NoError(t, err)
+		assert.Len(t, client.inputs, 2)
+
+		customMetricsCache.Lock()
+		_, stale := customMetricsCache.entries[staleKey]
+		customMetricsCache.Unlock()
+		assert.False(t, stale)
+	})
+}
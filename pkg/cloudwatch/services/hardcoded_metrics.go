@@ -2,24 +2,22 @@ package services
 
 import (
 	"fmt"
-	"github.com/appkube/cloud-datasource/pkg/cloudwatch/constants"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
 	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
 )
 
-var GetHardCodedDimensionKeysByNamespace = func(namespace string) ([]resources.ResourceResponse[string], error) {
-	var response []string
-	exists := false
-	if response, exists = constants.NamespaceDimensionKeysMap[namespace]; !exists {
+var GetHardCodedDimensionKeysByNamespace = func(metricCatalog *catalog.Catalog, namespace string) ([]resources.ResourceResponse[string], error) {
+	response, exists := metricCatalog.DimensionKeys(namespace)
+	if !exists {
 		return nil, fmt.Errorf("unable to find dimensions for namespace '%q'", namespace)
 	}
 	return valuesToListMetricRespone(response), nil
 }
 
-var GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.ResourceResponse[resources.Metric], error) {
+var GetHardCodedMetricsByNamespace = func(metricCatalog *catalog.Catalog, namespace string) ([]resources.ResourceResponse[resources.Metric], error) {
 	response := []resources.Metric{}
-	exists := false
-	var metrics []string
-	if metrics, exists = constants.NamespaceMetricsMap[namespace]; !exists {
+	metrics, exists := metricCatalog.Metrics(namespace)
+	if !exists {
 		return nil, fmt.Errorf("unable to find metrics for namespace '%q'", namespace)
 	}
 
@@ -30,9 +28,10 @@ var GetHardCodedMetricsByNamespace = func(namespace string) ([]resources.Resourc
 	return valuesToListMetricRespone(response), nil
 }
 
-var GetAllHardCodedMetrics = func() []resources.ResourceResponse[resources.Metric] {
+var GetAllHardCodedMetrics = func(metricCatalog *catalog.Catalog) []resources.ResourceResponse[resources.Metric] {
 	response := []resources.Metric{}
-	for namespace, metrics := range constants.NamespaceMetricsMap {
+	for _, namespace := range metricCatalog.Namespaces() {
+		metrics, _ := metricCatalog.Metrics(namespace)
 		for _, metric := range metrics {
 			response = append(response, resources.Metric{Namespace: namespace, Name: metric})
 		}
@@ -41,11 +40,6 @@ var GetAllHardCodedMetrics = func() []resources.ResourceResponse[resources.Metri
 	return valuesToListMetricRespone(response)
 }
 
-var GetHardCodedNamespaces = func() []resources.ResourceResponse[string] {
-	response := []string{}
-	for key := range constants.NamespaceMetricsMap {
-		response = append(response, key)
-	}
-
-	return valuesToListMetricRespone(response)
+var GetHardCodedNamespaces = func(metricCatalog *catalog.Catalog) []resources.ResourceResponse[string] {
+	return valuesToListMetricRespone(metricCatalog.Namespaces())
 }
@@ -8,6 +8,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 )
 
+// describeLogGroupsPageLimit is the maximum number of log groups DescribeLogGroups returns per call
+const describeLogGroupsPageLimit = int64(50)
+
 type LogGroupsService struct {
 	logGroupsAPI          models.CloudWatchLogsAPIProvider
 	isCrossAccountEnabled bool
@@ -17,36 +20,52 @@ func NewLogGroupsService(logsClient models.CloudWatchLogsAPIProvider, isCrossAcc
 	return &LogGroupsService{logGroupsAPI: logsClient, isCrossAccountEnabled: isCrossAccountEnabled}
 }
 
-func (s *LogGroupsService) GetLogGroups(req resources2.LogGroupsRequest) ([]resources2.ResourceResponse[resources2.LogGroup], error) {
+// GetLogGroups returns up to req.Limit log groups, starting at req.NextToken. The returned next token continues the search.
+func (s *LogGroupsService) GetLogGroups(req resources2.LogGroupsRequest) (resources2.LogGroupsResponse, error) {
 	input := &cloudwatchlogs.DescribeLogGroupsInput{
-		Limit:              aws.Int64(req.Limit),
-		LogGroupNamePrefix: req.LogGroupNamePrefix,
+		LogGroupNamePrefix:  req.LogGroupNamePrefix,
+		LogGroupNamePattern: req.LogGroupNamePattern,
+		NextToken:           req.NextToken,
 	}
 
 	if s.isCrossAccountEnabled && req.AccountId != nil {
 		input.IncludeLinkedAccounts = aws.Bool(true)
-		if req.LogGroupNamePattern != nil {
-			input.LogGroupNamePrefix = req.LogGroupNamePattern
-		}
 		if !req.IsTargetingAllAccounts() {
-			// TODO: accept more than one account id in search
-			input.AccountIdentifiers = []*string{req.AccountId}
+			input.AccountIdentifiers = aws.StringSlice(req.AccountIds)
 		}
 	}
-	response, err := s.logGroupsAPI.DescribeLogGroups(input)
-	if err != nil || response == nil {
-		return nil, err
-	}
 
-	var result []resources2.ResourceResponse[resources2.LogGroup]
-	for _, logGroup := range response.LogGroups {
-		result = append(result, resources2.ResourceResponse[resources2.LogGroup]{
-			Value: resources2.LogGroup{
-				Arn:  *logGroup.Arn,
-				Name: *logGroup.LogGroupName,
-			},
-			AccountId: utils.Pointer(getAccountId(*logGroup.Arn)),
-		})
+	result := resources2.LogGroupsResponse{LogGroups: make([]resources2.ResourceResponse[resources2.LogGroup], 0)}
+	for {
+		remaining := req.Limit - int64(len(result.LogGroups))
+		if remaining > describeLogGroupsPageLimit {
+			remaining = describeLogGroupsPageLimit
+		}
+		input.Limit = aws.Int64(remaining)
+
+		response, err := s.logGroupsAPI.DescribeLogGroups(input)
+		if err != nil {
+			return resources2.LogGroupsResponse{}, err
+		}
+		if response == nil {
+			break
+		}
+
+		for _, logGroup := range response.LogGroups {
+			result.LogGroups = append(result.LogGroups, resources2.ResourceResponse[resources2.LogGroup]{
+				Value: resources2.LogGroup{
+					Arn:  aws.StringValue(logGroup.Arn),
+					Name: aws.StringValue(logGroup.LogGroupName),
+				},
+				AccountId: utils.Pointer(getAccountId(aws.StringValue(logGroup.Arn))),
+			})
+		}
+
+		result.NextToken = response.NextToken
+		if response.NextToken == nil || int64(len(result.LogGroups)) >= req.Limit {
+			break
+		}
+		input.NextToken = response.NextToken
 	}
 
 	return result, nil
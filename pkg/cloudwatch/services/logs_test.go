@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogGroupsAPI serves total log groups, in pages of the requested limit
+type fakeLogGroupsAPI struct {
+	total  int
+	inputs []cloudwatchlogs.DescribeLogGroupsInput
+}
+
+func (f *fakeLogGroupsAPI) DescribeLogGroups(input *cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	f.inputs = append(f.inputs, *input)
+	from := 0
+	if input.NextToken != nil {
+		fmt.Sscanf(*input.NextToken, "token-%d", &from) //nolint
+	}
+	output := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	to := from + int(aws.Int64Value(input.Limit))
+	for i := from; i < to && i < f.total; i++ {
+		output.LogGroups = append(output.LogGroups, &cloudwatchlogs.LogGroup{
+			Arn:          aws.String(fmt.Sprintf("arn:aws:logs:us-east-1:111:log-group:group-%d:*", i)),
+			LogGroupName: aws.String(fmt.Sprintf("group-%d", i)),
+		})
+	}
+	if to < f.total {
+		output.NextToken = aws.String(fmt.Sprintf("token-%d", to))
+	}
+	return output, nil
+}
+
+func (f *fakeLogGroupsAPI) DescribeQueryDefinitions(*cloudwatchlogs.DescribeQueryDefinitionsInput) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	return &cloudwatchlogs.DescribeQueryDefinitionsOutput{}, nil
+}
+
+func (f *fakeLogGroupsAPI) PutQueryDefinition(*cloudwatchlogs.PutQueryDefinitionInput) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	return &cloudwatchlogs.PutQueryDefinitionOutput{}, nil
+}
+
+func (f *fakeLogGroupsAPI) DeleteQueryDefinition(*cloudwatchlogs.DeleteQueryDefinitionInput) (*cloudwatchlogs.DeleteQueryDefinitionOutput, error) {
+	return &cloudwatchlogs.DeleteQueryDefinitionOutput{}, nil
+}
+
+func TestGetLogGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		limit     int64
+		nextToken *string
+		groups    int
+		pages     []int64
+		first     string
+		wantToken *string
+	}{
+		{name: "a single page", total: 10, limit: 50, groups: 10, pages: []int64{50}, first: "group-0"},
+		{name: "pages up to the limit", total: 200, limit: 120, groups: 120, pages: []int64{50, 50, 20}, first: "group-0", wantToken: aws.String("token-120")},
+		{name: "all the pages", total: 70, limit: 1000, groups: 70, pages: []int64{50, 50}, first: "group-0"},
+		{name: "continues from the next token", total: 70, limit: 50, nextToken: aws.String("token-50"), groups: 20, pages: []int64{50}, first: "group-50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeLogGroupsAPI{total: tt.total}
+			response, err := NewLogGroupsService(api, false).GetLogGroups(resources.LogGroupsRequest{Limit: tt.limit, NextToken: tt.nextToken})
+			require.NoError(t, err)
+			require.Len(t, response.LogGroups, tt.groups)
+			assert.Equal(t, tt.first, response.LogGroups[0].Value.Name)
+			assert.Equal(t, "111", *response.LogGroups[0].AccountId)
+			assert.Equal(t, tt.wantToken, response.NextToken)
+			limits := []int64{}
+			for _, input := range api.inputs {
+				limits = append(limits, aws.Int64Value(input.Limit))
+			}
+			assert.Equal(t, tt.pages, limits)
+		})
+	}
+	t.Run("linked accounts are only searched with cross account enabled", func(t *testing.T) {
+		all, account := "all", "222"
+		api := &fakeLogGroupsAPI{total: 1}
+		_, err := NewLogGroupsService(api, true).GetLogGroups(resources.LogGroupsRequest{Limit: 50, ResourceRequest: resources.ResourceRequest{AccountId: &all}})
+		require.NoError(t, err)
+		_, err = NewLogGroupsService(api, true).GetLogGroups(resources.LogGroupsRequest{Limit: 50, ResourceRequest: resources.ResourceRequest{AccountId: &account}, AccountIds: []string{account}})
+		require.NoError(t, err)
+		_, err = NewLogGroupsService(api, false).GetLogGroups(resources.LogGroupsRequest{Limit: 50, ResourceRequest: resources.ResourceRequest{AccountId: &account}, AccountIds: []string{account}})
+		require.NoError(t, err)
+
+		assert.True(t, aws.BoolValue(api.inputs[0].IncludeLinkedAccounts))
+		assert.Nil(t, api.inputs[0].AccountIdentifiers)
+		assert.Equal(t, []string{"222"}, aws.StringValueSlice(api.inputs[1].AccountIdentifiers))
+		assert.Nil(t, api.inputs[2].IncludeLinkedAccounts)
+		assert.Nil(t, api.inputs[2].AccountIdentifiers)
+	})
+}
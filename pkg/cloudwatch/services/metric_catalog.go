@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/catalog"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// awsNamespacePrefix marks the namespaces of AWS services. Only those are merged into the catalog, custom namespaces
+// are specific to an account.
+const awsNamespacePrefix = "AWS/"
+
+// DiscoverNamespaces merges the AWS namespaces, metrics and dimension keys with recent data points, as seen through
+// ListMetrics, into the catalog
+func DiscoverNamespaces(metricCatalog *catalog.Catalog, metricsClient models.MetricsClientProvider) error {
+	metrics, err := metricsClient.ListMetricsWithPageLimit(&cloudwatch.ListMetricsInput{
+		RecentlyActive: aws.String(cloudwatch.RecentlyActivePt3h),
+	})
+	if err != nil {
+		metricCatalog.FinishDiscovery()
+		return fmt.Errorf("%v: %w", "unable to call AWS API", err)
+	}
+
+	discovered := make(map[string]catalog.Namespace)
+	for _, metric := range metrics {
+		namespace := aws.StringValue(metric.Namespace)
+		if !strings.HasPrefix(namespace, awsNamespacePrefix) {
+			continue
+		}
+		ns := discovered[namespace]
+		ns.Metrics = append(ns.Metrics, aws.StringValue(metric.MetricName))
+		for _, dim := range metric.Dimensions {
+			ns.Dimensions = append(ns.Dimensions, aws.StringValue(dim.Name))
+		}
+		discovered[namespace] = ns
+	}
+	metricCatalog.MergeDiscovered(discovered)
+	return nil
+}
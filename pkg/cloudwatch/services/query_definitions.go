@@ -0,0 +1,74 @@
+package services
+
+import (
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch/models/resources"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+type QueryDefinitionsService struct {
+	logsAPI models.CloudWatchLogsAPIProvider
+}
+
+func NewQueryDefinitionsService(logsClient models.CloudWatchLogsAPIProvider) models.QueryDefinitionsProvider {
+	return &QueryDefinitionsService{logsAPI: logsClient}
+}
+
+func (s *QueryDefinitionsService) GetQueryDefinitions(req resources.QueryDefinitionsRequest) (resources.QueryDefinitionsResponse, error) {
+	input := &cloudwatchlogs.DescribeQueryDefinitionsInput{
+		QueryDefinitionNamePrefix: req.QueryDefinitionNamePrefix,
+		NextToken:                 req.NextToken,
+	}
+	if req.Limit > 0 {
+		input.MaxResults = aws.Int64(req.Limit)
+	}
+
+	response, err := s.logsAPI.DescribeQueryDefinitions(input)
+	if err != nil {
+		return resources.QueryDefinitionsResponse{}, err
+	}
+
+	result := resources.QueryDefinitionsResponse{
+		QueryDefinitions: make([]resources.QueryDefinition, 0, len(response.QueryDefinitions)),
+		NextToken:        response.NextToken,
+	}
+	for _, definition := range response.QueryDefinitions {
+		result.QueryDefinitions = append(result.QueryDefinitions, resources.QueryDefinition{
+			Id:            aws.StringValue(definition.QueryDefinitionId),
+			Name:          aws.StringValue(definition.Name),
+			QueryString:   aws.StringValue(definition.QueryString),
+			LogGroupNames: aws.StringValueSlice(definition.LogGroupNames),
+			LastModified:  aws.Int64Value(definition.LastModified),
+		})
+	}
+
+	return result, nil
+}
+
+// PutQueryDefinition creates a query definition, or updates it when the definition has an id, and returns its id
+func (s *QueryDefinitionsService) PutQueryDefinition(definition resources.QueryDefinition) (string, error) {
+	input := &cloudwatchlogs.PutQueryDefinitionInput{
+		Name:        aws.String(definition.Name),
+		QueryString: aws.String(definition.QueryString),
+	}
+	if definition.Id != "" {
+		input.QueryDefinitionId = aws.String(definition.Id)
+	}
+	if len(definition.LogGroupNames) > 0 {
+		input.LogGroupNames = aws.StringSlice(definition.LogGroupNames)
+	}
+
+	response, err := s.logsAPI.PutQueryDefinition(input)
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(response.QueryDefinitionId), nil
+}
+
+func (s *QueryDefinitionsService) DeleteQueryDefinition(id string) error {
+	_, err := s.logsAPI.DeleteQueryDefinition(&cloudwatchlogs.DeleteQueryDefinitionInput{
+		QueryDefinitionId: aws.String(id),
+	})
+	return err
+}
@@ -47,8 +47,7 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, logger
 	//}
 
 	// change for appkube datasource
-	var result []*models.CloudWatchQuery
-	result = append(result, requestQueries)
+	result := e.expandAccountQueries(req.PluginContext, requestQueries)
 	// change for appkube datasource
 
 	requestQueriesByRegion := make(map[string][]*models.CloudWatchQuery)
@@ -61,7 +60,7 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, logger
 	//requestQueriesByRegion[requestQueries.Region] = append(requestQueriesByRegion[requestQueries.Region], requestQueriesByRegion)
 	//}
 
-	resultChan := make(chan *responseWrapper, len(req.Queries))
+	resultChan := make(chan *responseWrapper, len(req.Queries)+len(result))
 	eg, ectx := errgroup.WithContext(ctx)
 	for r, q := range requestQueriesByRegion {
 		requestQueries := q
@@ -130,6 +129,13 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, logger
 
 	for result := range resultChan {
 		fmt.Println("Accumulating AWS CW result", result.DataResponse)
+		// the queries expanded per account share the refId of the query
+		if existing, ok := resp.Responses[result.RefId]; ok {
+			result.DataResponse.Frames = append(existing.Frames, result.DataResponse.Frames...)
+			if result.DataResponse.Error == nil {
+				result.DataResponse.Error = existing.Error
+			}
+		}
 		resp.Responses[result.RefId] = *result.DataResponse
 	}
 
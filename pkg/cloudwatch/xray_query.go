@@ -0,0 +1,485 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"github.com/aws/aws-sdk-go/service/xray/xrayiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// X-Ray query modes
+const (
+	xrayModeTraceSummaries = "traceSummaries"
+	xrayModeTrace          = "trace"
+	xrayModeServiceMap     = "serviceMap"
+)
+
+const (
+	defaultTraceSummariesLimit = int64(1000)
+	maxTraceSummariesLimit     = int64(10000)
+	// maxBatchGetTraces is the number of trace ids accepted by a BatchGetTraces call
+	maxBatchGetTraces = 5
+)
+
+// Span status codes of Grafana's trace format
+const (
+	spanStatusUnset = int64(0)
+	spanStatusError = int64(2)
+)
+
+// XRayQueryJson is the model of an X-Ray query
+type XRayQueryJson struct {
+	Region string
+	// XRayMode is traceSummaries, trace or serviceMap
+	XRayMode string `json:"xrayMode"`
+	// FilterExpression filters the trace summaries, such as service("api") AND responsetime > 1
+	FilterExpression string `json:"filterExpression"`
+	// TraceId is the trace returned in trace mode. Up to 5 comma separated ids are accepted.
+	TraceId string `json:"traceId"`
+	// GroupName restricts the service map to an X-Ray group
+	GroupName string `json:"groupName"`
+	Limit     int64  `json:"limit"`
+}
+
+// executeXRayQuery returns trace summaries as a table, a trace in Grafana's trace format or the service map as node
+// graph frames
+func (e *cloudWatchExecutor) executeXRayQuery(ctx context.Context, pluginCtx backend.PluginContext, region string, query backend.DataQuery) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+
+	var model XRayQueryJson
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return nil, fmt.Errorf("invalid x-ray query: %w", err)
+	}
+	if model.Region == "" || model.Region == defaultRegion {
+		model.Region = region
+	}
+	// the region is used by the console links, so the default region is resolved here
+	if model.Region == "" || model.Region == defaultRegion {
+		model.Region = e.AwsCreds.Region
+	}
+
+	client, err := e.getXRayClient(pluginCtx, model.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames data.Frames
+	switch model.XRayMode {
+	case "", xrayModeTraceSummaries:
+		frames, err = traceSummaries(ctx, client, model, query)
+	case xrayModeTrace:
+		frames, err = traces(ctx, client, model, query)
+	case xrayModeServiceMap:
+		frames, err = serviceMap(ctx, client, model, query)
+	default:
+		return result, fmt.Errorf("invalid x-ray query: unknown mode %q", model.XRayMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	respD := result.Responses[query.RefID]
+	respD.Frames = append(respD.Frames, frames...)
+	result.Responses[query.RefID] = respD
+
+	return result, nil
+}
+
+func traceSummaries(ctx context.Context, client xrayiface.XRayAPI, model XRayQueryJson, query backend.DataQuery) (data.Frames, error) {
+	limit := model.Limit
+	if limit <= 0 {
+		limit = defaultTraceSummariesLimit
+	}
+	if limit > maxTraceSummariesLimit {
+		return nil, fmt.Errorf("invalid x-ray query: limit must not be greater than %d", maxTraceSummariesLimit)
+	}
+
+	input := &xray.GetTraceSummariesInput{
+		StartTime: aws.Time(query.TimeRange.From),
+		EndTime:   aws.Time(query.TimeRange.To),
+	}
+	if model.FilterExpression != "" {
+		input.FilterExpression = aws.String(model.FilterExpression)
+	}
+
+	summaries := make([]*xray.TraceSummary, 0)
+	err := client.GetTraceSummariesPagesWithContext(ctx, input, func(page *xray.GetTraceSummariesOutput, lastPage bool) bool {
+		for _, summary := range page.TraceSummaries {
+			if int64(len(summaries)) >= limit {
+				return false
+			}
+			summaries = append(summaries, summary)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call x-ray", err)
+	}
+
+	ids := make([]string, 0, len(summaries))
+	startTimes := make([]*time.Time, 0, len(summaries))
+	methods := make([]*string, 0, len(summaries))
+	urls := make([]*string, 0, len(summaries))
+	statuses := make([]*int64, 0, len(summaries))
+	responseTimes := make([]*float64, 0, len(summaries))
+	durations := make([]*float64, 0, len(summaries))
+	entryPoints := make([]*string, 0, len(summaries))
+	hasErrors := make([]bool, 0, len(summaries))
+	hasFaults := make([]bool, 0, len(summaries))
+	hasThrottles := make([]bool, 0, len(summaries))
+	for _, summary := range summaries {
+		id := aws.StringValue(summary.Id)
+		ids = append(ids, id)
+		startTimes = append(startTimes, traceStartTime(id))
+		var method, url *string
+		var status *int64
+		if summary.Http != nil {
+			method, url, status = summary.Http.HttpMethod, summary.Http.HttpURL, summary.Http.HttpStatus
+		}
+		methods = append(methods, method)
+		urls = append(urls, url)
+		statuses = append(statuses, status)
+		responseTimes = append(responseTimes, secondsToMillis(summary.ResponseTime))
+		durations = append(durations, secondsToMillis(summary.Duration))
+		var entryPoint *string
+		if summary.EntryPoint != nil {
+			entryPoint = summary.EntryPoint.Name
+		}
+		entryPoints = append(entryPoints, entryPoint)
+		hasErrors = append(hasErrors, aws.BoolValue(summary.HasError))
+		hasFaults = append(hasFaults, aws.BoolValue(summary.HasFault))
+		hasThrottles = append(hasThrottles, aws.BoolValue(summary.HasThrottle))
+	}
+
+	idField := data.NewField("id", nil, ids)
+	idField.SetConfig(&data.FieldConfig{Links: []data.DataLink{{
+		Title:       "View trace in X-Ray console",
+		TargetBlank: true,
+		URL:         fmt.Sprintf("https://%s.console.aws.amazon.com/xray/home?region=%s#/traces/${__value.raw}", model.Region, model.Region),
+	}}})
+	frame := data.NewFrame("traceSummaries",
+		idField,
+		data.NewField("startTime", nil, startTimes),
+		data.NewField("method", nil, methods),
+		data.NewField("url", nil, urls),
+		data.NewField("status", nil, statuses),
+		data.NewField("responseTime", nil, responseTimes).SetConfig(&data.FieldConfig{Unit: "ms"}),
+		data.NewField("duration", nil, durations).SetConfig(&data.FieldConfig{Unit: "ms"}),
+		data.NewField("entryPoint", nil, entryPoints),
+		data.NewField("hasError", nil, hasErrors),
+		data.NewField("hasFault", nil, hasFaults),
+		data.NewField("hasThrottle", nil, hasThrottles),
+	)
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTable,
+		ExecutedQueryString:    model.FilterExpression,
+	}
+	return data.Frames{frame}, nil
+}
+
+// xraySegmentDocument is the part of an X-Ray segment or subsegment document converted to a span
+type xraySegmentDocument struct {
+	Id          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	ParentId    string                 `json:"parent_id"`
+	StartTime   float64                `json:"start_time"`
+	EndTime     float64                `json:"end_time"`
+	InProgress  bool                   `json:"in_progress"`
+	Origin      string                 `json:"origin"`
+	Namespace   string                 `json:"namespace"`
+	Error       bool                   `json:"error"`
+	Fault       bool                   `json:"fault"`
+	Throttle    bool                   `json:"throttle"`
+	Http        map[string]interface{} `json:"http"`
+	Aws         map[string]interface{} `json:"aws"`
+	Annotations map[string]interface{} `json:"annotations"`
+	Subsegments []xraySegmentDocument  `json:"subsegments"`
+}
+
+type traceSpan struct {
+	traceID      string
+	spanID       string
+	parentSpanID string
+	operation    string
+	service      string
+	serviceTags  []traceKeyValue
+	startTime    float64
+	duration     float64
+	tags         []traceKeyValue
+	statusCode   int64
+}
+
+type traceKeyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+func traces(ctx context.Context, client xrayiface.XRayAPI, model XRayQueryJson, query backend.DataQuery) (data.Frames, error) {
+	traceIds := make([]string, 0)
+	for _, id := range strings.Split(model.TraceId, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			traceIds = append(traceIds, id)
+		}
+	}
+	if len(traceIds) == 0 {
+		return nil, fmt.Errorf("invalid x-ray query: traceId is required")
+	}
+	if len(traceIds) > maxBatchGetTraces {
+		return nil, fmt.Errorf("invalid x-ray query: at most %d trace ids are allowed", maxBatchGetTraces)
+	}
+
+	spans := make([]traceSpan, 0)
+	input := &xray.BatchGetTracesInput{TraceIds: aws.StringSlice(traceIds)}
+	err := client.BatchGetTracesPagesWithContext(ctx, input, func(page *xray.BatchGetTracesOutput, lastPage bool) bool {
+		for _, trace := range page.Traces {
+			for _, segment := range trace.Segments {
+				var doc xraySegmentDocument
+				if err := json.Unmarshal([]byte(aws.StringValue(segment.Document)), &doc); err != nil {
+					logger.Warn("unable to parse x-ray segment document", "segment", aws.StringValue(segment.Id), "error", err)
+					continue
+				}
+				spans = appendSegmentSpans(spans, aws.StringValue(trace.Id), doc, doc.Name, doc.Origin, doc.ParentId)
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call x-ray", err)
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].startTime < spans[j].startTime
+	})
+
+	frame := data.NewFrame("trace",
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("spanID", nil, []string{}),
+		data.NewField("parentSpanID", nil, []string{}),
+		data.NewField("operationName", nil, []string{}),
+		data.NewField("serviceName", nil, []string{}),
+		data.NewField("serviceTags", nil, []json.RawMessage{}),
+		data.NewField("startTime", nil, []float64{}),
+		data.NewField("duration", nil, []float64{}),
+		data.NewField("tags", nil, []json.RawMessage{}),
+		data.NewField("statusCode", nil, []int64{}),
+	)
+	for _, span := range spans {
+		serviceTags, err := json.Marshal(span.serviceTags)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := json.Marshal(span.tags)
+		if err != nil {
+			return nil, err
+		}
+		frame.AppendRow(span.traceID, span.spanID, span.parentSpanID, span.operation, span.service,
+			json.RawMessage(serviceTags), span.startTime, span.duration, json.RawMessage(tags), span.statusCode)
+	}
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTrace}
+	return data.Frames{frame}, nil
+}
+
+// appendSegmentSpans appends the span of a segment or subsegment and of its subsegments. Subsegments belong to the
+// service of their segment.
+func appendSegmentSpans(spans []traceSpan, traceID string, doc xraySegmentDocument, service string, origin string, parentSpanID string) []traceSpan {
+	span := traceSpan{
+		traceID:      traceID,
+		spanID:       doc.Id,
+		parentSpanID: parentSpanID,
+		operation:    doc.Name,
+		service:      service,
+		startTime:    doc.StartTime * 1000,
+		tags:         segmentTags(doc),
+		statusCode:   spanStatusUnset,
+	}
+	if !doc.InProgress && doc.EndTime > doc.StartTime {
+		span.duration = (doc.EndTime - doc.StartTime) * 1000
+	}
+	if doc.Error || doc.Fault {
+		span.statusCode = spanStatusError
+	}
+	span.serviceTags = []traceKeyValue{{Key: "name", Value: service}}
+	if origin != "" {
+		span.serviceTags = append(span.serviceTags, traceKeyValue{Key: "origin", Value: origin})
+	}
+	spans = append(spans, span)
+
+	for _, subsegment := range doc.Subsegments {
+		spans = appendSegmentSpans(spans, traceID, subsegment, service, origin, doc.Id)
+	}
+	return spans
+}
+
+func segmentTags(doc xraySegmentDocument) []traceKeyValue {
+	tags := make([]traceKeyValue, 0)
+	if doc.Namespace != "" {
+		tags = append(tags, traceKeyValue{Key: "namespace", Value: doc.Namespace})
+	}
+	for _, flag := range []struct {
+		key string
+		set bool
+	}{{"error", doc.Error}, {"fault", doc.Fault}, {"throttle", doc.Throttle}, {"in_progress", doc.InProgress}} {
+		if flag.set {
+			tags = append(tags, traceKeyValue{Key: flag.key, Value: true})
+		}
+	}
+	for _, group := range []struct {
+		prefix string
+		values map[string]interface{}
+	}{{"http", doc.Http}, {"aws", doc.Aws}, {"annotations", doc.Annotations}} {
+		flat := make(map[string]interface{})
+		flattenTags(group.prefix, group.values, flat)
+		keys := make([]string, 0, len(flat))
+		for key := range flat {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			tags = append(tags, traceKeyValue{Key: key, Value: flat[key]})
+		}
+	}
+	return tags
+}
+
+func flattenTags(prefix string, values map[string]interface{}, flat map[string]interface{}) {
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenTags(prefix+"."+key, nested, flat)
+			continue
+		}
+		flat[prefix+"."+key] = value
+	}
+}
+
+func serviceMap(ctx context.Context, client xrayiface.XRayAPI, model XRayQueryJson, query backend.DataQuery) (data.Frames, error) {
+	input := &xray.GetServiceGraphInput{
+		StartTime: aws.Time(query.TimeRange.From),
+		EndTime:   aws.Time(query.TimeRange.To),
+	}
+	if model.GroupName != "" {
+		input.GroupName = aws.String(model.GroupName)
+	}
+
+	services := make([]*xray.Service, 0)
+	err := client.GetServiceGraphPagesWithContext(ctx, input, func(page *xray.GetServiceGraphOutput, lastPage bool) bool {
+		services = append(services, page.Services...)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call x-ray", err)
+	}
+
+	nodes := data.NewFrame("nodes",
+		data.NewField("id", nil, []string{}),
+		data.NewField("title", nil, []string{}),
+		data.NewField("subTitle", nil, []string{}),
+		data.NewField("mainStat", nil, []float64{}).SetConfig(&data.FieldConfig{DisplayName: "Average response time", Unit: "ms"}),
+		data.NewField("secondaryStat", nil, []int64{}).SetConfig(&data.FieldConfig{DisplayName: "Requests"}),
+		data.NewField("arc__success", nil, []float64{}).SetConfig(arcConfig("green", "Success")),
+		data.NewField("arc__errors", nil, []float64{}).SetConfig(arcConfig("semi-dark-yellow", "Errors")),
+		data.NewField("arc__throttled", nil, []float64{}).SetConfig(arcConfig("purple", "Throttled")),
+		data.NewField("arc__faults", nil, []float64{}).SetConfig(arcConfig("red", "Faults")),
+	)
+	edges := data.NewFrame("edges",
+		data.NewField("id", nil, []string{}),
+		data.NewField("source", nil, []string{}),
+		data.NewField("target", nil, []string{}),
+		data.NewField("mainStat", nil, []float64{}).SetConfig(&data.FieldConfig{DisplayName: "Average response time", Unit: "ms"}),
+		data.NewField("secondaryStat", nil, []int64{}).SetConfig(&data.FieldConfig{DisplayName: "Requests"}),
+	)
+
+	for _, service := range services {
+		id := strconv.FormatInt(aws.Int64Value(service.ReferenceId), 10)
+		total, ok, errors, throttled, faults, responseTime := serviceStatistics(service.SummaryStatistics)
+		nodes.AppendRow(id, aws.StringValue(service.Name), aws.StringValue(service.Type), averageMillis(responseTime, total), total,
+			ratio(ok, total), ratio(errors, total), ratio(throttled, total), ratio(faults, total))
+
+		for _, edge := range service.Edges {
+			target := strconv.FormatInt(aws.Int64Value(edge.ReferenceId), 10)
+			var edgeTotal int64
+			var edgeResponseTime float64
+			if edge.SummaryStatistics != nil {
+				edgeTotal = aws.Int64Value(edge.SummaryStatistics.TotalCount)
+				edgeResponseTime = aws.Float64Value(edge.SummaryStatistics.TotalResponseTime)
+			}
+			edges.AppendRow(id+"-"+target, id, target, averageMillis(edgeResponseTime, edgeTotal), edgeTotal)
+		}
+	}
+
+	for _, frame := range []*data.Frame{nodes, edges} {
+		frame.RefID = query.RefID
+		frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph}
+	}
+	return data.Frames{nodes, edges}, nil
+}
+
+func serviceStatistics(stats *xray.ServiceStatistics) (total, ok, errors, throttled, faults int64, responseTime float64) {
+	if stats == nil {
+		return
+	}
+	total = aws.Int64Value(stats.TotalCount)
+	ok = aws.Int64Value(stats.OkCount)
+	responseTime = aws.Float64Value(stats.TotalResponseTime)
+	if stats.ErrorStatistics != nil {
+		throttled = aws.Int64Value(stats.ErrorStatistics.ThrottleCount)
+		errors = aws.Int64Value(stats.ErrorStatistics.TotalCount) - throttled
+	}
+	if stats.FaultStatistics != nil {
+		faults = aws.Int64Value(stats.FaultStatistics.TotalCount)
+	}
+	return
+}
+
+func arcConfig(color string, displayName string) *data.FieldConfig {
+	return &data.FieldConfig{
+		DisplayName: displayName,
+		Color:       map[string]interface{}{"mode": "fixed", "fixedColor": color},
+	}
+}
+
+func ratio(count int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// averageMillis returns the average of a total response time in seconds over count requests, in milliseconds
+func averageMillis(totalSeconds float64, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return totalSeconds / float64(count) * 1000
+}
+
+func secondsToMillis(seconds *float64) *float64 {
+	if seconds == nil {
+		return nil
+	}
+	millis := *seconds * 1000
+	return &millis
+}
+
+// traceStartTime returns the start time encoded in a trace id: 1-<epoch seconds in hex>-<random>
+func traceStartTime(traceID string) *time.Time {
+	parts := strings.Split(traceID, "-")
+	if len(parts) != 3 {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(parts[1], 16, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t
+}
@@ -0,0 +1,226 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"github.com/aws/aws-sdk-go/service/xray/xrayiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeXRayClient struct {
+	xrayiface.XRayAPI
+	summariesInput *xray.GetTraceSummariesInput
+	summariesPages []*xray.GetTraceSummariesOutput
+	tracesInput    *xray.BatchGetTracesInput
+	tracesOutput   *xray.BatchGetTracesOutput
+	graphInput     *xray.GetServiceGraphInput
+	graphOutput    *xray.GetServiceGraphOutput
+}
+
+func (c *fakeXRayClient) GetTraceSummariesPagesWithContext(_ aws.Context, input *xray.GetTraceSummariesInput, fn func(*xray.GetTraceSummariesOutput, bool) bool, _ ...request.Option) error {
+	c.summariesInput = input
+	for i, page := range c.summariesPages {
+		if !fn(page, i == len(c.summariesPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeXRayClient) BatchGetTracesPagesWithContext(_ aws.Context, input *xray.BatchGetTracesInput, fn func(*xray.BatchGetTracesOutput, bool) bool, _ ...request.Option) error {
+	c.tracesInput = input
+	fn(c.tracesOutput, true)
+	return nil
+}
+
+func (c *fakeXRayClient) GetServiceGraphPagesWithContext(_ aws.Context, input *xray.GetServiceGraphInput, fn func(*xray.GetServiceGraphOutput, bool) bool, _ ...request.Option) error {
+	c.graphInput = input
+	fn(c.graphOutput, true)
+	return nil
+}
+
+func stubXRayClient(t *testing.T, client *fakeXRayClient) {
+	t.Helper()
+	origNewXRayClient := NewXRayClient
+	t.Cleanup(func() { NewXRayClient = origNewXRayClient })
+	NewXRayClient = func(*session.Session) xrayiface.XRayAPI {
+		return client
+	}
+}
+
+func newXRayQuery(t *testing.T, model map[string]interface{}) backend.DataQuery {
+	t.Helper()
+	model["queryType"] = xrayQuery
+	b, err := json.Marshal(model)
+	require.NoError(t, err)
+	to := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	return backend.DataQuery{RefID: "A", JSON: b, TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to}}
+}
+
+func TestExecuteXRayQuery_TraceSummaries(t *testing.T) {
+	client := &fakeXRayClient{summariesPages: []*xray.GetTraceSummariesOutput{
+		{TraceSummaries: []*xray.TraceSummary{{
+			Id:           aws.String("1-63ff3b00-0123456789abcdef01234567"),
+			Duration:     aws.Float64(0.25),
+			ResponseTime: aws.Float64(0.2),
+			HasFault:     aws.Bool(true),
+			Http:         &xray.Http{HttpMethod: aws.String("GET"), HttpURL: aws.String("https://api/orders"), HttpStatus: aws.Int64(500)},
+			EntryPoint:   &xray.ServiceId{Name: aws.String("api")},
+		}}},
+		{TraceSummaries: []*xray.TraceSummary{{Id: aws.String("1-63ff3b01-0123456789abcdef01234567")}, {Id: aws.String("1-63ff3b02-0123456789abcdef01234567")}}},
+	}}
+	stubXRayClient(t, client)
+
+	query := newXRayQuery(t, map[string]interface{}{"filterExpression": `service("api")`, "limit": 2})
+	res, err := newTestExecutor().executeXRayQuery(context.Background(), backend.PluginContext{}, "eu-west-1", query)
+	require.NoError(t, err)
+
+	assert.Equal(t, `service("api")`, *client.summariesInput.FilterExpression)
+	assert.Equal(t, query.TimeRange.From, *client.summariesInput.StartTime)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 1)
+	frame := frames[0]
+	require.Equal(t, 2, frame.Rows())
+	assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+	assert.Equal(t, "1-63ff3b00-0123456789abcdef01234567", frame.Fields[0].At(0))
+	assert.Contains(t, frame.Fields[0].Config.Links[0].URL, "eu-west-1.console.aws.amazon.com/xray")
+	assert.Equal(t, time.Unix(0x63ff3b00, 0).UTC(), *frame.Fields[1].At(0).(*time.Time))
+	assert.Equal(t, int64(500), *frame.Fields[4].At(0).(*int64))
+	assert.Equal(t, 200.0, *frame.Fields[5].At(0).(*float64))
+	assert.Equal(t, true, frame.Fields[9].At(0))
+
+	t.Run("the default region is the region of the credentials", func(t *testing.T) {
+		for _, region := range []string{"default", ""} {
+			client.summariesPages = client.summariesPages[:1]
+			e := newTestExecutor()
+			e.AwsCreds.Region = "ap-south-1"
+			res, err := e.executeXRayQuery(context.Background(), backend.PluginContext{}, region, newXRayQuery(t, map[string]interface{}{"region": "default"}))
+			require.NoError(t, err)
+			link := res.Responses["A"].Frames[0].Fields[0].Config.Links[0].URL
+			assert.Contains(t, link, "ap-south-1.console.aws.amazon.com/xray", region)
+			assert.Contains(t, link, "region=ap-south-1", region)
+		}
+	})
+}
+
+func TestExecuteXRayQuery_Trace(t *testing.T) {
+	segment := `{"id":"seg1","name":"api","origin":"AWS::EC2::Instance","start_time":100,"end_time":100.5,"fault":true,
+		"http":{"request":{"method":"GET"},"response":{"status":500}},"annotations":{"customer":"c1"},
+		"subsegments":[{"id":"sub1","name":"DynamoDB","start_time":100.1,"end_time":100.3,"aws":{"operation":"GetItem"}}]}`
+	downstream := `{"id":"seg2","name":"orders","parent_id":"sub1","start_time":100.05,"end_time":100.2}`
+	client := &fakeXRayClient{tracesOutput: &xray.BatchGetTracesOutput{Traces: []*xray.Trace{{
+		Id: aws.String("1-trace"),
+		Segments: []*xray.Segment{
+			{Id: aws.String("seg1"), Document: aws.String(segment)},
+			{Id: aws.String("seg2"), Document: aws.String(downstream)},
+		},
+	}}}}
+	stubXRayClient(t, client)
+
+	res, err := newTestExecutor().executeXRayQuery(context.Background(), backend.PluginContext{}, "eu-west-1", newXRayQuery(t, map[string]interface{}{
+		"xrayMode": "trace",
+		"traceId":  "1-trace",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1-trace"}, aws.StringValueSlice(client.tracesInput.TraceIds))
+
+	frame := res.Responses["A"].Frames[0]
+	assert.Equal(t, data.VisTypeTrace, string(frame.Meta.PreferredVisualization))
+	require.Equal(t, 3, frame.Rows())
+
+	// spans are ordered by start time
+	assert.Equal(t, "seg1", frame.Fields[1].At(0))
+	assert.Equal(t, "", frame.Fields[2].At(0))
+	assert.Equal(t, "api", frame.Fields[4].At(0))
+	assert.InDelta(t, 500.0, frame.Fields[7].At(0).(float64), 0.001)
+	assert.Equal(t, spanStatusError, frame.Fields[9].At(0))
+	assert.Contains(t, string(frame.Fields[8].At(0).(json.RawMessage)), `{"key":"http.response.status","value":500}`)
+	assert.Contains(t, string(frame.Fields[5].At(0).(json.RawMessage)), `{"key":"origin","value":"AWS::EC2::Instance"}`)
+
+	assert.Equal(t, "seg2", frame.Fields[1].At(1))
+	assert.Equal(t, "sub1", frame.Fields[2].At(1))
+	assert.Equal(t, "orders", frame.Fields[4].At(1))
+
+	assert.Equal(t, "sub1", frame.Fields[1].At(2))
+	assert.Equal(t, "seg1", frame.Fields[2].At(2))
+	assert.Equal(t, "DynamoDB", frame.Fields[3].At(2))
+	assert.Equal(t, "api", frame.Fields[4].At(2))
+	assert.Equal(t, spanStatusUnset, frame.Fields[9].At(2))
+}
+
+func TestExecuteXRayQuery_ServiceMap(t *testing.T) {
+	client := &fakeXRayClient{graphOutput: &xray.GetServiceGraphOutput{Services: []*xray.Service{
+		{
+			ReferenceId: aws.Int64(0),
+			Name:        aws.String("api"),
+			Type:        aws.String("AWS::EC2::Instance"),
+			SummaryStatistics: &xray.ServiceStatistics{
+				TotalCount:        aws.Int64(10),
+				OkCount:           aws.Int64(6),
+				TotalResponseTime: aws.Float64(2),
+				ErrorStatistics:   &xray.ErrorStatistics{TotalCount: aws.Int64(3), ThrottleCount: aws.Int64(1)},
+				FaultStatistics:   &xray.FaultStatistics{TotalCount: aws.Int64(1)},
+			},
+			Edges: []*xray.Edge{{
+				ReferenceId:       aws.Int64(1),
+				SummaryStatistics: &xray.EdgeStatistics{TotalCount: aws.Int64(4), TotalResponseTime: aws.Float64(0.4)},
+			}},
+		},
+		{ReferenceId: aws.Int64(1), Name: aws.String("orders"), Type: aws.String("AWS::DynamoDB::Table")},
+	}}}
+	stubXRayClient(t, client)
+
+	res, err := newTestExecutor().executeXRayQuery(context.Background(), backend.PluginContext{}, "eu-west-1", newXRayQuery(t, map[string]interface{}{
+		"xrayMode":  "serviceMap",
+		"groupName": "prod",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "prod", *client.graphInput.GroupName)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 2)
+	nodes, edges := frames[0], frames[1]
+	assert.Equal(t, data.VisTypeNodeGraph, string(nodes.Meta.PreferredVisualization))
+	require.Equal(t, 2, nodes.Rows())
+	assert.Equal(t, "0", nodes.Fields[0].At(0))
+	assert.Equal(t, "api", nodes.Fields[1].At(0))
+	assert.InDelta(t, 200.0, nodes.Fields[3].At(0).(float64), 0.001)
+	assert.Equal(t, int64(10), nodes.Fields[4].At(0))
+	assert.InDelta(t, 0.6, nodes.Fields[5].At(0).(float64), 0.001)
+	assert.InDelta(t, 0.2, nodes.Fields[6].At(0).(float64), 0.001)
+	assert.InDelta(t, 0.1, nodes.Fields[7].At(0).(float64), 0.001)
+	assert.InDelta(t, 0.1, nodes.Fields[8].At(0).(float64), 0.001)
+
+	require.Equal(t, 1, edges.Rows())
+	assert.Equal(t, "0-1", edges.Fields[0].At(0))
+	assert.Equal(t, "0", edges.Fields[1].At(0))
+	assert.Equal(t, "1", edges.Fields[2].At(0))
+	assert.InDelta(t, 100.0, edges.Fields[3].At(0).(float64), 0.001)
+}
+
+func TestExecuteXRayQuery_InvalidQueries(t *testing.T) {
+	stubXRayClient(t, &fakeXRayClient{})
+	tests := map[string]map[string]interface{}{
+		"unknown mode":     {"xrayMode": "insights"},
+		"missing trace id": {"xrayMode": "trace"},
+		"too many traces":  {"xrayMode": "trace", "traceId": "1,2,3,4,5,6"},
+		"limit too high":   {"limit": 20000},
+	}
+	for name, model := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTestExecutor().executeXRayQuery(context.Background(), backend.PluginContext{}, "eu-west-1", newXRayQuery(t, model))
+			assert.Error(t, err)
+		})
+	}
+}
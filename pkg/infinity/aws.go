@@ -0,0 +1,215 @@
+package infinity
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const (
+	defaultAWSRegion  = "us-east-2"
+	defaultAWSService = "monitoring"
+	// awsCredentialsTTL is how long unused credentials are kept, each use extends it
+	awsCredentialsTTL = time.Hour
+	// maxCachedAWSCredentials bounds the credentials cache, the credentials expiring first are dropped beyond it
+	maxCachedAWSCredentials = 1000
+)
+
+// awsRequestOptions are the query specific signing options, passed to the transport with the request context
+type awsRequestOptions struct {
+	region      string
+	service     string
+	credentials *models.AwsCredential
+}
+
+type awsRequestOptionsKey struct{}
+
+func withAWSRequestOptions(ctx context.Context, query models.Query) context.Context {
+	options := awsRequestOptions{credentials: query.AWSCredentials}
+	if query.URLOptions.AWS != nil {
+		options.region = query.URLOptions.AWS.Region
+		options.service = query.URLOptions.AWS.Service
+	}
+	return context.WithValue(ctx, awsRequestOptionsKey{}, options)
+}
+
+// awsSigningTransport signs the requests with AWS SigV4. Region and service can be overridden by the query.
+type awsSigningTransport struct {
+	next     http.RoundTripper
+	settings models.InfinitySettings
+	// stsClient sends the assume role requests
+	stsClient *http.Client
+}
+
+func (t *awsSigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	options, _ := req.Context().Value(awsRequestOptionsKey{}).(awsRequestOptions)
+	region := awsRegion(t.settings, options)
+	service := t.settings.AWSSettings.Service
+	if options.service != "" {
+		service = options.service
+	}
+	if service == "" {
+		service = defaultAWSService
+	}
+	creds, err := getAWSCredentials(t.settings, options.credentials, region, t.stsClient)
+	if err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	signed := req.Clone(req.Context())
+	signed.Header.Add(headerKeyAccept, contentTypeJSON)
+	if _, err := v4.NewSigner(creds).Sign(signed, bytes.NewReader(body), service, region, time.Now()); err != nil {
+		return nil, fmt.Errorf("%v: %w", "error signing the aws request", err)
+	}
+	return t.next.RoundTrip(signed)
+}
+
+func awsRegion(settings models.InfinitySettings, options awsRequestOptions) string {
+	switch {
+	case options.region != "":
+		return options.region
+	case settings.AWSSettings.Region != "":
+		return settings.AWSSettings.Region
+	case options.credentials != nil && options.credentials.Region != "":
+		return options.credentials.Region
+	}
+	return defaultAWSRegion
+}
+
+type cachedAWSCredentials struct {
+	creds   *credentials.Credentials
+	expires time.Time
+}
+
+// awsCredentialsCache keeps the credentials by their source, so that assumed roles are only refreshed when they
+// expire. The cache holds at most maxCachedAWSCredentials credentials, unused ones are dropped after awsCredentialsTTL.
+type awsCredentialsCache struct {
+	mu    sync.Mutex
+	creds map[string]*cachedAWSCredentials
+}
+
+var sharedAWSCredentialsCache = &awsCredentialsCache{creds: map[string]*cachedAWSCredentials{}}
+
+func (c *awsCredentialsCache) get(key string) (*credentials.Credentials, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	cached, ok := c.creds[key]
+	if !ok || now.After(cached.expires) {
+		return nil, false
+	}
+	cached.expires = now.Add(awsCredentialsTTL)
+	return cached.creds, true
+}
+
+// put stores the credentials of the key and returns the cached ones, which are those of a concurrent call if it
+// stored them first
+func (c *awsCredentialsCache) put(key string, creds *credentials.Credentials) *credentials.Credentials {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if cached, ok := c.creds[key]; ok && now.Before(cached.expires) {
+		return cached.creds
+	}
+	delete(c.creds, key)
+	for k, cached := range c.creds {
+		if now.After(cached.expires) {
+			delete(c.creds, k)
+		}
+	}
+	for len(c.creds) >= maxCachedAWSCredentials {
+		oldest := ""
+		for k, cached := range c.creds {
+			if oldest == "" || cached.expires.Before(c.creds[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.creds, oldest)
+	}
+	c.creds[key] = &cachedAWSCredentials{creds: creds, expires: now.Add(awsCredentialsTTL)}
+	return creds
+}
+
+func getAWSCredentials(settings models.InfinitySettings, landingZone *models.AwsCredential, region string, stsClient *http.Client) (*credentials.Credentials, error) {
+	authType := settings.AWSSettings.AuthType
+	if authType == "" {
+		authType = models.AWSAuthTypeKeys
+	}
+	roleARN, externalID := settings.AWSSettings.AssumeRoleARN, settings.AWSSettings.ExternalID
+	var source []string
+	switch authType {
+	case models.AWSAuthTypeKeys:
+		source = []string{settings.AWSAccessKey, settings.AWSSecretKey, settings.AWSSessionToken}
+	case models.AWSAuthTypeDefault:
+		source = []string{region}
+	case models.AWSAuthTypeLandingZone:
+		if landingZone == nil {
+			return nil, errors.New("no landing zone credentials found for the query. make sure the query has a cloud element")
+		}
+		source = []string{landingZone.AccessKey, landingZone.SecretKey}
+		roleARN, externalID = landingZone.CrossAccountRoleArn, landingZone.ExternalId
+	default:
+		return nil, fmt.Errorf("unsupported aws auth type %q", authType)
+	}
+	hash := sha256.New()
+	io.WriteString(hash, strings.Join(append(source, string(authType), roleARN, externalID, region, settings.AWSSettings.STSEndpoint), "\n")) //nolint
+	key := hex.EncodeToString(hash.Sum(nil))
+	if cached, ok := sharedAWSCredentialsCache.get(key); ok {
+		return cached, nil
+	}
+
+	config := &aws.Config{Region: aws.String(region), HTTPClient: stsClient}
+	if settings.AWSSettings.STSEndpoint != "" {
+		config.Endpoint = aws.String(settings.AWSSettings.STSEndpoint)
+	}
+	var creds *credentials.Credentials
+	switch authType {
+	case models.AWSAuthTypeDefault:
+		// the default chain reads the instance and container credentials from link-local and private endpoints,
+		// which the restricted client of the STS requests blocks
+		sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "error loading the default aws credentials", err)
+		}
+		creds = sess.Config.Credentials
+	default:
+		creds = credentials.NewStaticCredentials(source[0], source[1], strings.Join(source[2:], ""))
+	}
+	if roleARN != "" {
+		sess, err := session.NewSession(config.WithCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "error creating the aws session", err)
+		}
+		creds = stscreds.NewCredentials(sess, roleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = "grafana-infinity"
+			if externalID != "" {
+				p.ExternalID = aws.String(externalID)
+			}
+		})
+	}
+	return sharedAWSCredentialsCache.put(key, creds), nil
+}
@@ -0,0 +1,180 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var credentialScope = regexp.MustCompile(`Credential=([^/]+)/\d{8}/([^/]+)/([^/]+)/aws4_request`)
+
+// signedRequest is what the sigv4Echo server saw of a request
+type signedRequest struct {
+	AccessKey    string
+	Region       string
+	Service      string
+	SessionToken string
+	Body         string
+}
+
+func sigv4Echo(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := credentialScope.FindStringSubmatch(r.Header.Get("Authorization"))
+		if match == nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"accessKey":%q,"region":%q,"service":%q,"sessionToken":%q,"body":%q}`, match[1], match[2], match[3], r.Header.Get("X-Amz-Security-Token"), string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// stsServer stands in for STS. It only answers AssumeRole calls of the given role and external id
+func stsServer(t *testing.T, roleARN, externalID string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("Action") != "AssumeRole" || r.PostForm.Get("RoleArn") != roleARN || r.PostForm.Get("ExternalId") != externalID {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>denied</Message></Error></ErrorResponse>`)
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials>
+			<AccessKeyId>ASSUMEDKEY</AccessKeyId><SecretAccessKey>assumed-secret</SecretAccessKey><SessionToken>assumed-token</SessionToken>
+			<Expiration>%s</Expiration></Credentials><AssumedRoleUser><Arn>%s/grafana-infinity</Arn><AssumedRoleId>ROLEID:grafana-infinity</AssumedRoleId></AssumedRoleUser>
+			</AssumeRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339), roleARN)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func getSignedRequest(t *testing.T, client *infinity.Client, query models.Query) signedRequest {
+	t.Helper()
+	res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+	require.NoError(t, err)
+	out := res.(map[string]any)
+	return signedRequest{
+		AccessKey:    out["accessKey"].(string),
+		Region:       out["region"].(string),
+		Service:      out["service"].(string),
+		SessionToken: out["sessionToken"].(string),
+		Body:         out["body"].(string),
+	}
+}
+
+func TestAWSAuth(t *testing.T) {
+	query := models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url"}
+	t.Run("should sign with static keys and a session token", func(t *testing.T) {
+		server := sigv4Echo(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  server.URL,
+			AuthenticationMethod: models.AuthenticationMethodAWS,
+			AWSAccessKey:         "STATICKEY",
+			AWSSecretKey:         "secret",
+			AWSSessionToken:      "session-token",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, signedRequest{AccessKey: "STATICKEY", Region: "us-east-2", Service: "monitoring", SessionToken: "session-token"}, getSignedRequest(t, client, query))
+
+		override := query
+		override.URLOptions = models.URLOptions{Method: http.MethodPost, Body: `{"a":1}`, BodyType: "raw", AWS: &models.URLAWSOptions{Region: "eu-west-1", Service: "execute-api"}}
+		assert.Equal(t, signedRequest{AccessKey: "STATICKEY", Region: "eu-west-1", Service: "execute-api", SessionToken: "session-token", Body: `{"a":1}`}, getSignedRequest(t, client, override))
+	})
+	t.Run("should assume the role with the external id", func(t *testing.T) {
+		server := sigv4Echo(t)
+		sts, calls := stsServer(t, "arn:aws:iam::123456789012:role/infinity", "external-id")
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  server.URL,
+			AuthenticationMethod: models.AuthenticationMethodAWS,
+			AWSSettings:          models.AWSSettings{Region: "ap-south-1", Service: "execute-api", AssumeRoleARN: "arn:aws:iam::123456789012:role/infinity", ExternalID: "external-id", STSEndpoint: sts.URL},
+			AWSAccessKey:         "SOURCEKEY",
+			AWSSecretKey:         "secret",
+		})
+		require.NoError(t, err)
+		expected := signedRequest{AccessKey: "ASSUMEDKEY", Region: "ap-south-1", Service: "execute-api", SessionToken: "assumed-token"}
+		assert.Equal(t, expected, getSignedRequest(t, client, query))
+		assert.Equal(t, expected, getSignedRequest(t, client, query))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("should sign with the landing zone credentials of the query", func(t *testing.T) {
+		server := sigv4Echo(t)
+		sts, calls := stsServer(t, "arn:aws:iam::210987654321:role/CrossAccount", "landing-zone-id")
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  server.URL,
+			AuthenticationMethod: models.AuthenticationMethodAWS,
+			AWSSettings:          models.AWSSettings{AuthType: models.AWSAuthTypeLandingZone, STSEndpoint: sts.URL},
+		})
+		require.NoError(t, err)
+		landingZone := query
+		landingZone.AWSCredentials = &models.AwsCredential{Region: "us-west-2", AccessKey: "LANDINGZONEKEY", SecretKey: "secret"}
+		assert.Equal(t, signedRequest{AccessKey: "LANDINGZONEKEY", Region: "us-west-2", Service: "monitoring"}, getSignedRequest(t, client, landingZone))
+
+		landingZone.AWSCredentials = &models.AwsCredential{Region: "us-west-2", AccessKey: "LANDINGZONEKEY", SecretKey: "secret", CrossAccountRoleArn: "arn:aws:iam::210987654321:role/CrossAccount", ExternalId: "landing-zone-id"}
+		assert.Equal(t, signedRequest{AccessKey: "ASSUMEDKEY", Region: "us-west-2", Service: "monitoring", SessionToken: "assumed-token"}, getSignedRequest(t, client, landingZone))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no landing zone credentials")
+	})
+	t.Run("should sign with the default credential chain", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "ENVIRONMENTKEY")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "environment-token")
+		server := sigv4Echo(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  server.URL,
+			AuthenticationMethod: models.AuthenticationMethodAWS,
+			AWSSettings:          models.AWSSettings{AuthType: models.AWSAuthTypeDefault, Region: "eu-north-1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, signedRequest{AccessKey: "ENVIRONMENTKEY", Region: "eu-north-1", Service: "monitoring", SessionToken: "environment-token"}, getSignedRequest(t, client, query))
+	})
+	t.Run("should load the default credentials of the container endpoint when private networks are blocked", func(t *testing.T) {
+		credentialsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"AccessKeyId":"CONTAINERKEY","SecretAccessKey":"secret","Token":"container-token","Expiration":%q}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		}))
+		defer credentialsServer.Close()
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+		t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", credentialsServer.URL)
+		// the api is reached through the proxy, which is exempt from the restrictions
+		proxy := sigv4Echo(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  "http://api.example.com",
+			BlockPrivateNetworks: true,
+			ProxyURL:             proxy.URL,
+			AuthenticationMethod: models.AuthenticationMethodAWS,
+			AWSSettings:          models.AWSSettings{AuthType: models.AWSAuthTypeDefault, Region: "eu-south-1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, signedRequest{AccessKey: "CONTAINERKEY", Region: "eu-south-1", Service: "monitoring", SessionToken: "container-token"}, getSignedRequest(t, client, query))
+	})
+	t.Run("should fail with an unsupported auth type", func(t *testing.T) {
+		server := sigv4Echo(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, AuthenticationMethod: models.AuthenticationMethodAWS, AWSSettings: models.AWSSettings{AuthType: "profile"}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported aws auth type")
+	})
+}
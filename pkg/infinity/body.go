@@ -0,0 +1,198 @@
+package infinity
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const defaultMaxResponseSizeInMB = 100
+
+// ResponseTooLargeError is returned when a response body exceeds the max response size of the datasource
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds the max response size of %d MB. Increase the max response size in the datasource settings", e.Limit/(1024*1024))
+}
+
+func maxResponseSize(settings models.InfinitySettings) int64 {
+	sizeInMB := int64(defaultMaxResponseSizeInMB)
+	if settings.MaxResponseSizeInMB > 0 {
+		sizeInMB = settings.MaxResponseSizeInMB
+	}
+	return sizeInMB * 1024 * 1024
+}
+
+// maxBytesReader reads at most limit bytes and fails with a ResponseTooLargeError when there are more
+type maxBytesReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func newMaxBytesReader(r io.Reader, limit int64) *maxBytesReader {
+	return &maxBytesReader{r: r, limit: limit, remaining: limit}
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		var b [1]byte
+		n, err := r.r.Read(b[:])
+		if n > 0 {
+			return 0, &ResponseTooLargeError{Limit: r.limit}
+		}
+		return 0, err
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+var simpleJSONPath = regexp.MustCompile(`^(\$\.)?[\w\-]+(\.[\w\-]+)*$`)
+
+// jsonSelection returns the paths of the response the query reads, or nil when the whole response is needed. Only
+// plain dotted root selectors are understood, anything else such as a JSONata expression reads the whole response.
+func jsonSelection(query models.Query) [][]string {
+	if query.Parser == models.InfinityParserUQL || query.Parser == models.InfinityParserGROQ || !simpleJSONPath.MatchString(query.RootSelector) {
+		return nil
+	}
+	paths := [][]string{jsonPathSegments(query.RootSelector)}
+	if options, ok := paginationOptions(query); ok && options.Mode == models.PaginationModeCursor && options.CursorSelector != "" {
+		if !simpleJSONPath.MatchString(options.CursorSelector) {
+			return nil
+		}
+		paths = append(paths, jsonPathSegments(options.CursorSelector))
+	}
+	return paths
+}
+
+// decodeJSON decodes a JSON document. When paths are given, only the values at these paths are decoded and the
+// other subtrees are skipped without being held in memory. The decoded value keeps the shape of the document so that
+// the selectors still apply to it.
+func decodeJSON(r io.Reader, paths [][]string) (any, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		_, _ = br.Discard(3)
+	}
+	dec := json.NewDecoder(br)
+	var out any
+	var err error
+	if len(paths) == 0 {
+		err = dec.Decode(&out)
+	} else {
+		out, err = decodeSelected(dec, paths)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return out, nil
+}
+
+func decodeSelected(dec *json.Decoder, paths [][]string) (any, error) {
+	for _, path := range paths {
+		if len(path) == 0 {
+			var out any
+			err := dec.Decode(&out)
+			return out, err
+		}
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		out := make(map[string]any)
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			if subPaths := childPaths(paths, key); len(subPaths) > 0 {
+				if out[key], err = decodeSelected(dec, subPaths); err != nil {
+					return nil, err
+				}
+			} else if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+		}
+		_, err = dec.Token()
+		return out, err
+	case '[':
+		out := make([]any, 0)
+		// as in JSONata, a field selector applied to an array is applied to each of its items
+		mapItems := false
+		for _, path := range paths {
+			if _, err := strconv.Atoi(path[0]); err != nil {
+				mapItems = true
+			}
+		}
+		for i := 0; dec.More(); i++ {
+			subPaths := paths
+			if !mapItems {
+				subPaths = childPaths(paths, strconv.Itoa(i))
+			}
+			var item any
+			if len(subPaths) > 0 {
+				if item, err = decodeSelected(dec, subPaths); err != nil {
+					return nil, err
+				}
+			} else if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			out = append(out, item)
+		}
+		_, err = dec.Token()
+		return out, err
+	}
+	return nil, fmt.Errorf("unexpected %v in JSON response", delim)
+}
+
+func childPaths(paths [][]string, key string) [][]string {
+	children := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		if path[0] == key {
+			children = append(children, path[1:])
+		}
+	}
+	return children
+}
+
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package infinity_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeJSON returns a document with rows users next to a large subtree the queries don't read
+func largeJSON(rows int) string {
+	var sb strings.Builder
+	sb.WriteString(`{"meta":{"count":`)
+	sb.WriteString(fmt.Sprint(rows))
+	sb.WriteString(`},"audit":[`)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"changes":[{"field":"name","from":"user %d","to":"user %d"},{"field":"tags","from":["a","b"],"to":["c"]}]}`, i, i, i)
+	}
+	sb.WriteString(`],"data":{"users":[`)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"user %d","score":%d.5}`, i, i, i)
+	}
+	sb.WriteString(`]}}`)
+	return sb.String()
+}
+
+func largeCSV(rows int) string {
+	var sb strings.Builder
+	sb.WriteString("id,name,score\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&sb, "%d,user %d,%d.5\n", i, i, i)
+	}
+	return sb.String()
+}
+
+func staticServer(tb testing.TB, body string) *httptest.Server {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no content length, so that the size limit applies while streaming
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, body)
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	body := largeCSV(100000)
+	bodies := map[models.QueryType]string{models.QueryTypeCSV: body, models.QueryTypeJSON: largeJSON(20000)}
+	for _, cacheEnabled := range []bool{false, true} {
+		for queryType, body := range bodies {
+			require.Greater(t, len(body), 1024*1024)
+			t.Run(fmt.Sprintf("%s with cache %v", queryType, cacheEnabled), func(t *testing.T) {
+				server := staticServer(t, body)
+				client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, MaxResponseSizeInMB: 1, CacheEnabled: cacheEnabled})
+				require.NoError(t, err)
+				_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: queryType}, map[string]string{})
+				var tooLarge *infinity.ResponseTooLargeError
+				require.True(t, errors.As(err, &tooLarge), "unexpected error %v", err)
+				assert.Equal(t, "response exceeds the max response size of 1 MB. Increase the max response size in the datasource settings", err.Error())
+			})
+		}
+	}
+	t.Run("should reject a too large content length before reading", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, MaxResponseSizeInMB: 1})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeCSV}, map[string]string{})
+		var tooLarge *infinity.ResponseTooLargeError
+		assert.True(t, errors.As(err, &tooLarge))
+	})
+}
+
+func TestStreamingJSONDecoding(t *testing.T) {
+	server := staticServer(t, "\xef\xbb\xbf"+largeJSON(3))
+	client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+	require.NoError(t, err)
+
+	t.Run("should only decode the root selector", func(t *testing.T) {
+		res, _, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON, RootSelector: "data.users"}, map[string]string{})
+		require.NoError(t, err)
+		users := []any{}
+		for i := 0; i < 3; i++ {
+			users = append(users, map[string]any{"id": float64(i), "name": fmt.Sprintf("user %d", i), "score": float64(i) + 0.5})
+		}
+		assert.Equal(t, map[string]any{"data": map[string]any{"users": users}}, res)
+	})
+	t.Run("should apply field selectors to the items of arrays", func(t *testing.T) {
+		res, _, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON, RootSelector: "audit.id"}, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"audit": []any{map[string]any{"id": 0.0}, map[string]any{"id": 1.0}, map[string]any{"id": 2.0}}}, res)
+	})
+	t.Run("should decode the whole response for expressions", func(t *testing.T) {
+		res, _, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON, RootSelector: "data.users[score > 1]"}, map[string]string{})
+		require.NoError(t, err)
+		assert.Contains(t, res, "audit")
+		assert.Contains(t, res, "meta")
+	})
+	t.Run("should frame the selected rows", func(t *testing.T) {
+		query := models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url", Parser: "backend", RootSelector: "data.users"}
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, frame.Rows())
+	})
+	t.Run("should fail on trailing data", func(t *testing.T) {
+		server := staticServer(t, `{"data":{"users":[]}} {}`)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON, RootSelector: "data.users"}, map[string]string{})
+		assert.Error(t, err)
+	})
+}
+
+func benchmarkGetResults(b *testing.B, body string, query models.Query) {
+	b.Helper()
+	server := staticServer(b, body)
+	client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+	require.NoError(b, err)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := client.GetResults(context.Background(), query, map[string]string{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetResults_JSON(b *testing.B) {
+	benchmarkGetResults(b, largeJSON(50000), models.Query{URL: "/", Type: models.QueryTypeJSON})
+}
+
+func BenchmarkGetResults_JSONWithRootSelector(b *testing.B) {
+	benchmarkGetResults(b, largeJSON(50000), models.Query{URL: "/", Type: models.QueryTypeJSON, RootSelector: "data.users"})
+}
+
+func BenchmarkGetResults_CSV(b *testing.B) {
+	benchmarkGetResults(b, largeCSV(200000), models.Query{URL: "/", Type: models.QueryTypeCSV})
+}
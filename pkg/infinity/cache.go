@@ -0,0 +1,241 @@
+package infinity
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const (
+	defaultCacheTTL          = time.Minute
+	defaultCacheMaxSizeInMB  = 64
+	headerKeyCacheControl    = "Cache-Control"
+	headerKeyETag            = "ETag"
+	headerKeyLastModified    = "Last-Modified"
+	headerKeyIfNoneMatch     = "If-None-Match"
+	headerKeyIfModifiedSince = "If-Modified-Since"
+)
+
+// Cache status reported in the custom meta of the frames
+const (
+	CacheStatusHit         = "hit"
+	CacheStatusMiss        = "miss"
+	CacheStatusRevalidated = "revalidated"
+	CacheStatusBypass      = "bypass"
+)
+
+type cacheEntry struct {
+	key          string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	expiresAt    time.Time
+}
+
+func (entry *cacheEntry) size() int64 {
+	return int64(len(entry.body) + len(entry.key))
+}
+
+func (entry *cacheEntry) fresh() bool {
+	return time.Now().Before(entry.expiresAt)
+}
+
+func (entry *cacheEntry) canRevalidate() bool {
+	return entry.etag != "" || entry.lastModified != ""
+}
+
+// ResponseCache keeps the responses of URL queries of a datasource in memory, up to a total size.
+// The least recently used responses are evicted first. It is safe for concurrent use.
+type ResponseCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+// NewResponseCache returns the response cache configured by the settings, or nil when caching is not enabled
+func NewResponseCache(settings models.InfinitySettings) *ResponseCache {
+	if !settings.CacheEnabled {
+		return nil
+	}
+	ttl := defaultCacheTTL
+	if settings.CacheTTLInSeconds > 0 {
+		ttl = time.Duration(settings.CacheTTLInSeconds) * time.Second
+	}
+	maxSizeInMB := int64(defaultCacheMaxSizeInMB)
+	if settings.CacheMaxSizeInMB > 0 {
+		maxSizeInMB = settings.CacheMaxSizeInMB
+	}
+	return &ResponseCache{
+		ttl:      ttl,
+		maxBytes: maxSizeInMB * 1024 * 1024,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *ResponseCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	entry := *element.Value.(*cacheEntry)
+	return &entry
+}
+
+// store keeps a response unless its Cache-Control forbids it. It reports whether the response was kept.
+func (c *ResponseCache) store(key string, res *http.Response, body []byte) bool {
+	ttl, ok := c.responseTTL(res.Header)
+	if !ok {
+		return false
+	}
+	entry := &cacheEntry{
+		key:          key,
+		statusCode:   res.StatusCode,
+		header:       res.Header.Clone(),
+		body:         body,
+		etag:         res.Header.Get(headerKeyETag),
+		lastModified: res.Header.Get(headerKeyLastModified),
+		expiresAt:    time.Now().Add(ttl),
+	}
+	if entry.size() > c.maxBytes {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size()
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back().Value.(*cacheEntry).key)
+	}
+	return true
+}
+
+// revalidate extends the lifetime of an entry the upstream answered 304 Not Modified for
+func (c *ResponseCache) revalidate(key string, header http.Header) {
+	ttl, ok := c.responseTTL(header)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.remove(key)
+		return
+	}
+	element, found := c.entries[key]
+	if !found {
+		return
+	}
+	entry := element.Value.(*cacheEntry)
+	entry.expiresAt = time.Now().Add(ttl)
+	if etag := header.Get(headerKeyETag); etag != "" {
+		entry.etag = etag
+	}
+}
+
+func (c *ResponseCache) remove(key string) {
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(element)
+	delete(c.entries, key)
+	c.size -= element.Value.(*cacheEntry).size()
+}
+
+// Flush removes all responses from the cache and returns how many there were
+func (c *ResponseCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := len(c.entries)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+	return count
+}
+
+// Len returns the number of responses in the cache
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// responseTTL returns how long a response may be served from the cache without revalidation.
+// no-store responses are not cached, no-cache responses are revalidated every time.
+func (c *ResponseCache) responseTTL(header http.Header) (time.Duration, bool) {
+	ttl := c.ttl
+	for _, directive := range strings.Split(strings.ToLower(header.Get(headerKeyCacheControl)), ",") {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case directive == "no-store":
+			return 0, false
+		case directive == "no-cache":
+			ttl = 0
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds >= 0 {
+				if maxAge := time.Duration(seconds) * time.Second; maxAge < ttl {
+					ttl = maxAge
+				}
+			}
+		}
+	}
+	return ttl, true
+}
+
+// cacheKey identifies a request by its method, URL, body and headers. Secrets are masked, except the identity
+// forwarded from Grafana so that users don't see the responses of each other.
+func cacheKey(settings models.InfinitySettings, query models.Query, requestHeaders map[string]string) (string, error) {
+	req, err := GetRequest(settings, GetQueryBody(query), query, requestHeaders, false)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	io.WriteString(hash, req.Method+"\n"+req.URL.String()+"\n") //nolint
+	// multipart bodies have a random boundary, their fields are hashed instead
+	isFormData := req.Method == http.MethodPost && query.URLOptions.BodyType == "form-data"
+	switch {
+	case isFormData:
+		for _, f := range query.URLOptions.BodyForm {
+			io.WriteString(hash, f.Key+"="+f.Value+"\n") //nolint
+		}
+	case req.Body != nil:
+		if _, err := io.Copy(hash, req.Body); err != nil {
+			return "", err
+		}
+	}
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		if isFormData && strings.EqualFold(key, headerKeyContentType) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		io.WriteString(hash, "\n"+key+": "+strings.Join(req.Header[key], ",")) //nolint
+	}
+	if settings.ForwardOauthIdentity {
+		io.WriteString(hash, "\n"+requestHeaders[headerKeyAuthorization]+"\n"+requestHeaders[headerKeyIdToken]) //nolint
+	}
+	if creds := query.AWSCredentials; creds != nil {
+		io.WriteString(hash, "\n"+creds.AccessKey+"\n"+creds.CrossAccountRoleArn) //nolint
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,123 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getCacheStatus(t *testing.T, client *infinity.Client, query models.Query) string {
+	t.Helper()
+	frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+	require.NoError(t, err)
+	return frame.Meta.Custom.(*infinity.CustomMeta).Cache
+}
+
+func TestResponseCache(t *testing.T) {
+	t.Run("should serve repeated requests from the cache", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			fmt.Fprintf(w, `[{"path":%q}]`, r.URL.Path)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, CacheEnabled: true})
+		require.NoError(t, err)
+
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url"}
+		assert.Equal(t, infinity.CacheStatusMiss, getCacheStatus(t, client, query))
+		assert.Equal(t, infinity.CacheStatusHit, getCacheStatus(t, client, query))
+		res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, []any{map[string]any{"path": "/users"}}, res)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		other := query
+		other.URLOptions.Headers = []models.URLOptionKeyValuePair{{Key: "X-Team", Value: "payments"}}
+		assert.Equal(t, infinity.CacheStatusMiss, getCacheStatus(t, client, other))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+		assert.Equal(t, 2, client.Cache.Flush())
+		assert.Equal(t, infinity.CacheStatusMiss, getCacheStatus(t, client, query))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+	t.Run("should revalidate stale responses with their etag", func(t *testing.T) {
+		var calls, notModified int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprint(w, `{"version":1}`)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, CacheEnabled: true})
+		require.NoError(t, err)
+
+		query := models.Query{URL: "/version", Type: models.QueryTypeJSON, Source: "url"}
+		assert.Equal(t, infinity.CacheStatusMiss, getCacheStatus(t, client, query))
+		assert.Equal(t, infinity.CacheStatusRevalidated, getCacheStatus(t, client, query))
+		res, statusCode, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, map[string]any{"version": 1.0}, res)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+	})
+	t.Run("should not keep no-store responses", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Cache-Control", "private, no-store")
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, CacheEnabled: true})
+		require.NoError(t, err)
+
+		query := models.Query{URL: "/secret", Type: models.QueryTypeJSON, Source: "url"}
+		assert.Equal(t, infinity.CacheStatusBypass, getCacheStatus(t, client, query))
+		assert.Equal(t, infinity.CacheStatusBypass, getCacheStatus(t, client, query))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, 0, client.Cache.Len())
+	})
+	t.Run("should evict the least recently used responses beyond the size limit", func(t *testing.T) {
+		body := strings.Repeat("x", 400*1024)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, CacheEnabled: true, CacheMaxSizeInMB: 1})
+		require.NoError(t, err)
+
+		for _, path := range []string{"/a", "/b", "/a", "/c"} {
+			getCacheStatus(t, client, models.Query{URL: path, Type: models.QueryTypeCSV, Source: "url"})
+		}
+		assert.Equal(t, 2, client.Cache.Len())
+		assert.Equal(t, infinity.CacheStatusHit, getCacheStatus(t, client, models.Query{URL: "/a", Type: models.QueryTypeCSV, Source: "url"}))
+		assert.Equal(t, infinity.CacheStatusMiss, getCacheStatus(t, client, models.Query{URL: "/b", Type: models.QueryTypeCSV, Source: "url"}))
+	})
+	t.Run("should not cache when disabled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		assert.Nil(t, client.Cache)
+		assert.Equal(t, "", getCacheStatus(t, client, models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url"}))
+	})
+}
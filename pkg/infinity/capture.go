@@ -0,0 +1,178 @@
+package infinity
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// captureBodyLimit is how much of the request and response bodies is kept in a capture
+const captureBodyLimit = 4 * 1024
+
+// RequestCapture is the redacted record of the requests sent for a query, including the authentication requests,
+// retries and redirects. It is shown in the query inspector when the debug option of the query is enabled.
+type RequestCapture struct {
+	Exchanges     []CapturedExchange `json:"exchanges"`
+	RedirectChain []string           `json:"redirectChain,omitempty"`
+}
+
+type CapturedExchange struct {
+	Method        string            `json:"method"`
+	URL           string            `json:"url"`
+	Headers       http.Header       `json:"headers,omitempty"`
+	Body          string            `json:"body,omitempty"`
+	BodyTruncated bool              `json:"bodyTruncated,omitempty"`
+	StartedAt     time.Time         `json:"startedAt"`
+	Duration      time.Duration     `json:"duration"`
+	Response      *CapturedResponse `json:"response,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+type CapturedResponse struct {
+	StatusCode    int         `json:"statusCode"`
+	Headers       http.Header `json:"headers,omitempty"`
+	Body          string      `json:"body,omitempty"`
+	BodyTruncated bool        `json:"bodyTruncated,omitempty"`
+	Location      string      `json:"location,omitempty"`
+}
+
+// captureRecorder collects the exchanges of a query. Secrets are only redacted when the capture is read.
+type captureRecorder struct {
+	mu        sync.Mutex
+	exchanges []*CapturedExchange
+	// secrets resolved for the requests, such as the vault secrets
+	secrets []string
+}
+
+type captureRecorderKey struct{}
+
+func newCaptureRecorder() *captureRecorder {
+	return &captureRecorder{}
+}
+
+func withCaptureRecorder(ctx context.Context, recorder *captureRecorder) context.Context {
+	return context.WithValue(ctx, captureRecorderKey{}, recorder)
+}
+
+func (recorder *captureRecorder) start(req *http.Request) *CapturedExchange {
+	recorded := &CapturedExchange{
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		Headers:   req.Header.Clone(),
+		StartedAt: time.Now(),
+	}
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			head, _ := io.ReadAll(io.LimitReader(body, captureBodyLimit+1))
+			body.Close()
+			recorded.Body, recorded.BodyTruncated = preview(head)
+		}
+	}
+	recorder.mu.Lock()
+	recorder.exchanges = append(recorder.exchanges, recorded)
+	recorder.mu.Unlock()
+	return recorded
+}
+
+func (recorder *captureRecorder) addSecrets(secrets []string) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.secrets = append(recorder.secrets, secrets...)
+}
+
+// finish records the response. The start of its body is read ahead and handed back to the caller.
+func (recorder *captureRecorder) finish(recorded *CapturedExchange, res *http.Response, err error) {
+	duration := time.Since(recorded.StartedAt)
+	var response *CapturedResponse
+	if res != nil {
+		response = &CapturedResponse{StatusCode: res.StatusCode, Headers: res.Header.Clone()}
+		if location, err := res.Location(); err == nil && res.StatusCode >= http.StatusMultipleChoices && res.StatusCode < http.StatusBadRequest {
+			response.Location = location.String()
+		}
+		if res.Body != nil && res.Body != http.NoBody {
+			head, _ := io.ReadAll(io.LimitReader(res.Body, captureBodyLimit+1))
+			response.Body, response.BodyTruncated = preview(head)
+			res.Body = &previewReadCloser{Reader: io.MultiReader(bytes.NewReader(head), res.Body), Closer: res.Body}
+		}
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorded.Duration = duration
+	recorded.Response = response
+	if err != nil {
+		recorded.Error = err.Error()
+	}
+}
+
+// capture returns the redacted exchanges recorded so far
+func (recorder *captureRecorder) capture(r *redactor) *RequestCapture {
+	if recorder == nil {
+		return nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	// the values of the sensitive headers, like the tokens added by the authentication transports, are masked
+	// wherever they are echoed back
+	for _, recorded := range recorder.exchanges {
+		r.addHeaderSecrets(recorded.Headers)
+	}
+	for _, secret := range recorder.secrets {
+		r.addSecret(secret)
+	}
+	out := &RequestCapture{Exchanges: []CapturedExchange{}}
+	for _, recorded := range recorder.exchanges {
+		exchange := *recorded
+		exchange.URL = r.url(exchange.URL)
+		exchange.Headers = r.header(exchange.Headers)
+		exchange.Body = r.body(exchange.Body)
+		if exchange.Error != "" {
+			exchange.Error = r.text(exchange.Error)
+		}
+		if exchange.Response != nil {
+			response := *exchange.Response
+			response.Headers = r.header(response.Headers)
+			response.Location = r.url(response.Location)
+			response.Body = r.body(response.Body)
+			exchange.Response = &response
+			if response.Location != "" {
+				if len(out.RedirectChain) == 0 {
+					out.RedirectChain = append(out.RedirectChain, exchange.URL)
+				}
+				out.RedirectChain = append(out.RedirectChain, response.Location)
+			}
+		}
+		out.Exchanges = append(out.Exchanges, exchange)
+	}
+	return out
+}
+
+// captureTransport records the requests as they are sent, after the authentication transports added their headers
+type captureTransport struct {
+	next http.RoundTripper
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder, ok := req.Context().Value(captureRecorderKey{}).(*captureRecorder)
+	if !ok || recorder == nil {
+		return t.next.RoundTrip(req)
+	}
+	recorded := recorder.start(req)
+	res, err := t.next.RoundTrip(req)
+	recorder.finish(recorded, res, err)
+	return res, err
+}
+
+func preview(head []byte) (body string, truncated bool) {
+	if len(head) > captureBodyLimit {
+		return string(head[:captureBodyLimit]), true
+	}
+	return string(head), false
+}
+
+type previewReadCloser struct {
+	io.Reader
+	io.Closer
+}
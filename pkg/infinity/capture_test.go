@@ -0,0 +1,110 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getCapture(t *testing.T, client *infinity.Client, query models.Query) *infinity.RequestCapture {
+	t.Helper()
+	frame, _ := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+	require.NotNil(t, frame)
+	return frame.Meta.Custom.(*infinity.CustomMeta).Capture
+}
+
+func TestRequestCapture(t *testing.T) {
+	t.Run("should only capture when the debug option is enabled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		assert.Nil(t, getCapture(t, client, models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url"}))
+	})
+	t.Run("should capture the redirects with the secrets masked", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new?"+r.URL.RawQuery, http.StatusMovedPermanently)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=abcdef")
+			fmt.Fprintf(w, `{"access_token":"issued-token","echo":%q,"name":"value"}`, r.Header.Get("X-Tenant-Secret"))
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  server.URL,
+			AuthenticationMethod: models.AuthenticationMethodApiKey,
+			ApiKeyType:           models.ApiKeyTypeQuery,
+			ApiKeyKey:            "key",
+			ApiKeyValue:          "api-key-value",
+			CustomHeaders:        map[string]string{"X-Tenant-Secret": "tenant-secret"},
+			SecureQueryFields:    map[string]string{"sig": "signature-value"},
+		})
+		require.NoError(t, err)
+		query := models.Query{URL: "/old?page=1", Type: models.QueryTypeJSON, Source: "url", URLOptions: models.URLOptions{Method: http.MethodPost, BodyType: "raw", Body: `{"password":"hunter22","filter":"all"}`, Debug: true}}
+		capture := getCapture(t, client, query)
+		require.NotNil(t, capture)
+		require.Len(t, capture.Exchanges, 2)
+
+		first, second := capture.Exchanges[0], capture.Exchanges[1]
+		assert.Equal(t, server.URL+"/old?key=xxxxxxxx&page=1&sig=xxxxxxxx", first.URL)
+		assert.Equal(t, []string{"xxxxxxxx"}, first.Headers["X-Tenant-Secret"])
+		assert.Equal(t, `{"password":"xxxxxxxx","filter":"all"}`, first.Body)
+		assert.Equal(t, http.StatusMovedPermanently, first.Response.StatusCode)
+		assert.Equal(t, server.URL+"/new?key=xxxxxxxx&page=1&sig=xxxxxxxx", first.Response.Location)
+		assert.Equal(t, []string{first.URL, first.Response.Location}, capture.RedirectChain)
+
+		assert.Equal(t, http.StatusOK, second.Response.StatusCode)
+		assert.Equal(t, []string{"xxxxxxxx"}, second.Response.Headers["Set-Cookie"])
+		assert.Equal(t, `{"access_token":"xxxxxxxx","echo":"xxxxxxxx","name":"value"}`, second.Response.Body)
+		assert.NotContains(t, fmt.Sprintf("%+v", capture), "api-key-value")
+		assert.NotContains(t, fmt.Sprintf("%+v", capture), "signature-value")
+	})
+	t.Run("should capture the authentication requests", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"capture"}},
+		})
+		require.NoError(t, err)
+		capture := getCapture(t, client, models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url", URLOptions: models.URLOptions{Debug: true}})
+		require.NotNil(t, capture)
+		require.Len(t, capture.Exchanges, 2)
+		token, request := capture.Exchanges[0], capture.Exchanges[1]
+		assert.Equal(t, oauth.URL, token.URL)
+		assert.Equal(t, []string{"Basic xxxxxxxx"}, token.Headers["Authorization"])
+		assert.Contains(t, token.Response.Body, `"access_token":"xxxxxxxx"`)
+		assert.Contains(t, token.Response.Body, `"refresh_token":"xxxxxxxx"`)
+		assert.Equal(t, []string{"Bearer xxxxxxxx"}, request.Headers["Authorization"])
+		assert.Equal(t, `{"authorization":"Bearer xxxxxxxx","idToken":""}`, request.Response.Body)
+	})
+	t.Run("should capture failed responses and truncate large bodies", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, strings.Repeat("a", 5000))
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		capture := getCapture(t, client, models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url", URLOptions: models.URLOptions{Debug: true}})
+		require.NotNil(t, capture)
+		require.Len(t, capture.Exchanges, 1)
+		response := capture.Exchanges[0].Response
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		assert.Len(t, response.Body, 4096)
+		assert.True(t, response.BodyTruncated)
+	})
+}
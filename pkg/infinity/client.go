@@ -20,9 +20,20 @@ import (
 )
 
 type Client struct {
-	Settings   models.InfinitySettings
-	HttpClient *http.Client
-	IsMock     bool
+	Settings        models.InfinitySettings
+	HttpClient      *http.Client
+	ProxyHttpClient *http.Client
+	IsMock          bool
+	Cache           *ResponseCache
+}
+
+// responseInfo describes how the response of a URL query was obtained
+type responseInfo struct {
+	cacheStatus string
+	header      http.Header
+	pages       int
+	truncated   bool
+	attempts    int
 }
 
 func GetTLSConfigFromSettings(settings models.InfinitySettings) (*tls.Config, error) {
@@ -56,16 +67,35 @@ func getBaseHTTPClient(settings models.InfinitySettings) *http.Client {
 	if err != nil {
 		return nil
 	}
+	proxy, err := GetProxyFunc(settings)
+	if err != nil {
+		return nil
+	}
 	transport := &http.Transport{
-		Proxy:           http.ProxyFromEnvironment,
+		Proxy:           proxy,
+		DialContext:     getDialContext(settings),
 		TLSClientConfig: tlsConfig,
 	}
 	return &http.Client{
-		Transport: transport,
-		Timeout:   time.Second * time.Duration(settings.TimeoutInSeconds),
+		Transport:     &captureTransport{next: transport},
+		Timeout:       time.Second * time.Duration(settings.TimeoutInSeconds),
+		CheckRedirect: checkRedirect(settings),
 	}
 }
 
+// NewHTTPClient returns a client applying the TLS, proxy and host restrictions of the settings, without their
+// authentication. Used for the requests of the plugin which aren't datasource queries.
+func NewHTTPClient(settings models.InfinitySettings) (*http.Client, error) {
+	if err := validateHostPatterns(settings); err != nil {
+		return nil, err
+	}
+	httpClient := getBaseHTTPClient(settings)
+	if httpClient == nil {
+		return nil, errors.New("invalid http client")
+	}
+	return httpClient, nil
+}
+
 func NewClient(settings models.InfinitySettings) (client *Client, err error) {
 	if settings.AuthenticationMethod == "" {
 		settings.AuthenticationMethod = models.AuthenticationMethodNone
@@ -76,6 +106,13 @@ func NewClient(settings models.InfinitySettings) (client *Client, err error) {
 			settings.AuthenticationMethod = models.AuthenticationMethodForwardOauth
 		}
 	}
+	if err := validateHostPatterns(settings); err != nil {
+		return nil, err
+	}
+	proxyHttpClient, err := getProxyHTTPClient(settings)
+	if err != nil {
+		return nil, err
+	}
 	httpClient := getBaseHTTPClient(settings)
 	if httpClient == nil {
 		return nil, errors.New("invalid http client")
@@ -83,10 +120,16 @@ func NewClient(settings models.InfinitySettings) (client *Client, err error) {
 	httpClient = ApplyDigestAuth(httpClient, settings)
 	httpClient = ApplyOAuthClientCredentials(httpClient, settings)
 	httpClient = ApplyOAuthJWT(httpClient, settings)
+	httpClient = ApplyOAuthPassword(httpClient, settings)
+	httpClient = ApplyOAuthRefreshToken(httpClient, settings)
+	httpClient = ApplyOAuthTokenExchange(httpClient, settings)
 	httpClient = ApplyAWSAuth(httpClient, settings)
+	httpClient.CheckRedirect = checkRedirect(settings)
 	return &Client{
-		Settings:   settings,
-		HttpClient: httpClient,
+		Settings:        settings,
+		HttpClient:      httpClient,
+		ProxyHttpClient: proxyHttpClient,
+		Cache:           NewResponseCache(settings),
 	}, err
 }
 
@@ -99,51 +142,131 @@ func replaceSect(input string, settings models.InfinitySettings, includeSect boo
 			input = strings.ReplaceAll(input, fmt.Sprintf("${__qs.%s}", key), dummyHeader)
 		}
 	}
+	if !includeSect {
+		input = vaultPlaceholder.ReplaceAllString(input, dummyHeader)
+	}
 	return input
 }
 
-func (client *Client) req(ctx context.Context, url string, body io.Reader, settings models.InfinitySettings, query models.Query, requestHeaders map[string]string) (obj any, statusCode int, duration time.Duration, err error) {
+func (client *Client) req(ctx context.Context, url string, body io.Reader, settings models.InfinitySettings, query models.Query, requestHeaders map[string]string) (obj any, statusCode int, duration time.Duration, info responseInfo, err error) {
 	req, _ := GetRequest(settings, body, query, requestHeaders, true)
 	startTime := time.Now()
-	if !CanAllowURL(req.URL.String(), settings.AllowedHosts) {
-		backend.Logger.Error("url is not in the allowed list. make sure to match the base URL with the settings", "url", req.URL.String())
-		return nil, http.StatusUnauthorized, 0, errors.New("requested URL is not allowed. To allow this URL, update the datasource config URL -> Allowed Hosts section")
+	if err := checkURL(req.URL, settings); err != nil {
+		backend.Logger.Error("url is not allowed. make sure to match the base URL with the settings", "url", url, "error", err.Error())
+		return nil, http.StatusUnauthorized, 0, info, err
+	}
+	var key string
+	var cached *cacheEntry
+	if client.Cache != nil {
+		if key, err = cacheKey(settings, query, requestHeaders); err != nil {
+			backend.Logger.Warn("unable to compute the cache key, the response is not cached", "url", url, "error", err.Error())
+			key, err = "", nil
+		}
+		if key != "" {
+			info.cacheStatus = CacheStatusMiss
+			cached = client.Cache.get(key)
+		}
+		if cached != nil && cached.fresh() {
+			info.cacheStatus = CacheStatusHit
+			info.header = cached.header
+			obj, err = parseResponseBody(url, cached.body, cached.header, query)
+			return obj, cached.statusCode, time.Since(startTime), info, err
+		}
+		if cached != nil && cached.canRevalidate() {
+			if cached.etag != "" {
+				req.Header.Set(headerKeyIfNoneMatch, cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set(headerKeyIfModifiedSince, cached.lastModified)
+			}
+		}
+	}
+	if settings.AuthenticationMethod == models.AuthenticationMethodAWS {
+		ctx = withAWSRequestOptions(ctx, query)
+	}
+	policy := getRetryPolicy(settings, query)
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
 	}
-	res, err := client.HttpClient.Do(req)
+	res, attempts, err := client.doWithRetry(ctx, req.WithContext(ctx), policy)
+	err = maskVaultSecretsInError(ctx, err)
+	info.attempts = attempts
 	duration = time.Since(startTime)
 	if res != nil {
 		defer res.Body.Close()
 	}
 	if err != nil && res != nil {
 		backend.Logger.Error("error getting response from server", "url", url, "method", req.Method, "error", err.Error(), "status code", res.StatusCode)
-		return nil, res.StatusCode, duration, fmt.Errorf("error getting response from %s", url)
+		if reason := errors.Unwrap(err); reason != nil {
+			// a redirect was refused
+			return nil, res.StatusCode, duration, info, fmt.Errorf("error getting response from %s. %w", url, reason)
+		}
+		return nil, res.StatusCode, duration, info, fmt.Errorf("error getting response from %s", url)
 	}
 	if err != nil && res == nil {
 		backend.Logger.Error("error getting response from server. no response received", "url", url, "error", err.Error())
-		return nil, http.StatusInternalServerError, duration, fmt.Errorf("error getting response from url %s. no response received. Error: %s", url, err.Error())
+		return nil, http.StatusInternalServerError, duration, info, fmt.Errorf("error getting response from url %s. no response received. Error: %s", url, err.Error())
 	}
 	if err == nil && res == nil {
 		backend.Logger.Error("invalid response from server and also no error", "url", url, "method", req.Method)
-		return nil, http.StatusInternalServerError, duration, fmt.Errorf("invalid response received for the URL %s", url)
+		return nil, http.StatusInternalServerError, duration, info, fmt.Errorf("invalid response received for the URL %s", url)
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		client.Cache.revalidate(key, res.Header)
+		info.cacheStatus = CacheStatusRevalidated
+		info.header = cached.header
+		obj, err = parseResponseBody(url, cached.body, cached.header, query)
+		return obj, cached.statusCode, duration, info, err
 	}
+	info.header = res.Header
 	if res.StatusCode >= http.StatusBadRequest {
-		return nil, res.StatusCode, duration, errors.New(res.Status)
+		return nil, res.StatusCode, duration, info, errors.New(res.Status)
+	}
+	limit := maxResponseSize(settings)
+	if res.ContentLength > limit {
+		return nil, res.StatusCode, duration, info, &ResponseTooLargeError{Limit: limit}
+	}
+	body = newMaxBytesReader(res.Body, limit)
+	if key == "" && CanParseAsJSON(query.Type, res.Header) {
+		// nothing is cached, so the response is decoded as it streams in
+		obj, err = decodeJSON(body, jsonSelection(query))
+		if err != nil {
+			backend.Logger.Error("error un-marshaling JSON response", "url", url, "error", err.Error())
+		}
+		return obj, res.StatusCode, duration, info, err
 	}
-	bodyBytes, err := io.ReadAll(res.Body)
+	bodyBytes, err := io.ReadAll(body)
 	if err != nil {
 		backend.Logger.Error("error reading response body", "url", url, "error", err.Error())
-		return nil, res.StatusCode, duration, err
+		return nil, res.StatusCode, duration, info, err
 	}
 	bodyBytes = removeBOMContent(bodyBytes)
-	if CanParseAsJSON(query.Type, res.Header) {
+	if key != "" && (res.StatusCode >= http.StatusMultipleChoices || !client.Cache.store(key, res, bodyBytes)) {
+		info.cacheStatus = CacheStatusBypass
+	}
+	obj, err = parseResponseBody(url, bodyBytes, res.Header, query)
+	return obj, res.StatusCode, duration, info, err
+}
+
+func parseResponseBody(url string, bodyBytes []byte, responseHeaders http.Header, query models.Query) (any, error) {
+	if CanParseAsJSON(query.Type, responseHeaders) {
+		if paths := jsonSelection(query); paths != nil {
+			out, err := decodeJSON(bytes.NewReader(bodyBytes), paths)
+			if err != nil {
+				backend.Logger.Error("error un-marshaling JSON response", "url", url, "error", err.Error())
+			}
+			return out, err
+		}
 		var out any
 		err := json.Unmarshal(bodyBytes, &out)
 		if err != nil {
 			backend.Logger.Error("error un-marshaling JSON response", "url", url, "error", err.Error())
 		}
-		return out, res.StatusCode, duration, err
+		return out, err
 	}
-	return string(bodyBytes), res.StatusCode, duration, err
+	return string(bodyBytes), nil
 }
 
 // https://stackoverflow.com/questions/31398044/got-error-invalid-character-%C3%AF-looking-for-beginning-of-value-from-json-unmar
@@ -152,12 +275,32 @@ func removeBOMContent(input []byte) []byte {
 }
 
 func (client *Client) GetResults(ctx context.Context, query models.Query, requestHeaders map[string]string) (o any, statusCode int, duration time.Duration, err error) {
+	o, statusCode, duration, _, err = client.getResults(ctx, query, requestHeaders)
+	return o, statusCode, duration, err
+}
+
+func (client *Client) getResults(ctx context.Context, query models.Query, requestHeaders map[string]string) (o any, statusCode int, duration time.Duration, info responseInfo, err error) {
+	if options, ok := paginationOptions(query); ok {
+		return client.getPaginatedResults(ctx, query, requestHeaders, options)
+	}
+	return client.getPage(ctx, query, requestHeaders)
+}
+
+func (client *Client) getPage(ctx context.Context, query models.Query, requestHeaders map[string]string) (o any, statusCode int, duration time.Duration, info responseInfo, err error) {
+	// the URL with its placeholders is the one logged
+	queryURL := query.URL
+	query, secrets, err := client.resolveVaultPlaceholders(ctx, query)
+	if err != nil {
+		backend.Logger.Error("error resolving the vault placeholders", "url", queryURL, "error", err.Error())
+		return nil, http.StatusInternalServerError, 0, info, err
+	}
+	ctx = withVaultSecrets(ctx, secrets)
 	switch strings.ToUpper(query.URLOptions.Method) {
 	case http.MethodPost:
 		body := GetQueryBody(query)
-		return client.req(ctx, query.URL, body, client.Settings, query, requestHeaders)
+		return client.req(ctx, queryURL, body, client.Settings, query, requestHeaders)
 	default:
-		return client.req(ctx, query.URL, nil, client.Settings, query, requestHeaders)
+		return client.req(ctx, queryURL, nil, client.Settings, query, requestHeaders)
 	}
 }
 
@@ -174,17 +317,12 @@ func CanParseAsJSON(queryType models.QueryType, responseHeaders http.Header) boo
 	return false
 }
 
+// CanAllowURL checks the URL against the allowed hosts. Any URL is allowed when no hosts are configured.
 func CanAllowURL(url string, allowedHosts []string) bool {
-	allow := false
 	if len(allowedHosts) == 0 {
 		return true
 	}
-	for _, host := range allowedHosts {
-		if strings.HasPrefix(url, host) {
-			return true
-		}
-	}
-	return allow
+	return matchHostPatterns(url, allowedHosts)
 }
 
 func GetQueryBody(query models.Query) io.Reader {
@@ -222,3 +360,14 @@ func GetQueryBody(query models.Query) io.Reader {
 	}
 	return body
 }
+
+// detachedContext keeps the values of its parent, such as the capture recorder, but not its deadline and cancellation.
+// It is meant for the calls shared by concurrent requests, which shouldn't end with the request that started them.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (ctx detachedContext) Done() <-chan struct{}       { return nil }
+func (ctx detachedContext) Err() error                  { return nil }
+func (ctx detachedContext) Value(key any) any           { return ctx.parent.Value(key) }
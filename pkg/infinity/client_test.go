@@ -101,9 +101,15 @@ func TestCanAllowURL(t *testing.T) {
 			want:         false,
 		},
 		{
-			name:         "should match only case sensitive URL",
+			name:         "should match the host case insensitively",
 			url:          "https://FOO.com",
 			allowedHosts: []string{"https://foo.com"},
+			want:         true,
+		},
+		{
+			name:         "should match the path case sensitively",
+			url:          "https://foo.com/Users",
+			allowedHosts: []string{"https://foo.com/users"},
 			want:         false,
 		},
 		{
@@ -0,0 +1,232 @@
+package infinity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+var errBlockedAddress = errors.New("requested URL resolves to a private, link-local or metadata address, which is blocked by the datasource settings")
+
+// hostPattern is an entry of the allowed or denied hosts. Entries look like scheme://host:port/path where all but the
+// host are optional. A host of *.example.com matches the subdomains of example.com, a port of * matches any port and
+// a missing port only matches the default port of the scheme.
+type hostPattern struct {
+	scheme   string
+	host     string
+	wildcard bool
+	port     string
+	path     string
+}
+
+func parseHostPattern(pattern string) (hostPattern, error) {
+	raw := strings.TrimSpace(pattern)
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	// the port wildcard isn't a valid url port
+	raw = strings.Replace(raw, ":*", ":0", 1)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return hostPattern{}, fmt.Errorf("%v: %w", "invalid host pattern "+pattern, err)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return hostPattern{}, fmt.Errorf("invalid host pattern %s. only scheme, host, port and path are allowed", pattern)
+	}
+	hp := hostPattern{scheme: u.Scheme, host: strings.ToLower(u.Hostname()), port: u.Port(), path: strings.TrimSuffix(u.Path, "/")}
+	if strings.HasPrefix(hp.host, "*.") {
+		hp.wildcard = true
+		hp.host = hp.host[1:]
+	}
+	if hp.host == "" || strings.Contains(hp.host, "*") || (hp.wildcard && len(hp.host) < 2) {
+		return hostPattern{}, fmt.Errorf("invalid host pattern %s. missing or invalid host", pattern)
+	}
+	if hp.port == "0" {
+		hp.port = "*"
+	}
+	return hp, nil
+}
+
+func (hp hostPattern) match(u *url.URL) bool {
+	if hp.scheme != "" && hp.scheme != u.Scheme {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if hp.wildcard && !strings.HasSuffix(host, hp.host) {
+		return false
+	}
+	if !hp.wildcard && host != hp.host {
+		return false
+	}
+	if hp.port != "*" {
+		port, expected := u.Port(), hp.port
+		if port == "" {
+			port = defaultPort(u.Scheme)
+		}
+		if expected == "" {
+			expected = defaultPort(u.Scheme)
+			if hp.scheme != "" {
+				expected = defaultPort(hp.scheme)
+			}
+		}
+		if port != expected {
+			return false
+		}
+	}
+	if hp.path == "" {
+		return true
+	}
+	p := path.Clean("/" + u.Path)
+	return p == hp.path || strings.HasPrefix(p, hp.path+"/")
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+func matchHostPatterns(rawURL string, patterns []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		if hp, err := parseHostPattern(pattern); err == nil && hp.match(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateHostPatterns(settings models.InfinitySettings) error {
+	for _, pattern := range append(append([]string{}, settings.AllowedHosts...), settings.DeniedHosts...) {
+		if _, err := parseHostPattern(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsDeniedURL checks the URL against the denied hosts. Denied hosts take precedence over the allowed hosts.
+func IsDeniedURL(url string, deniedHosts []string) bool {
+	return matchHostPatterns(url, deniedHosts)
+}
+
+// checkURL checks the URL of a request, or of a redirect, against the host settings of the datasource.
+// IP addresses are checked against the blocked addresses here, host names once they are resolved, when dialing.
+func checkURL(u *url.URL, settings models.InfinitySettings) error {
+	if !CanAllowURL(u.String(), settings.AllowedHosts) || IsDeniedURL(u.String(), settings.DeniedHosts) {
+		return errors.New("requested URL is not allowed. To allow this URL, update the datasource config URL -> Allowed Hosts section")
+	}
+	if settings.BlockPrivateNetworks {
+		if ip := net.ParseIP(u.Hostname()); ip != nil && isBlockedIP(ip) {
+			return errBlockedAddress
+		}
+	}
+	return nil
+}
+
+// CheckURL checks a URL the way the URL of a query is checked before it is sent
+func CheckURL(rawURL string, settings models.InfinitySettings) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%v: %w", "invalid url", err)
+	}
+	return checkURL(u, settings)
+}
+
+func checkRedirect(settings models.InfinitySettings) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkURL(req.URL, settings)
+	}
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isBlockedIP reports loopback, private, shared, link-local and unspecified addresses. The metadata services of the
+// cloud providers live in these ranges.
+func isBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+// proxyAddress returns the host:port dialed for a proxy URL, or an empty string when the URL is invalid. Like the
+// proxy environment variables, a URL without a scheme is an http proxy.
+func proxyAddress(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil || proxyURL.Hostname() == "" {
+		return ""
+	}
+	port := proxyURL.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxyURL.Scheme]
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// getDialContext returns the dialer of the datasource. When private networks are blocked, every connection, including
+// the ones of redirects, is checked once the host is resolved. The proxy of the datasource, or the proxies of the
+// environment variables without one, are exempt.
+//
+// Behind a proxy, the proxy resolves the host names of the requests and the dialer only sees the proxy. The URLs are
+// then only checked by checkURL, which blocks the private IP addresses but not the host names resolving to them.
+func getDialContext(settings models.InfinitySettings) func(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !settings.BlockPrivateNetworks {
+		return dialer.DialContext
+	}
+	proxies := map[string]bool{}
+	if strings.TrimSpace(settings.ProxyURL) != "" {
+		proxies[proxyAddress(settings.ProxyURL)] = true
+	} else {
+		for _, key := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+			proxies[proxyAddress(os.Getenv(key))] = true
+		}
+	}
+	delete(proxies, "")
+	guarded := &net.Dialer{
+		Timeout:   dialer.Timeout,
+		KeepAlive: dialer.KeepAlive,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isBlockedIP(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if proxies[address] {
+			return dialer.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+}
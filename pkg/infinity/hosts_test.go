@@ -0,0 +1,191 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		allowed []string
+		denied  []string
+	}{
+		{
+			pattern: "https://api.example.com",
+			allowed: []string{"https://api.example.com", "https://api.example.com/users?id=1", "https://api.example.com:443/"},
+			denied:  []string{"https://api.example.com.evil.io", "https://api.example.com@evil.io", "http://api.example.com", "https://api.example.com:8443", "https://sub.api.example.com"},
+		},
+		{
+			pattern: "https://*.example.com",
+			allowed: []string{"https://api.example.com", "https://a.b.example.com/users"},
+			denied:  []string{"https://example.com", "https://api.example.com.evil.io", "https://evilexample.com"},
+		},
+		{
+			pattern: "api.example.com:*",
+			allowed: []string{"http://api.example.com", "https://api.example.com:8443"},
+			denied:  []string{"https://www.example.com"},
+		},
+		{
+			pattern: "api.example.com",
+			allowed: []string{"http://api.example.com", "https://api.example.com"},
+			denied:  []string{"http://api.example.com:8080"},
+		},
+		{
+			pattern: "https://API.Example.com",
+			allowed: []string{"https://api.example.com", "https://Api.EXAMPLE.com/users"},
+			denied:  []string{"https://www.example.com"},
+		},
+		{
+			pattern: "https://*.Example.com",
+			allowed: []string{"https://API.example.COM"},
+			denied:  []string{"https://example.com"},
+		},
+		{
+			pattern: "https://api.example.com/v1/",
+			allowed: []string{"https://api.example.com/v1", "https://api.example.com/v1/users"},
+			denied:  []string{"https://api.example.com/v10", "https://api.example.com/v1/../admin", "https://api.example.com/v1/%2e%2e/admin", "https://api.example.com/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			for _, u := range tt.allowed {
+				assert.True(t, infinity.CanAllowURL(u, []string{tt.pattern}), u)
+				assert.True(t, infinity.IsDeniedURL(u, []string{tt.pattern}), u)
+			}
+			for _, u := range tt.denied {
+				assert.False(t, infinity.CanAllowURL(u, []string{tt.pattern}), u)
+				assert.False(t, infinity.IsDeniedURL(u, []string{tt.pattern}), u)
+			}
+		})
+	}
+	t.Run("should fail on invalid patterns", func(t *testing.T) {
+		for _, pattern := range []string{"https://", "https://api.*.com", "https://user@api.example.com", "https://api.example.com?key=value", "*."} {
+			_, err := infinity.NewClient(models.InfinitySettings{URL: "https://api.example.com", AllowedHosts: []string{pattern}})
+			assert.Error(t, err, pattern)
+			_, err = infinity.NewClient(models.InfinitySettings{URL: "https://api.example.com", DeniedHosts: []string{pattern}})
+			assert.Error(t, err, pattern)
+		}
+	})
+}
+
+func TestHostRestrictions(t *testing.T) {
+	query := models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url"}
+	t.Run("denied hosts should take precedence over allowed hosts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, DeniedHosts: []string{server.URL + "/admin"}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/admin/users", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, statusCode)
+	})
+	t.Run("should check redirects against the allowed and denied hosts", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer target.Close()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, AllowedHosts: []string{server.URL}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requested URL is not allowed")
+
+		client, err = infinity.NewClient(models.InfinitySettings{URL: server.URL, DeniedHosts: []string{target.URL}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requested URL is not allowed")
+	})
+	t.Run("should block private networks once resolved", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		for _, u := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "http://169.254.169.254/latest/meta-data", "http://[::1]/", "http://10.0.0.1/", "http://100.100.100.200/"} {
+			client, err := infinity.NewClient(models.InfinitySettings{URL: u, BlockPrivateNetworks: true, TimeoutInSeconds: 5})
+			require.NoError(t, err)
+			_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+			require.Error(t, err, u)
+			assert.Contains(t, err.Error(), "blocked by the datasource settings", u)
+		}
+	})
+	t.Run("should block redirects to private networks", func(t *testing.T) {
+		proxy, urls := httpProxy(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		})
+		// the proxy itself is on a private network, but is exempt
+		client, err := infinity.NewClient(models.InfinitySettings{URL: "http://www.example.com", BlockPrivateNetworks: true, ProxyURL: proxy.URL, ProxyUserName: "proxyuser", ProxyPassword: "proxypass"})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/redirect", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "blocked by the datasource settings")
+		assert.Equal(t, []string{"http://www.example.com/", "http://www.example.com/redirect"}, urls())
+
+		// host names are resolved by the proxy, the private IP addresses are blocked before reaching it
+		client, err = infinity.NewClient(models.InfinitySettings{URL: "http://10.0.0.1", BlockPrivateNetworks: true, ProxyURL: proxy.URL, ProxyUserName: "proxyuser", ProxyPassword: "proxypass"})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "blocked by the datasource settings")
+		assert.Len(t, urls(), 2)
+	})
+	t.Run("should exempt the proxy of the environment variables", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		u := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+		t.Setenv("HTTP_PROXY", strings.TrimPrefix(u, "http://"))
+		client, err := infinity.NewClient(models.InfinitySettings{URL: u, BlockPrivateNetworks: true, TimeoutInSeconds: 5})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+	})
+	t.Run("the http client without authentication should apply the restrictions", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		settings := models.InfinitySettings{BasicAuthEnabled: true, UserName: "user", Password: "secret", TimeoutInSeconds: 5}
+		httpClient, err := infinity.NewHTTPClient(settings)
+		require.NoError(t, err)
+		res, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		settings.BlockPrivateNetworks = true
+		httpClient, err = infinity.NewHTTPClient(settings)
+		require.NoError(t, err)
+		_, err = httpClient.Get(server.URL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "blocked by the datasource settings")
+
+		_, err = infinity.NewHTTPClient(models.InfinitySettings{AllowedHosts: []string{"http://[invalid"}})
+		require.Error(t, err)
+	})
+}
@@ -9,11 +9,15 @@ import (
 )
 
 type CustomMeta struct {
-	Query                  models.Query  `json:"query"`
-	Data                   any           `json:"data"`
-	ResponseCodeFromServer int           `json:"responseCodeFromServer"`
-	Duration               time.Duration `json:"duration"`
-	Error                  string        `json:"error"`
+	Query                  models.Query    `json:"query"`
+	Data                   any             `json:"data"`
+	ResponseCodeFromServer int             `json:"responseCodeFromServer"`
+	Duration               time.Duration   `json:"duration"`
+	Error                  string          `json:"error"`
+	Cache                  string          `json:"cache,omitempty"`
+	Pages                  int             `json:"pages,omitempty"`
+	Attempts               int             `json:"attempts,omitempty"`
+	Capture                *RequestCapture `json:"capture,omitempty"`
 }
 
 func GetDummyFrame(query models.Query) *data.Frame {
@@ -6,7 +6,6 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/grafana/grafana-aws-sdk/pkg/sigv4"
 	dac "github.com/xinsnake/go-http-digest-auth-client"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
@@ -21,21 +20,17 @@ func ApplyOAuthClientCredentials(httpClient *http.Client, settings models.Infini
 			ClientID:       settings.OAuth2Settings.ClientID,
 			ClientSecret:   settings.OAuth2Settings.ClientSecret,
 			TokenURL:       settings.OAuth2Settings.TokenURL,
-			Scopes:         []string{},
+			Scopes:         oauthScopes(settings),
 			EndpointParams: url.Values{},
 		}
-		for _, scope := range settings.OAuth2Settings.Scopes {
-			if scope != "" {
-				oauthConfig.Scopes = append(oauthConfig.Scopes, scope)
-			}
-		}
 		for k, v := range settings.OAuth2Settings.EndpointParams {
 			if k != "" && v != "" {
 				oauthConfig.EndpointParams.Set(k, v)
 			}
 		}
-		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
-		httpClient = oauthConfig.Client(ctx)
+		httpClient = newOAuthClient(httpClient, settings, func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error) {
+			return oauthConfig.Token(ctx)
+		})
 	}
 	return httpClient
 }
@@ -47,52 +42,65 @@ func ApplyOAuthJWT(httpClient *http.Client, settings models.InfinitySettings) *h
 			PrivateKey:   []byte(strings.ReplaceAll(settings.OAuth2Settings.PrivateKey, "\\n", "\n")),
 			PrivateKeyID: settings.OAuth2Settings.PrivateKeyID,
 			Subject:      settings.OAuth2Settings.Subject,
-			Scopes:       []string{},
+			Scopes:       oauthScopes(settings),
 		}
-		for _, scope := range settings.OAuth2Settings.Scopes {
-			if scope != "" {
-				jwtConfig.Scopes = append(jwtConfig.Scopes, scope)
+		httpClient = newOAuthClient(httpClient, settings, func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error) {
+			return jwtConfig.TokenSource(ctx).Token()
+		})
+	}
+	return httpClient
+}
+func ApplyOAuthPassword(httpClient *http.Client, settings models.InfinitySettings) *http.Client {
+	if settings.AuthenticationMethod == models.AuthenticationMethodOAuth && settings.OAuth2Settings.OAuth2Type == models.AuthOAuthPassword {
+		httpClient = newOAuthClient(httpClient, settings, func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error) {
+			return refreshOrFetch(ctx, settings, current, func() (*oauth2.Token, error) {
+				return oauthConfig(settings).PasswordCredentialsToken(ctx, settings.OAuth2Settings.Username, settings.OAuth2Settings.Password)
+			})
+		})
+	}
+	return httpClient
+}
+func ApplyOAuthRefreshToken(httpClient *http.Client, settings models.InfinitySettings) *http.Client {
+	if settings.AuthenticationMethod == models.AuthenticationMethodOAuth && settings.OAuth2Settings.OAuth2Type == models.AuthOAuthRefreshToken {
+		httpClient = newOAuthClient(httpClient, settings, func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error) {
+			refreshToken := settings.OAuth2Settings.RefreshToken
+			// a rotated refresh token replaces the configured one
+			if current != nil && current.RefreshToken != "" {
+				refreshToken = current.RefreshToken
 			}
-		}
-		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
-		httpClient = jwtConfig.Client(ctx)
+			return oauthConfig(settings).TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+		})
+	}
+	return httpClient
+}
+func ApplyOAuthTokenExchange(httpClient *http.Client, settings models.InfinitySettings) *http.Client {
+	if settings.AuthenticationMethod == models.AuthenticationMethodOAuth && settings.OAuth2Settings.OAuth2Type == models.AuthOAuthTokenExchange {
+		httpClient = newOAuthClient(httpClient, settings, func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error) {
+			return exchangeToken(ctx, settings, subjectToken)
+		})
 	}
 	return httpClient
 }
 func ApplyDigestAuth(httpClient *http.Client, settings models.InfinitySettings) *http.Client {
 	if settings.AuthenticationMethod == models.AuthenticationMethodDigestAuth {
 		a := dac.NewTransport(settings.UserName, settings.Password)
+		a.HTTPClient = &http.Client{Transport: httpClient.Transport, Timeout: httpClient.Timeout, CheckRedirect: httpClient.CheckRedirect}
 		httpClient.Transport = &a
 	}
 	return httpClient
 }
 func ApplyAWSAuth(httpClient *http.Client, settings models.InfinitySettings) *http.Client {
 	if settings.AuthenticationMethod == models.AuthenticationMethodAWS {
-		tempHttpClient := getBaseHTTPClient(settings)
-		authType := settings.AWSSettings.AuthType
-		if authType == "" {
-			authType = models.AWSAuthTypeKeys
-		}
-		region := settings.AWSSettings.Region
-		if region == "" {
-			region = "us-east-2"
-		}
-		service := settings.AWSSettings.Service
-		if service == "" {
-			service = "monitoring"
+		next := httpClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
 		}
-		conf := &sigv4.Config{
-			AuthType:  string(authType),
-			Region:    region,
-			Service:   service,
-			AccessKey: settings.AWSAccessKey,
-			SecretKey: settings.AWSSecretKey,
+		stsClient := getBaseHTTPClient(settings)
+		if capture, ok := stsClient.Transport.(*captureTransport); ok {
+			// the aws sdk needs the plain transport to load custom CA bundles
+			stsClient.Transport = capture.next
 		}
-		rt, _ := sigv4.New(conf, sigv4.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
-			req.Header.Add("Accept", "application/json")
-			return tempHttpClient.Do(req)
-		}))
-		httpClient.Transport = rt
+		httpClient.Transport = &awsSigningTransport{next: next, settings: settings, stsClient: stsClient}
 	}
 	return httpClient
 }
@@ -0,0 +1,289 @@
+package infinity_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oauthServer stands in for an authorization server. It issues numbered tokens, valid for expiresIn seconds,
+// and records the grants it received
+type oauthServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	grants    []string
+	issued    int
+	expiresIn int
+}
+
+func newOAuthServer(t *testing.T, expiresIn int) *oauthServer {
+	t.Helper()
+	s := &oauthServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID == "" {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		grant := r.PostForm.Get("grant_type")
+		switch grant {
+		case "password":
+			if r.PostForm.Get("username") != "user" || r.PostForm.Get("password") != "pass" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+		case "refresh_token":
+			grant += ":" + r.PostForm.Get("refresh_token")
+		case "urn:ietf:params:oauth:grant-type:token-exchange":
+			if r.PostForm.Get("subject_token_type") != "urn:ietf:params:oauth:token-type:access_token" || r.PostForm.Get("audience") != "downstream" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_request"}`)
+				return
+			}
+			grant = "token-exchange:" + r.PostForm.Get("subject_token")
+		}
+		s.grants = append(s.grants, grant)
+		s.issued++
+		json.NewEncoder(w).Encode(map[string]any{ //nolint
+			"access_token":  fmt.Sprintf("token-%d", s.issued),
+			"token_type":    "Bearer",
+			"expires_in":    s.expiresIn,
+			"refresh_token": fmt.Sprintf("refresh-%d", s.issued),
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *oauthServer) receivedGrants() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.grants...)
+}
+
+// bearerEcho returns the authorization header it received
+func bearerEcho(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"authorization":%q,"idToken":%q}`, r.Header.Get("Authorization"), r.Header.Get("X-ID-Token"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getAuthorization(t *testing.T, client *infinity.Client, requestHeaders map[string]string) string {
+	t.Helper()
+	res, _, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, requestHeaders)
+	require.NoError(t, err)
+	return res.(map[string]any)["authorization"].(string)
+}
+
+func TestOAuthFlows(t *testing.T) {
+	t.Run("client credentials tokens should be shared across instances", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := bearerEcho(t)
+		settings := models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret"},
+		}
+		for i := 0; i < 2; i++ {
+			client, err := infinity.NewClient(settings)
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		}
+		assert.Equal(t, []string{"client_credentials"}, oauth.receivedGrants())
+
+		settings.OAuth2Settings.Scopes = []string{"read"}
+		client, err := infinity.NewClient(settings)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, map[string]string{}))
+	})
+	t.Run("tokens should be refreshed ahead of their expiry", func(t *testing.T) {
+		oauth := newOAuthServer(t, 2)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", EarlyRefreshInSeconds: 1},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		time.Sleep(1100 * time.Millisecond)
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, map[string]string{}))
+	})
+	t.Run("password grant should refresh its tokens with the refresh token", func(t *testing.T) {
+		oauth := newOAuthServer(t, 1)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthPassword, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", Username: "user", Password: "pass"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		time.Sleep(600 * time.Millisecond)
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, map[string]string{}))
+		assert.Equal(t, []string{"password", "refresh_token:refresh-1"}, oauth.receivedGrants())
+	})
+	t.Run("refresh token grant should use the rotated refresh tokens", func(t *testing.T) {
+		oauth := newOAuthServer(t, 1)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthRefreshToken, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", RefreshToken: "configured"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		time.Sleep(600 * time.Millisecond)
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, map[string]string{}))
+		assert.Equal(t, []string{"refresh_token:configured", "refresh_token:refresh-1"}, oauth.receivedGrants())
+	})
+	t.Run("token exchange should swap the forwarded user token", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			ForwardOauthIdentity: true,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTokenExchange, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", Audience: "downstream"},
+		})
+		require.NoError(t, err)
+		userA := map[string]string{"Authorization": "Bearer user-a", "X-ID-Token": "id-a"}
+		userB := map[string]string{"Authorization": "Bearer user-b"}
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, userA))
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, userB))
+		res, _, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, userA)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"authorization": "Bearer token-1", "idToken": ""}, res)
+		assert.Equal(t, []string{"token-exchange:user-a", "token-exchange:user-b"}, oauth.receivedGrants())
+
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no user token to exchange")
+	})
+	t.Run("rejected tokens should be fetched again", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		}))
+		defer api.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret"},
+		})
+		require.NoError(t, err)
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, statusCode)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.NoError(t, err)
+	})
+	t.Run("rejected tokens should be refreshed with the rotated refresh token", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"authorization":%q}`, r.Header.Get("Authorization"))
+		}))
+		defer api.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthRefreshToken, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", RefreshToken: "rotated"},
+		})
+		require.NoError(t, err)
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, statusCode)
+		assert.Equal(t, "Bearer token-2", getAuthorization(t, client, map[string]string{}))
+		// the configured refresh token was rotated with the first token, it isn't used again
+		assert.Equal(t, []string{"refresh_token:rotated", "refresh_token:refresh-1"}, oauth.receivedGrants())
+	})
+	t.Run("tokens without an expiry should be reused", func(t *testing.T) {
+		oauth := newOAuthServer(t, 0)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"no-expiry"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		assert.Equal(t, "Bearer token-1", getAuthorization(t, client, map[string]string{}))
+		assert.Equal(t, []string{"client_credentials"}, oauth.receivedGrants())
+	})
+	t.Run("a cancelled request shouldn't cancel the token fetch shared with other requests", func(t *testing.T) {
+		release := make(chan struct{})
+		oauth := newOAuthServer(t, 3600)
+		slowOAuth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			oauth.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer slowOAuth.Close()
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: slowOAuth.URL, ClientID: "client", ClientSecret: "secret"},
+		})
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := make(chan error)
+		go func() {
+			_, _, _, err := client.GetResults(ctx, models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+			cancelled <- err
+		}()
+		waiting := make(chan string)
+		go func() {
+			waiting <- getAuthorization(t, client, map[string]string{})
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		assert.Error(t, <-cancelled)
+		close(release)
+		assert.Equal(t, "Bearer token-1", <-waiting)
+		assert.Equal(t, []string{"client_credentials"}, oauth.receivedGrants())
+	})
+	t.Run("should fail when the token can't be fetched", func(t *testing.T) {
+		oauth := newOAuthServer(t, 3600)
+		api := bearerEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  api.URL,
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthPassword, TokenURL: oauth.URL, ClientID: "client", ClientSecret: "secret", Username: "user", Password: "wrong"},
+		})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error getting oauth2 token")
+	})
+}
@@ -0,0 +1,441 @@
+package infinity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const (
+	defaultPaginationLimit    = 100
+	defaultPaginationMaxPages = 10
+	defaultPaginationMaxRows  = 10000
+	// paginationConcurrency is the number of pages fetched at once in the offset and page modes
+	paginationConcurrency = 4
+	headerKeyLink         = "Link"
+)
+
+type pageResult struct {
+	obj        any
+	statusCode int
+	info       responseInfo
+	err        error
+}
+
+// paginationOptions returns the pagination options of the query with their defaults, and whether the query is paginated
+func paginationOptions(query models.Query) (models.URLPagination, bool) {
+	if query.URLOptions.Pagination == nil {
+		return models.URLPagination{}, false
+	}
+	options := *query.URLOptions.Pagination
+	if options.Mode == "" || options.Mode == models.PaginationModeNone {
+		return options, false
+	}
+	if options.Limit <= 0 {
+		options.Limit = defaultPaginationLimit
+	}
+	if options.Mode == models.PaginationModeOffset {
+		if options.OffsetParam == "" {
+			options.OffsetParam = "offset"
+		}
+		if options.LimitParam == "" {
+			options.LimitParam = "limit"
+		}
+	}
+	if options.PageParam == "" {
+		options.PageParam = "page"
+	}
+	if options.PageStart == nil {
+		pageStart := int64(1)
+		options.PageStart = &pageStart
+	}
+	if options.CursorParam == "" {
+		options.CursorParam = "cursor"
+	}
+	if options.MaxPages <= 0 {
+		options.MaxPages = defaultPaginationMaxPages
+	}
+	if options.MaxRows <= 0 {
+		options.MaxRows = defaultPaginationMaxRows
+	}
+	return options, true
+}
+
+// getPaginatedResults fetches the pages of a paginated query and concatenates them into one response, as if the
+// upstream had returned all the rows at once
+func (client *Client) getPaginatedResults(ctx context.Context, query models.Query, requestHeaders map[string]string, options models.URLPagination) (o any, statusCode int, duration time.Duration, info responseInfo, err error) {
+	startTime := time.Now()
+	var pages []pageResult
+	var more bool
+	switch options.Mode {
+	case models.PaginationModeOffset, models.PaginationModePage:
+		pages, more, err = client.getNumberedPages(ctx, query, requestHeaders, options)
+	case models.PaginationModeCursor, models.PaginationModeLink:
+		pages, more, err = client.getLinkedPages(ctx, query, requestHeaders, options)
+	default:
+		return nil, http.StatusBadRequest, 0, info, fmt.Errorf("invalid pagination mode %q", options.Mode)
+	}
+	duration = time.Since(startTime)
+	if len(pages) > 0 {
+		last := pages[len(pages)-1]
+		statusCode = last.statusCode
+		info = last.info
+		info.cacheStatus = combinedCacheStatus(pages)
+		info.pages = len(pages)
+		info.attempts = 0
+		for _, page := range pages {
+			info.attempts += page.info.attempts
+		}
+	}
+	if err != nil {
+		return nil, statusCode, duration, info, err
+	}
+	o, info.truncated, err = concatPages(pages, query, options.MaxRows)
+	// the rows of the pages left behind by the guards are missing too
+	info.truncated = info.truncated || more
+	return o, statusCode, duration, info, err
+}
+
+// getNumberedPages fetches the pages of the offset and page modes, several at a time, until a page is shorter than
+// the previous ones or a guard is hit. more reports that a guard stopped the pagination before the last page.
+func (client *Client) getNumberedPages(ctx context.Context, query models.Query, requestHeaders map[string]string, options models.URLPagination) (pages []pageResult, more bool, err error) {
+	pages = make([]pageResult, 0)
+	pageSize := int64(0)
+	if options.LimitParam != "" {
+		pageSize = options.Limit
+	}
+	rows := int64(0)
+	for int64(len(pages)) < options.MaxPages {
+		batch := make([]pageResult, min64(paginationConcurrency, options.MaxPages-int64(len(pages))))
+		var wg sync.WaitGroup
+		for i := range batch {
+			wg.Add(1)
+			go func(i int, index int64) {
+				defer wg.Done()
+				pageQuery := withParams(query, numberedPageParams(options, index))
+				result := &batch[i]
+				result.obj, result.statusCode, _, result.info, result.err = client.getPage(ctx, pageQuery, requestHeaders)
+			}(i, int64(len(pages)+i))
+		}
+		wg.Wait()
+
+		for _, result := range batch {
+			if result.err != nil {
+				return append(pages, result), false, result.err
+			}
+			count, err := pageRowCount(result.obj, query)
+			if err != nil {
+				return pages, false, err
+			}
+			pages = append(pages, result)
+			rows += count
+			if pageSize == 0 {
+				// without a limit parameter the size of the first page is the page size of the upstream
+				pageSize = count
+			}
+			if count == 0 || count < pageSize {
+				return pages, false, nil
+			}
+			if rows >= options.MaxRows {
+				return pages, true, nil
+			}
+		}
+	}
+	return pages, true, nil
+}
+
+func numberedPageParams(options models.URLPagination, index int64) map[string]string {
+	params := make(map[string]string)
+	if options.LimitParam != "" {
+		params[options.LimitParam] = strconv.FormatInt(options.Limit, 10)
+	}
+	if options.Mode == models.PaginationModeOffset {
+		params[options.OffsetParam] = strconv.FormatInt(index*options.Limit, 10)
+	} else {
+		params[options.PageParam] = strconv.FormatInt(*options.PageStart+index, 10)
+	}
+	return params
+}
+
+// getLinkedPages fetches the pages of the cursor and link modes one after the other, each page telling where the
+// next one is. more reports that a guard stopped the pagination before the last page.
+func (client *Client) getLinkedPages(ctx context.Context, query models.Query, requestHeaders map[string]string, options models.URLPagination) (pages []pageResult, more bool, err error) {
+	pages = make([]pageResult, 0)
+	pageQuery := query
+	rows := int64(0)
+	seen := make(map[string]bool)
+	for {
+		var result pageResult
+		result.obj, result.statusCode, _, result.info, result.err = client.getPage(ctx, pageQuery, requestHeaders)
+		pages = append(pages, result)
+		if result.err != nil {
+			return pages, false, result.err
+		}
+		count, err := pageRowCount(result.obj, query)
+		if err != nil {
+			return pages, false, err
+		}
+		rows += count
+		if count == 0 {
+			return pages, false, nil
+		}
+
+		var next string
+		if options.Mode == models.PaginationModeCursor {
+			if options.CursorSelector == "" {
+				return pages, false, errors.New("invalid pagination. cursor selector is required")
+			}
+			if cursor, ok := jsonPathValue(result.obj, options.CursorSelector); ok && cursor != nil {
+				next = fmt.Sprintf("%v", cursor)
+			}
+		} else {
+			next, err = nextLink(client.Settings, pageQuery, result.info.header)
+			if err != nil {
+				return pages, false, err
+			}
+		}
+		// a repeated cursor or link would loop forever
+		if next == "" || seen[next] {
+			return pages, false, nil
+		}
+		seen[next] = true
+		if rows >= options.MaxRows || int64(len(pages)) >= options.MaxPages {
+			return pages, true, nil
+		}
+
+		if options.Mode == models.PaginationModeCursor {
+			pageQuery = withParams(query, map[string]string{options.CursorParam: next})
+		} else {
+			pageQuery = query
+			pageQuery.URL = next
+			pageQuery.URLOptions.Params = nil
+		}
+	}
+}
+
+// nextLink returns the absolute URL of the rel=next link of a response, as defined by RFC 5988
+func nextLink(settings models.InfinitySettings, query models.Query, header http.Header) (string, error) {
+	for _, value := range header.Values(headerKeyLink) {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") || !hasRel(strings.Trim(val, `"`), "next") {
+					continue
+				}
+				base, err := GetQueryURL(settings, query, false)
+				if err != nil {
+					return "", err
+				}
+				baseURL, err := url.Parse(base)
+				if err != nil {
+					return "", err
+				}
+				nextURL, err := baseURL.Parse(strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">"))
+				if err != nil {
+					return "", fmt.Errorf("invalid next link. %w", err)
+				}
+				return nextURL.String(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func hasRel(rels string, rel string) bool {
+	for _, r := range strings.Fields(rels) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// withParams returns a copy of the query with the given URL parameters, replacing the parameters of the same name
+func withParams(query models.Query, params map[string]string) models.Query {
+	queryParams := make([]models.URLOptionKeyValuePair, 0, len(query.URLOptions.Params)+len(params))
+	for _, param := range query.URLOptions.Params {
+		if _, ok := params[param.Key]; !ok {
+			queryParams = append(queryParams, param)
+		}
+	}
+	for key, value := range params {
+		queryParams = append(queryParams, models.URLOptionKeyValuePair{Key: key, Value: value})
+	}
+	query.URLOptions.Params = queryParams
+	return query
+}
+
+// pageRows returns the rows of a JSON page, either the page itself or the array found at the root selector
+func pageRows(obj any, rootSelector string) ([]any, error) {
+	if rows, ok := obj.([]any); ok {
+		return rows, nil
+	}
+	if rootSelector != "" {
+		if value, ok := jsonPathValue(obj, rootSelector); ok {
+			if rows, ok := value.([]any); ok {
+				return rows, nil
+			}
+		}
+	}
+	return nil, errors.New("unable to find the rows of the page. set the root selector to the array of rows")
+}
+
+// pageLines returns the data lines of a CSV or TSV page, without the header line
+func pageLines(body string, query models.Query) []string {
+	lines := nonEmptyLines(body)
+	if csvHasHeader(query) && len(lines) > 0 {
+		return lines[1:]
+	}
+	return lines
+}
+
+func nonEmptyLines(body string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func csvHasHeader(query models.Query) bool {
+	return query.CSVOptions.Columns == ""
+}
+
+func pageRowCount(obj any, query models.Query) (int64, error) {
+	if body, ok := obj.(string); ok {
+		if query.Type != models.QueryTypeCSV && query.Type != models.QueryTypeTSV {
+			return 0, fmt.Errorf("pagination is not supported for %s responses", query.Type)
+		}
+		return int64(len(pageLines(body, query))), nil
+	}
+	rows, err := pageRows(obj, query.RootSelector)
+	return int64(len(rows)), err
+}
+
+// concatPages joins the rows of the pages and keeps at most maxRows of them. JSON pages keep the shape of the first
+// page so that the root selector still applies.
+func concatPages(pages []pageResult, query models.Query, maxRows int64) (o any, truncated bool, err error) {
+	if len(pages) == 0 {
+		return nil, false, nil
+	}
+	if _, ok := pages[0].obj.(string); ok {
+		lines := make([]string, 0)
+		if first := nonEmptyLines(pages[0].obj.(string)); csvHasHeader(query) && len(first) > 0 {
+			lines = append(lines, first[0])
+		}
+		header := len(lines)
+		for _, page := range pages {
+			body, _ := page.obj.(string)
+			lines = append(lines, pageLines(body, query)...)
+		}
+		if int64(len(lines)-header) > maxRows {
+			lines, truncated = lines[:int64(header)+maxRows], true
+		}
+		return strings.Join(lines, "\n"), truncated, nil
+	}
+
+	rows := make([]any, 0)
+	for _, page := range pages {
+		pageRows, err := pageRows(page.obj, query.RootSelector)
+		if err != nil {
+			return nil, false, err
+		}
+		rows = append(rows, pageRows...)
+	}
+	if int64(len(rows)) > maxRows {
+		rows, truncated = rows[:maxRows], true
+	}
+	if _, ok := pages[0].obj.([]any); ok {
+		return rows, truncated, nil
+	}
+	return setJSONPathValue(pages[0].obj, query.RootSelector, rows), truncated, nil
+}
+
+func combinedCacheStatus(pages []pageResult) string {
+	status := ""
+	for i, page := range pages {
+		if i == 0 {
+			status = page.info.cacheStatus
+			continue
+		}
+		if page.info.cacheStatus != status {
+			return CacheStatusMiss
+		}
+	}
+	return status
+}
+
+func jsonPathSegments(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// jsonPathValue returns the value at a dotted path, such as meta.next_cursor or data.0.id
+func jsonPathValue(obj any, path string) (any, bool) {
+	value := obj
+	for _, segment := range jsonPathSegments(path) {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func setJSONPathValue(obj any, path string, newValue any) any {
+	segments := jsonPathSegments(path)
+	if len(segments) == 0 {
+		return newValue
+	}
+	parent, ok := jsonPathValue(obj, strings.Join(segments[:len(segments)-1], "."))
+	if !ok {
+		return obj
+	}
+	last := segments[len(segments)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = newValue
+	case []any:
+		if index, err := strconv.Atoi(last); err == nil && index >= 0 && index < len(p) {
+			p[index] = newValue
+		}
+	}
+	return obj
+}
+
+func min64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
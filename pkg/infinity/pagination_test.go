@@ -0,0 +1,186 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paginatedUsers serves 25 users, in pages of the requested limit
+func paginatedUsers(t *testing.T, page func(r *http.Request, w http.ResponseWriter, limit int) (from int)) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			limit = 10
+		}
+		from := page(r, w, limit)
+		users := []string{}
+		for i := from; i < from+limit && i < 25; i++ {
+			users = append(users, fmt.Sprintf(`{"id":%d}`, i))
+		}
+		fmt.Fprintf(w, `{"data":{"users":[%s]},"next":%q}`, strings.Join(users, ","), nextCursor(from+limit))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func nextCursor(from int) string {
+	if from >= 25 {
+		return ""
+	}
+	return fmt.Sprintf("c%d", from)
+}
+
+func userIds(t *testing.T, res any) []float64 {
+	t.Helper()
+	users, ok := res.(map[string]any)["data"].(map[string]any)["users"].([]any)
+	require.True(t, ok)
+	ids := []float64{}
+	for _, user := range users {
+		ids = append(ids, user.(map[string]any)["id"].(float64))
+	}
+	return ids
+}
+
+func rangeIds(from, to int) []float64 {
+	ids := []float64{}
+	for i := from; i < to; i++ {
+		ids = append(ids, float64(i))
+	}
+	return ids
+}
+
+func TestPagination(t *testing.T) {
+	t.Run("offset mode should fetch all pages", func(t *testing.T) {
+		server, calls := paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int {
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			return offset
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", RootSelector: "data.users", URLOptions: models.URLOptions{
+			Pagination: &models.URLPagination{Mode: models.PaginationModeOffset, Limit: 10},
+		}}
+		res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, rangeIds(0, 25), userIds(t, res))
+		// pages are fetched in parallel batches
+		assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+	})
+	t.Run("page mode should stop at the first short page", func(t *testing.T) {
+		server, calls := paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int {
+			page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+			return page * limit
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		pageStart := int64(0)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", RootSelector: "data.users", URLOptions: models.URLOptions{
+			Params:     []models.URLOptionKeyValuePair{{Key: "p", Value: "7"}},
+			Pagination: &models.URLPagination{Mode: models.PaginationModePage, PageParam: "p", PageStart: &pageStart, MaxPages: 2},
+		}}
+		res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, rangeIds(0, 20), userIds(t, res))
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, frame.Meta.Notices, 1)
+		assert.Contains(t, frame.Meta.Notices[0].Text, "max pages")
+	})
+	t.Run("should not report the results as incomplete when the last page is reached", func(t *testing.T) {
+		server, _ := paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int {
+			from, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("cursor"), "c"))
+			return from
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", RootSelector: "data.users", URLOptions: models.URLOptions{
+			Pagination: &models.URLPagination{Mode: models.PaginationModeCursor, CursorSelector: "next", MaxPages: 3, MaxRows: 25},
+		}}
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, frame.Meta.Custom.(*infinity.CustomMeta).Pages)
+		assert.Empty(t, frame.Meta.Notices)
+	})
+	t.Run("cursor mode should follow the cursor of the response", func(t *testing.T) {
+		server, _ := paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int {
+			from, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("after"), "c"))
+			return from
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", RootSelector: "data.users", URLOptions: models.URLOptions{
+			Pagination: &models.URLPagination{Mode: models.PaginationModeCursor, CursorParam: "after", CursorSelector: "next"},
+		}}
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		customMeta := frame.Meta.Custom.(*infinity.CustomMeta)
+		assert.Equal(t, 3, customMeta.Pages)
+		assert.Equal(t, rangeIds(0, 25), userIds(t, customMeta.Data))
+	})
+	t.Run("link mode should follow the next link and stop at max rows", func(t *testing.T) {
+		var server *httptest.Server
+		server, _ = paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int {
+			from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+			w.Header().Add("Link", fmt.Sprintf(`<%s/users?from=0>; rel="first", </users?from=%d&limit=%d>; rel="next"`, server.URL, from+limit, limit))
+			return from
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", Parser: "backend", RootSelector: "data.users", URLOptions: models.URLOptions{
+			Params:     []models.URLOptionKeyValuePair{{Key: "limit", Value: "10"}},
+			Pagination: &models.URLPagination{Mode: models.PaginationModeLink, MaxRows: 15},
+		}}
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		require.Equal(t, 15, frame.Rows())
+		assert.Equal(t, 2, frame.Meta.Custom.(*infinity.CustomMeta).Pages)
+		require.Len(t, frame.Meta.Notices, 1)
+	})
+	t.Run("csv pages should be concatenated under a single header", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "1":
+				fmt.Fprint(w, "id,name\n1,a\n2,b\n")
+			case "2":
+				fmt.Fprint(w, "id,name\n3,c\n")
+			default:
+				fmt.Fprint(w, "id,name\n")
+			}
+		}))
+		defer server.Close()
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users.csv", Type: models.QueryTypeCSV, Source: "url", URLOptions: models.URLOptions{
+			Pagination: &models.URLPagination{Mode: models.PaginationModePage},
+		}}
+		res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, "id,name\n1,a\n2,b\n3,c", res)
+	})
+	t.Run("should fail when the rows of a page can't be found", func(t *testing.T) {
+		server, _ := paginatedUsers(t, func(r *http.Request, w http.ResponseWriter, limit int) int { return 0 })
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url", URLOptions: models.URLOptions{
+			Pagination: &models.URLPagination{Mode: models.PaginationModeOffset},
+		}}
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,131 @@
+package infinity
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+// GetProxyFunc returns the proxy selection of the datasource. Requests go through the configured proxy
+// unless their host is in the no-proxy list. Without a proxy URL the proxy environment variables apply.
+func GetProxyFunc(settings models.InfinitySettings) (func(*http.Request) (*url.URL, error), error) {
+	if strings.TrimSpace(settings.ProxyURL) == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := url.Parse(strings.TrimSpace(settings.ProxyURL))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "invalid proxy url", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy url. unsupported scheme %q. use http, https or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url. missing host")
+	}
+	if settings.ProxyUserName != "" {
+		proxyURL.User = url.UserPassword(settings.ProxyUserName, settings.ProxyPassword)
+	}
+	noProxy := newNoProxyList(settings.NoProxy)
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy.match(req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// getProxyHTTPClient returns a client without the TLS and authentication settings of the datasource, which still
+// honours its proxy settings. It is meant for the calls to the AppKube services.
+func getProxyHTTPClient(settings models.InfinitySettings) (*http.Client, error) {
+	proxy, err := GetProxyFunc(settings)
+	if err != nil {
+		return nil, err
+	}
+	timeout := settings.TimeoutInSeconds
+	if timeout <= 0 {
+		timeout = 60
+	}
+	return &http.Client{
+		Transport: &http.Transport{Proxy: proxy},
+		Timeout:   time.Second * time.Duration(timeout),
+	}, nil
+}
+
+type noProxyEntry struct {
+	host     string
+	port     string
+	suffix   bool
+	network  *net.IPNet
+	matchAll bool
+}
+
+type noProxyList []noProxyEntry
+
+// newNoProxyList parses the no-proxy entries. An entry is either "*", an IP address, a CIDR range, a host name or a
+// domain. "example.com" matches the domain and its subdomains while ".example.com" and "*.example.com" only match
+// the subdomains. Any entry but a CIDR range can be restricted to a port with host:port.
+func newNoProxyList(entries []string) noProxyList {
+	list := noProxyList{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list = append(list, noProxyEntry{matchAll: true})
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list = append(list, noProxyEntry{network: network})
+			continue
+		}
+		item := noProxyEntry{host: entry}
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			item.host, item.port = host, port
+		}
+		item.host = strings.Trim(item.host, "[]")
+		if strings.HasPrefix(item.host, "*.") {
+			item.host = item.host[1:]
+		}
+		if strings.HasPrefix(item.host, ".") {
+			item.suffix = true
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+func (list noProxyList) match(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+	for _, entry := range list {
+		switch {
+		case entry.matchAll:
+			return true
+		case entry.network != nil:
+			if ip != nil && entry.network.Contains(ip) {
+				return true
+			}
+		case entry.port != "" && entry.port != port:
+			continue
+		case entry.suffix:
+			if strings.HasSuffix(host, entry.host) {
+				return true
+			}
+		case host == entry.host || (ip == nil && strings.HasSuffix(host, "."+entry.host)):
+			return true
+		}
+	}
+	return false
+}
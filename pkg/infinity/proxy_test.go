@@ -0,0 +1,255 @@
+package infinity_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// httpProxy stands in for an HTTP proxy. It answers the proxied requests itself and records their absolute URLs
+func httpProxy(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	urls := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("proxyuser:proxypass")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		mu.Lock()
+		urls = append(urls, r.URL.String())
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, urls...)
+	}
+}
+
+// socks5Proxy stands in for a SOCKS5 proxy with username/password authentication and returns the addresses it connected to
+func socks5Proxy(t *testing.T) (string, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	var mu sync.Mutex
+	addresses := []string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				address, err := socks5Handshake(conn)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				addresses = append(addresses, address)
+				mu.Unlock()
+				target, err := net.Dial("tcp", address)
+				if err != nil {
+					conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, addresses...)
+	}
+}
+
+func socks5Handshake(conn net.Conn) (string, error) {
+	r := bufio.NewReader(conn)
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(r, greeting); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(r, make([]byte, greeting[1])); err != nil {
+		return "", err
+	}
+	conn.Write([]byte{5, 2})
+	// username/password negotiation
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	username := make([]byte, header[1])
+	if _, err := io.ReadFull(r, username); err != nil {
+		return "", err
+	}
+	passwordLength, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	password := make([]byte, passwordLength)
+	if _, err := io.ReadFull(r, password); err != nil {
+		return "", err
+	}
+	if string(username) != "proxyuser" || string(password) != "proxypass" {
+		conn.Write([]byte{1, 1})
+		return "", fmt.Errorf("invalid credentials")
+	}
+	conn.Write([]byte{1, 0})
+	// connect request
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return "", err
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", request[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+func TestProxy(t *testing.T) {
+	query := models.Query{URL: "/users", Type: models.QueryTypeJSON, Source: "url"}
+	t.Run("should send url queries and oauth token requests through the http proxy", func(t *testing.T) {
+		proxy, urls := httpProxy(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"access_token":"token","token_type":"bearer"}`)
+			default:
+				fmt.Fprintf(w, `{"authorization":%q}`, r.Header.Get("Authorization"))
+			}
+		})
+		client, err := infinity.NewClient(models.InfinitySettings{
+			URL:                  "http://api.example.com",
+			AuthenticationMethod: models.AuthenticationMethodOAuth,
+			OAuth2Settings:       models.OAuth2Settings{OAuth2Type: models.AuthOAuthTypeClientCredentials, TokenURL: "http://auth.example.com/token"},
+			ProxyURL:             proxy.URL,
+			ProxyUserName:        "proxyuser",
+			ProxyPassword:        "proxypass",
+		})
+		require.NoError(t, err)
+		res, statusCode, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, map[string]any{"authorization": "Bearer token"}, res)
+		assert.Equal(t, []string{"http://auth.example.com/token", "http://api.example.com/users"}, urls())
+	})
+	t.Run("should reject requests without the proxy credentials", func(t *testing.T) {
+		proxy, urls := httpProxy(t, func(w http.ResponseWriter, r *http.Request) {})
+		client, err := infinity.NewClient(models.InfinitySettings{URL: "http://api.example.com", ProxyURL: proxy.URL})
+		require.NoError(t, err)
+		_, statusCode, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusProxyAuthRequired, statusCode)
+		assert.Empty(t, urls())
+	})
+	t.Run("should send url queries through the socks5 proxy", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok":true}`)
+		}))
+		defer server.Close()
+		proxy, addresses := socks5Proxy(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, ProxyURL: "socks5://" + proxy, ProxyUserName: "proxyuser", ProxyPassword: "proxypass"})
+		require.NoError(t, err)
+		res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"ok": true}, res)
+		assert.Equal(t, []string{server.Listener.Addr().String()}, addresses())
+	})
+	t.Run("should bypass the proxy for hosts in the no proxy list", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"direct":true}`)
+		}))
+		defer server.Close()
+		proxy, urls := httpProxy(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"direct":false}`)
+		})
+		for _, noProxy := range []string{"127.0.0.1", "127.0.0.0/8", server.Listener.Addr().String(), "*"} {
+			client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, ProxyURL: proxy.URL, ProxyUserName: "proxyuser", ProxyPassword: "proxypass", NoProxy: []string{"internal.example.com", noProxy}})
+			require.NoError(t, err)
+			res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"direct": true}, res, noProxy)
+		}
+		assert.Empty(t, urls())
+		for _, noProxy := range []string{"127.0.0.2", "10.0.0.0/8", "127.0.0.1:1", ".example.com"} {
+			client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, ProxyURL: proxy.URL, ProxyUserName: "proxyuser", ProxyPassword: "proxypass", NoProxy: []string{noProxy}})
+			require.NoError(t, err)
+			res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"direct": false}, res, noProxy)
+		}
+		assert.Len(t, urls(), 4)
+	})
+	t.Run("should match domains and subdomains", func(t *testing.T) {
+		bypass := map[string][]string{
+			"example.com":        {"http://api.example.com/users", "http://example.com/users", "http://api.example.com:8080/users"},
+			".example.com":       {"http://api.example.com/users", "http://api.example.com:8080/users"},
+			"*.example.com":      {"http://api.example.com/users", "http://api.example.com:8080/users"},
+			"api.example.com:80": {"http://api.example.com/users"},
+		}
+		for noProxy, expected := range bypass {
+			proxyFunc, err := infinity.GetProxyFunc(models.InfinitySettings{ProxyURL: "http://proxy.example.com:3128", NoProxy: []string{noProxy}})
+			require.NoError(t, err)
+			bypassed := []string{}
+			for _, target := range []string{"http://api.example.com/users", "http://example.com/users", "http://api.example.com:8080/users", "http://notexample.com/users"} {
+				req, _ := http.NewRequest(http.MethodGet, target, nil)
+				proxyURL, err := proxyFunc(req)
+				require.NoError(t, err)
+				if proxyURL == nil {
+					bypassed = append(bypassed, target)
+				}
+			}
+			assert.Equal(t, expected, bypassed, noProxy)
+		}
+	})
+	t.Run("should fail on invalid proxy urls", func(t *testing.T) {
+		for _, proxyURL := range []string{"ftp://proxy.example.com", "http://", "://proxy"} {
+			_, err := infinity.NewClient(models.InfinitySettings{URL: "http://api.example.com", ProxyURL: proxyURL})
+			assert.Error(t, err, proxyURL)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package infinity
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+// sensitiveHeaders are always masked, along with the custom headers and the API key header of the datasource
+var sensitiveHeaders = []string{headerKeyAuthorization, "Proxy-Authorization", "Cookie", "Set-Cookie", headerKeyIdToken, "X-Api-Key", "X-Amz-Security-Token"}
+
+// sensitiveFields are the query, form, JSON and XML fields that are always masked, along with the secure query fields
+// of the datasource
+var sensitiveFields = []string{
+	"access_token", "refresh_token", "id_token", "subject_token", "actor_token", "client_secret", "assertion",
+	"password", "secret", "token", "api_key", "apikey",
+	"X-Amz-Signature", "X-Amz-Credential", "X-Amz-Security-Token", "SecretAccessKey", "SessionToken",
+}
+
+// minSecretLength keeps short values, which would mask unrelated text, out of the redaction
+const minSecretLength = 4
+
+// redactor masks the secrets of the datasource in captured requests and responses
+type redactor struct {
+	headers map[string]bool
+	secrets []string
+	fields  []*regexp.Regexp
+}
+
+func newRedactor(settings models.InfinitySettings) *redactor {
+	r := &redactor{headers: map[string]bool{}}
+	for _, name := range sensitiveHeaders {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	for name, value := range settings.CustomHeaders {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+		r.addSecret(value)
+	}
+	fields := append([]string{}, sensitiveFields...)
+	for name, value := range settings.SecureQueryFields {
+		fields = append(fields, name)
+		r.addSecret(value)
+	}
+	if settings.AuthenticationMethod == models.AuthenticationMethodApiKey && settings.ApiKeyKey != "" {
+		r.headers[http.CanonicalHeaderKey(settings.ApiKeyKey)] = true
+		fields = append(fields, settings.ApiKeyKey)
+	}
+	for _, value := range []string{
+		settings.Password, settings.BearerToken, settings.ApiKeyValue, settings.AWSSecretKey, settings.AWSSessionToken,
+		settings.ProxyPassword, settings.TLSClientKey, settings.OAuth2Settings.ClientSecret, settings.OAuth2Settings.PrivateKey,
+		settings.OAuth2Settings.Password, settings.OAuth2Settings.RefreshToken,
+	} {
+		r.addSecret(value)
+	}
+	names := make([]string, len(fields))
+	for i, name := range fields {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	pattern := strings.Join(names, "|")
+	r.fields = []*regexp.Regexp{
+		// query strings and form bodies
+		regexp.MustCompile(`(?i)((?:^|[?&])(?:` + pattern + `)=)[^&]*`),
+		// JSON
+		regexp.MustCompile(`(?i)("(?:` + pattern + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`),
+		// XML
+		regexp.MustCompile(`(?i)(<(?:` + pattern + `)>)[^<]*`),
+	}
+	return r
+}
+
+// addSecret registers a value to mask wherever it shows up, as is and URL encoded
+func (r *redactor) addSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	r.secrets = append(r.secrets, value)
+	if escaped := url.QueryEscape(value); escaped != value {
+		r.secrets = append(r.secrets, escaped)
+	}
+}
+
+// addHeaderSecrets registers the values of the sensitive headers, without their authentication scheme
+func (r *redactor) addHeaderSecrets(h http.Header) {
+	for name, values := range h {
+		if !r.headers[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for _, value := range values {
+			parts := strings.SplitN(value, " ", 2)
+			r.addSecret(parts[len(parts)-1])
+		}
+	}
+}
+
+// text masks the known secret values
+func (r *redactor) text(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, dummyHeader)
+	}
+	return s
+}
+
+// body masks the sensitive fields and the known secret values
+func (r *redactor) body(s string) string {
+	s = r.fields[0].ReplaceAllString(s, "${1}"+dummyHeader)
+	s = r.fields[1].ReplaceAllString(s, `${1}"`+dummyHeader+`"`)
+	s = r.fields[2].ReplaceAllString(s, "${1}"+dummyHeader)
+	return r.text(s)
+}
+
+func (r *redactor) url(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return r.text(raw)
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), dummyHeader)
+	}
+	u.RawQuery = r.fields[0].ReplaceAllString(u.RawQuery, "${1}"+dummyHeader)
+	return r.text(u.String())
+}
+
+func (r *redactor) header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		key := http.CanonicalHeaderKey(name)
+		masked := make([]string, len(values))
+		for i, value := range values {
+			switch {
+			case !r.headers[key]:
+				masked[i] = r.text(value)
+			case strings.Contains(value, " ") && (key == headerKeyAuthorization || key == "Proxy-Authorization"):
+				// the scheme is kept, it tells which authentication was used
+				masked[i] = strings.SplitN(value, " ", 2)[0] + " " + dummyHeader
+			default:
+				masked[i] = dummyHeader
+			}
+		}
+		out[name] = masked
+	}
+	return out
+}
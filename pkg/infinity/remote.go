@@ -14,7 +14,12 @@ import (
 
 func GetFrameForURLSources(ctx context.Context, query models.Query, infClient Client, requestHeaders map[string]string) (*data.Frame, error) {
 	frame := GetDummyFrame(query)
-	urlResponseObject, statusCode, duration, err := infClient.GetResults(ctx, query, requestHeaders)
+	var recorder *captureRecorder
+	if query.URLOptions.Debug {
+		recorder = newCaptureRecorder()
+		ctx = withCaptureRecorder(ctx, recorder)
+	}
+	urlResponseObject, statusCode, duration, info, err := infClient.getResults(ctx, query, requestHeaders)
 	frame.Meta.ExecutedQueryString = infClient.GetExecutedURL(query)
 	if infClient.IsMock {
 		duration = 123
@@ -26,6 +31,10 @@ func GetFrameForURLSources(ctx context.Context, query models.Query, infClient Cl
 			Duration:               duration,
 			Query:                  query,
 			Error:                  err.Error(),
+			Cache:                  info.cacheStatus,
+			Pages:                  info.pages,
+			Attempts:               info.attempts,
+			Capture:                recorder.capture(newRedactor(infClient.Settings)),
 		}
 		return frame, err
 	}
@@ -82,6 +91,16 @@ func GetFrameForURLSources(ctx context.Context, query models.Query, infClient Cl
 		Data:                   urlResponseObject,
 		ResponseCodeFromServer: statusCode,
 		Duration:               duration,
+		Cache:                  info.cacheStatus,
+		Pages:                  info.pages,
+		Attempts:               info.attempts,
+		Capture:                recorder.capture(newRedactor(infClient.Settings)),
+	}
+	if info.truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Pagination stopped after %d pages because the max pages or max rows limit was reached. The results are incomplete", info.pages),
+		})
 	}
 	if err != nil {
 		backend.Logger.Error("error getting response for query", "error", err.Error())
@@ -91,6 +110,8 @@ func GetFrameForURLSources(ctx context.Context, query models.Query, infClient Cl
 			Duration:               duration,
 			Query:                  query,
 			Error:                  err.Error(),
+			Cache:                  info.cacheStatus,
+			Capture:                recorder.capture(newRedactor(infClient.Settings)),
 		}
 		return frame, err
 	}
@@ -96,10 +96,10 @@ func (client *Client) GetExecutedURL(query models.Query) string {
 		out = append(out, "###############", "## GROQ", "###############", "", query.GROQ, "")
 	}
 	if client.Settings.AuthenticationMethod == models.AuthenticationMethodOAuth {
-		out = append(out, "###############", "> Authentication steps not included for OAuth authentication")
+		out = append(out, "###############", "> Authentication steps not included for OAuth authentication. Enable the debug option of the query to capture them")
 	}
 	if client.Settings.AuthenticationMethod == models.AuthenticationMethodAWS {
-		out = append(out, "###############", "> Authentication steps not included for AWS authentication")
+		out = append(out, "###############", "> Authentication steps not included for AWS authentication. Enable the debug option of the query to capture them")
 	}
 	return strings.Join(out, "\n")
 }
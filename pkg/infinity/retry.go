@@ -0,0 +1,154 @@
+package infinity
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	defaultRetryInitialDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay     = 5 * time.Second
+	headerKeyRetryAfter      = "Retry-After"
+)
+
+var defaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+type retryPolicy struct {
+	maxRetries   int64
+	statusCodes  map[int]bool
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	timeout      time.Duration
+}
+
+// getRetryPolicy merges the retry settings of the query into the ones of the datasource
+func getRetryPolicy(settings models.InfinitySettings, query models.Query) retryPolicy {
+	retry := settings.Retry
+	if override := query.URLOptions.Retry; override != nil {
+		if override.MaxRetries != 0 {
+			retry.MaxRetries = override.MaxRetries
+		}
+		if len(override.StatusCodes) > 0 {
+			retry.StatusCodes = override.StatusCodes
+		}
+		if override.InitialDelayInMs > 0 {
+			retry.InitialDelayInMs = override.InitialDelayInMs
+		}
+		if override.MaxDelayInMs > 0 {
+			retry.MaxDelayInMs = override.MaxDelayInMs
+		}
+		if override.TimeoutInSeconds > 0 {
+			retry.TimeoutInSeconds = override.TimeoutInSeconds
+		}
+	}
+	policy := retryPolicy{
+		maxRetries:   retry.MaxRetries,
+		statusCodes:  make(map[int]bool),
+		initialDelay: defaultRetryInitialDelay,
+		maxDelay:     defaultRetryMaxDelay,
+		timeout:      time.Duration(retry.TimeoutInSeconds) * time.Second,
+	}
+	// a negative count in the query disables the retries of the datasource
+	if policy.maxRetries < 0 {
+		policy.maxRetries = 0
+	}
+	statusCodes := retry.StatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = defaultRetryStatusCodes
+	}
+	for _, code := range statusCodes {
+		policy.statusCodes[code] = true
+	}
+	if retry.InitialDelayInMs > 0 {
+		policy.initialDelay = time.Duration(retry.InitialDelayInMs) * time.Millisecond
+	}
+	if retry.MaxDelayInMs > 0 {
+		policy.maxDelay = time.Duration(retry.MaxDelayInMs) * time.Millisecond
+	}
+	return policy
+}
+
+// canRetry reports whether a request may be sent again. Only idempotent requests are retried.
+func (policy retryPolicy) canRetry(req *http.Request) bool {
+	return policy.maxRetries > 0 && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+// delay returns how long to wait before the given retry: an exponential backoff with jitter, unless the upstream
+// asked for a specific delay with Retry-After. ok is false when that delay is longer than the max delay, the request
+// isn't retried then.
+func (policy retryPolicy) delay(retry int64, res *http.Response) (wait time.Duration, ok bool) {
+	if res != nil {
+		if retryAfter, found := parseRetryAfter(res.Header.Get(headerKeyRetryAfter)); found {
+			return retryAfter, retryAfter <= policy.maxDelay
+		}
+	}
+	backoff := policy.initialDelay
+	for i := int64(1); i < retry && backoff < policy.maxDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.maxDelay {
+		backoff = policy.maxDelay
+	}
+	// jitter in [backoff/2, backoff] so that clients retrying together spread out
+	half := int64(backoff / 2)
+	return time.Duration(half + rand.Int63n(half+1)), true //nolint:gosec
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// doWithRetry sends the request and retries it on transport errors and on the retryable status codes, until the
+// retries are exhausted, the upstream asks to wait longer than the max delay or waiting would exceed the deadline of
+// the context. It returns the last response and the number of attempts made.
+func (client *Client) doWithRetry(ctx context.Context, req *http.Request, policy retryPolicy) (res *http.Response, attempts int, err error) {
+	for {
+		attempts++
+		res, err = client.HttpClient.Do(req)
+		if !policy.canRetry(req) || int64(attempts) > policy.maxRetries || ctx.Err() != nil {
+			return res, attempts, err
+		}
+		if err == nil && res != nil && !policy.statusCodes[res.StatusCode] {
+			return res, attempts, err
+		}
+		wait, ok := policy.delay(int64(attempts), res)
+		if !ok {
+			return res, attempts, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return res, attempts, err
+		}
+		if res != nil {
+			// the body is drained so that the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+			res.Body.Close()
+		}
+		backend.Logger.Debug("retrying request", "url", maskVaultSecrets(ctx, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path), "attempt", attempts, "wait", wait.String())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempts, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
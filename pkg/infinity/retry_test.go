@@ -0,0 +1,128 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first requests with the given status code
+func flakyServer(t *testing.T, failures int32, statusCode int, retryAfter string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statusCode)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetries(t *testing.T) {
+	retry := models.RetrySettings{MaxRetries: 3, InitialDelayInMs: 1, MaxDelayInMs: 5}
+	t.Run("should retry retryable status codes and report the attempts", func(t *testing.T) {
+		server, calls := flakyServer(t, 2, http.StatusServiceUnavailable, "")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		query := models.Query{URL: "/", Type: models.QueryTypeJSON, Source: "url"}
+		frame, err := infinity.GetFrameForURLSources(context.Background(), query, *client, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, frame.Meta.Custom.(*infinity.CustomMeta).Attempts)
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	})
+	t.Run("should give up after the max retries", func(t *testing.T) {
+		server, calls := flakyServer(t, 10, http.StatusBadGateway, "")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+	})
+	t.Run("should not retry other status codes", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusInternalServerError, "")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("should not retry post requests", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, "")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		query := models.Query{URL: "/", Type: models.QueryTypeJSON, URLOptions: models.URLOptions{Method: http.MethodPost, Body: "{}"}}
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("should let the query override the retries of the datasource", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusTooManyRequests, "")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		query := models.Query{URL: "/", Type: models.QueryTypeJSON, URLOptions: models.URLOptions{Retry: &models.RetrySettings{MaxRetries: -1}}}
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+		server, calls = flakyServer(t, 1, http.StatusInternalServerError, "")
+		client, err = infinity.NewClient(models.InfinitySettings{URL: server.URL})
+		require.NoError(t, err)
+		query.URLOptions.Retry = &models.RetrySettings{MaxRetries: 1, StatusCodes: []int{http.StatusInternalServerError}, InitialDelayInMs: 1}
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("should honor retry-after", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusTooManyRequests, "1")
+		settings := models.InfinitySettings{URL: server.URL, Retry: retry}
+		settings.Retry.MaxDelayInMs = 2000
+		client, err := infinity.NewClient(settings)
+		require.NoError(t, err)
+		start := time.Now()
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("should not retry when retry-after exceeds the max delay", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusTooManyRequests, "3600")
+		client, err := infinity.NewClient(models.InfinitySettings{URL: server.URL, Retry: retry})
+		require.NoError(t, err)
+		start := time.Now()
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, statusCode)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("should stop retrying when the wait exceeds the deadline", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, "30")
+		settings := models.InfinitySettings{URL: server.URL, Retry: retry}
+		settings.Retry.MaxDelayInMs = 60000
+		settings.Retry.TimeoutInSeconds = 2
+		client, err := infinity.NewClient(settings)
+		require.NoError(t, err)
+		start := time.Now()
+		_, statusCode, _, err := client.GetResults(context.Background(), models.Query{URL: "/", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+}
@@ -0,0 +1,373 @@
+package infinity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const (
+	defaultTokenEarlyRefresh = time.Minute
+	// defaultTokenTTL is how long the tokens issued without an expiry are used before fetching new ones
+	defaultTokenTTL = time.Hour
+	// tokenFetchTimeout bounds the fetch of a token, which doesn't end with the request that started it
+	tokenFetchTimeout = 30 * time.Second
+	// maxCachedTokens bounds the token cache, the least recently used tokens are dropped beyond it
+	maxCachedTokens        = 1000
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	tokenTypeIdToken       = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+type cachedToken struct {
+	token     *oauth2.Token
+	refreshAt time.Time
+	// expiresAt is the expiry of the token, or the end of the default TTL for the tokens without one
+	expiresAt time.Time
+	usedAt    time.Time
+}
+
+func (t *cachedToken) fresh() bool {
+	return time.Now().Before(t.refreshAt)
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *oauth2.Token
+	err   error
+}
+
+// tokenCache holds the OAuth2 tokens of all the datasource instances, so that instances with the same credentials
+// share their tokens. Tokens are refreshed ahead of their expiry and concurrent refreshes of a token are merged.
+// The cache holds at most maxCachedTokens tokens.
+type tokenCache struct {
+	mu       sync.Mutex
+	tokens   map[string]*cachedToken
+	inflight map[string]*tokenCall
+}
+
+var sharedTokenCache = &tokenCache{tokens: map[string]*cachedToken{}, inflight: map[string]*tokenCall{}}
+
+// token returns the cached token of the key, or fetches it. fetch receives the expiring token, if any, to refresh it.
+// The fetch runs on its own, the callers stop waiting for it when their context is done.
+func (c *tokenCache) token(ctx context.Context, key string, earlyRefresh time.Duration, fetch func(current *oauth2.Token) (*oauth2.Token, error)) (*oauth2.Token, error) {
+	c.mu.Lock()
+	cached := c.tokens[key]
+	if cached != nil && cached.fresh() {
+		cached.usedAt = time.Now()
+		c.mu.Unlock()
+		return cached.token, nil
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &tokenCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		var current *oauth2.Token
+		if cached != nil {
+			current = cached.token
+		}
+		go c.fetch(key, call, current, earlyRefresh, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *tokenCache) fetch(key string, call *tokenCall, current *oauth2.Token, earlyRefresh time.Duration, fetch func(current *oauth2.Token) (*oauth2.Token, error)) {
+	call.token, call.err = fetch(current)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		delete(c.tokens, key)
+		c.evict()
+		now := time.Now()
+		cached := &cachedToken{token: call.token, refreshAt: refreshTime(call.token, earlyRefresh), expiresAt: call.token.Expiry, usedAt: now}
+		if call.token.Expiry.IsZero() {
+			cached.refreshAt = now.Add(defaultTokenTTL)
+			cached.expiresAt = cached.refreshAt
+		}
+		c.tokens[key] = cached
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// invalidate marks the token of the key as expired, when it got rejected before its expiry. The token is kept, so
+// that a refresh token rotated with it is used by the next fetch.
+func (c *tokenCache) invalidate(key string, token *oauth2.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.tokens[key]; ok && cached.token.AccessToken == token.AccessToken {
+		cached.refreshAt = time.Time{}
+		cached.expiresAt = time.Time{}
+	}
+}
+
+// evict drops the expired tokens which can't be refreshed, and the least recently used token when the cache is full.
+// Tokens which can't be refreshed are dropped first, a dropped refresh token may not be issued again.
+func (c *tokenCache) evict() {
+	now := time.Now()
+	for key, cached := range c.tokens {
+		if cached.expiresAt.Before(now) && cached.token.RefreshToken == "" {
+			delete(c.tokens, key)
+		}
+	}
+	for len(c.tokens) >= maxCachedTokens {
+		oldest := ""
+		for key, cached := range c.tokens {
+			if oldest == "" || evictBefore(cached, c.tokens[oldest]) {
+				oldest = key
+			}
+		}
+		delete(c.tokens, oldest)
+	}
+}
+
+// evictBefore reports whether a is dropped before b when the cache is full
+func evictBefore(a *cachedToken, b *cachedToken) bool {
+	if aRefreshable, bRefreshable := a.token.RefreshToken != "", b.token.RefreshToken != ""; aRefreshable != bRefreshable {
+		return bRefreshable
+	}
+	return a.usedAt.Before(b.usedAt)
+}
+
+// refreshTime is the early refresh before the expiry of the token, but at most half of its remaining lifetime
+func refreshTime(token *oauth2.Token, earlyRefresh time.Duration) time.Time {
+	if lifetime := time.Until(token.Expiry); earlyRefresh > lifetime/2 {
+		earlyRefresh = lifetime / 2
+	}
+	return token.Expiry.Add(-earlyRefresh)
+}
+
+func tokenEarlyRefresh(settings models.InfinitySettings) time.Duration {
+	if settings.OAuth2Settings.EarlyRefreshInSeconds > 0 {
+		return time.Duration(settings.OAuth2Settings.EarlyRefreshInSeconds) * time.Second
+	}
+	return defaultTokenEarlyRefresh
+}
+
+// tokenCacheKey identifies the credentials of the token. Secrets are part of it, so that a change of them gets a new token.
+func tokenCacheKey(settings models.InfinitySettings, subjectToken string) string {
+	oauth := settings.OAuth2Settings
+	params := []string{}
+	for k, v := range oauth.EndpointParams {
+		params = append(params, k+"="+v)
+	}
+	sort.Strings(params)
+	hash := sha256.New()
+	for _, part := range []string{
+		oauth.OAuth2Type, oauth.TokenURL, oauth.ClientID, oauth.ClientSecret, strings.Join(oauth.Scopes, " "), strings.Join(params, "&"),
+		oauth.Email, oauth.PrivateKeyID, oauth.PrivateKey, oauth.Subject, oauth.Username, oauth.Password, oauth.RefreshToken,
+		oauth.Audience, oauth.Resource, oauth.SubjectTokenType, oauth.RequestedTokenType, subjectToken,
+	} {
+		io.WriteString(hash, part+"\n") //nolint
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// oauthTransport authorizes the requests with the tokens of the shared token cache
+type oauthTransport struct {
+	base         http.RoundTripper
+	httpClient   *http.Client
+	settings     models.InfinitySettings
+	earlyRefresh time.Duration
+	// fetch gets a new token. subjectToken is the forwarded user token, for token exchange
+	fetch func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error)
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	subjectToken := ""
+	if t.settings.OAuth2Settings.OAuth2Type == models.AuthOAuthTokenExchange {
+		subjectToken = forwardedSubjectToken(req, t.settings)
+		if subjectToken == "" {
+			closeRequestBody(req)
+			return nil, errors.New("no user token to exchange. enable forwarding the oauth identity of the user")
+		}
+	}
+	key := tokenCacheKey(t.settings, subjectToken)
+	token, err := sharedTokenCache.token(req.Context(), key, t.earlyRefresh, func(current *oauth2.Token) (*oauth2.Token, error) {
+		// the fetch is shared by the concurrent requests, it isn't cancelled with the request which started it
+		ctx, cancel := context.WithTimeout(detachedContext{parent: req.Context()}, tokenFetchTimeout)
+		defer cancel()
+		return t.fetch(context.WithValue(ctx, oauth2.HTTPClient, t.httpClient), current, subjectToken)
+	})
+	if err != nil {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%v: %w", "error getting oauth2 token", err)
+	}
+	authorized := req.Clone(req.Context())
+	if subjectToken != "" {
+		// the user tokens aren't passed on downstream
+		authorized.Header.Del(headerKeyIdToken)
+	}
+	token.SetAuthHeader(authorized)
+	res, err := t.base.RoundTrip(authorized)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		sharedTokenCache.invalidate(key, token)
+	}
+	return res, err
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// forwardedSubjectToken is the token of the Grafana user forwarded with the request
+func forwardedSubjectToken(req *http.Request, settings models.InfinitySettings) string {
+	if settings.OAuth2Settings.SubjectTokenType == tokenTypeIdToken {
+		return req.Header.Get(headerKeyIdToken)
+	}
+	authHeader := req.Header.Get(headerKeyAuthorization)
+	if len(authHeader) > 7 && strings.EqualFold(authHeader[:7], "bearer ") {
+		return strings.TrimSpace(authHeader[7:])
+	}
+	return ""
+}
+
+func newOAuthClient(httpClient *http.Client, settings models.InfinitySettings, fetch func(ctx context.Context, current *oauth2.Token, subjectToken string) (*oauth2.Token, error)) *http.Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &oauthTransport{
+			base:         base,
+			httpClient:   httpClient,
+			settings:     settings,
+			earlyRefresh: tokenEarlyRefresh(settings),
+			fetch:        fetch,
+		},
+		Timeout:       httpClient.Timeout,
+		CheckRedirect: httpClient.CheckRedirect,
+	}
+}
+
+func oauthScopes(settings models.InfinitySettings) []string {
+	scopes := []string{}
+	for _, scope := range settings.OAuth2Settings.Scopes {
+		if scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func oauthConfig(settings models.InfinitySettings) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     settings.OAuth2Settings.ClientID,
+		ClientSecret: settings.OAuth2Settings.ClientSecret,
+		Endpoint:     oauth2.Endpoint{TokenURL: settings.OAuth2Settings.TokenURL},
+		Scopes:       oauthScopes(settings),
+	}
+}
+
+// refreshOrFetch uses the refresh token of the current token, if any, and falls back to fetching a new token
+func refreshOrFetch(ctx context.Context, settings models.InfinitySettings, current *oauth2.Token, fetch func() (*oauth2.Token, error)) (*oauth2.Token, error) {
+	if current != nil && current.RefreshToken != "" {
+		token, err := oauthConfig(settings).TokenSource(ctx, &oauth2.Token{RefreshToken: current.RefreshToken}).Token()
+		if err == nil {
+			return token, nil
+		}
+	}
+	return fetch()
+}
+
+// exchangeToken swaps the subject token for a token of the downstream API, as per RFC 8693
+func exchangeToken(ctx context.Context, settings models.InfinitySettings, subjectToken string) (*oauth2.Token, error) {
+	oauth := settings.OAuth2Settings
+	form := url.Values{}
+	form.Set("grant_type", grantTypeTokenExchange)
+	form.Set("subject_token", subjectToken)
+	form.Set("subject_token_type", tokenTypeAccessToken)
+	if oauth.SubjectTokenType != "" {
+		form.Set("subject_token_type", oauth.SubjectTokenType)
+	}
+	if oauth.RequestedTokenType != "" {
+		form.Set("requested_token_type", oauth.RequestedTokenType)
+	}
+	if oauth.Audience != "" {
+		form.Set("audience", oauth.Audience)
+	}
+	if oauth.Resource != "" {
+		form.Set("resource", oauth.Resource)
+	}
+	if scopes := oauthScopes(settings); len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	for k, v := range oauth.EndpointParams {
+		if k != "" && v != "" {
+			form.Set(k, v)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headerKeyContentType, contentTypeFormURLEncoded)
+	req.Header.Set(headerKeyAccept, contentTypeJSON)
+	if oauth.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(oauth.ClientID), url.QueryEscape(oauth.ClientSecret))
+	}
+	httpClient, _ := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		AccessToken      string `json:"access_token"`
+		IssuedTokenType  string `json:"issued_token_type"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil && res.StatusCode/100 == 2 {
+		return nil, fmt.Errorf("%v: %w", "invalid token exchange response", err)
+	}
+	if res.StatusCode/100 != 2 || out.AccessToken == "" {
+		if out.Error != "" {
+			return nil, fmt.Errorf("token exchange failed. %s: %s", out.Error, out.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token exchange failed. status: %d", res.StatusCode)
+	}
+	// exchanged tokens are exchanged again rather than refreshed, so that they expire from the cache
+	token := &oauth2.Token{AccessToken: out.AccessToken, TokenType: out.TokenType}
+	// issued tokens that aren't access tokens have a token type of N_A and are sent as bearer tokens
+	if strings.EqualFold(token.TokenType, "N_A") {
+		token.TokenType = "Bearer"
+	}
+	if out.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,275 @@
+package infinity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/models"
+)
+
+const (
+	defaultVaultTTL = 5 * time.Minute
+	// vaultFetchTimeout bounds the read of a secret, which doesn't end with the request that started it
+	vaultFetchTimeout = 30 * time.Second
+	// maxVaultResponseSize bounds the secret documents read from the vault
+	maxVaultResponseSize = 1024 * 1024
+)
+
+// vaultPlaceholder matches ${__vault.<path>#<key>}
+var vaultPlaceholder = regexp.MustCompile(`\$\{__vault\.([^#{}]+)#([^{}]+)\}`)
+
+type vaultSecret struct {
+	values  map[string]string
+	expires time.Time
+}
+
+type vaultCall struct {
+	done   chan struct{}
+	values map[string]string
+	err    error
+}
+
+// vaultCache holds the secrets read from the vault by all the datasource instances, until their TTL. Concurrent
+// reads of a secret are merged.
+type vaultCache struct {
+	mu       sync.Mutex
+	secrets  map[string]*vaultSecret
+	inflight map[string]*vaultCall
+}
+
+var sharedVaultCache = &vaultCache{secrets: map[string]*vaultSecret{}, inflight: map[string]*vaultCall{}}
+
+// secret returns the cached values of the key, or reads them. The read runs on its own, the callers stop waiting for
+// it when their context is done.
+func (c *vaultCache) secret(ctx context.Context, key string, ttl time.Duration, fetch func() (map[string]string, error)) (map[string]string, error) {
+	c.mu.Lock()
+	if cached, ok := c.secrets[key]; ok && time.Now().Before(cached.expires) {
+		c.mu.Unlock()
+		return cached.values, nil
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &vaultCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.fetch(key, call, ttl, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.values, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *vaultCache) fetch(key string, call *vaultCall, ttl time.Duration, fetch func() (map[string]string, error)) {
+	call.values, call.err = fetch()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		now := time.Now()
+		for k, cached := range c.secrets {
+			if now.After(cached.expires) {
+				delete(c.secrets, k)
+			}
+		}
+		c.secrets[key] = &vaultSecret{values: call.values, expires: now.Add(ttl)}
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+func vaultTTL(settings models.InfinitySettings) time.Duration {
+	if settings.VaultTTLInSeconds > 0 {
+		return time.Duration(settings.VaultTTLInSeconds) * time.Second
+	}
+	return defaultVaultTTL
+}
+
+// resolveVaultPlaceholders returns a copy of the query with the vault placeholders of its URL, params, headers and
+// body replaced by their secrets, along with the secrets used
+func (client *Client) resolveVaultPlaceholders(ctx context.Context, query models.Query) (models.Query, []string, error) {
+	resolved := map[string]string{}
+	var err error
+	replace := func(input string) string {
+		if !strings.Contains(input, "${__vault.") {
+			return input
+		}
+		return vaultPlaceholder.ReplaceAllStringFunc(input, func(placeholder string) string {
+			if value, ok := resolved[placeholder]; ok || err != nil {
+				return value
+			}
+			match := vaultPlaceholder.FindStringSubmatch(placeholder)
+			value, secretErr := client.vaultSecret(ctx, match[1], match[2])
+			if secretErr != nil {
+				err = secretErr
+				return ""
+			}
+			resolved[placeholder] = value
+			return value
+		})
+	}
+	replacePairs := func(pairs []models.URLOptionKeyValuePair) []models.URLOptionKeyValuePair {
+		out := make([]models.URLOptionKeyValuePair, len(pairs))
+		for i, pair := range pairs {
+			out[i] = pair
+			out[i].Value = replace(pair.Value)
+		}
+		return out
+	}
+	query.URL = replace(query.URL)
+	query.URLOptions.Params = replacePairs(query.URLOptions.Params)
+	query.URLOptions.Headers = replacePairs(query.URLOptions.Headers)
+	query.URLOptions.BodyForm = replacePairs(query.URLOptions.BodyForm)
+	query.URLOptions.Body = replace(query.URLOptions.Body)
+	query.URLOptions.BodyGraphQLQuery = replace(query.URLOptions.BodyGraphQLQuery)
+	if err != nil {
+		return query, nil, err
+	}
+	secrets := make([]string, 0, len(resolved))
+	for _, value := range resolved {
+		secrets = append(secrets, value)
+	}
+	return query, secrets, nil
+}
+
+// isAllowedVaultPath checks the path against the vault paths allowed by the datasource. An allowed path covers the
+// secrets below it.
+func isAllowedVaultPath(secretPath string, allowedPaths []string) bool {
+	for _, segment := range strings.Split(secretPath, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	for _, allowed := range allowedPaths {
+		allowed = strings.Trim(strings.TrimSpace(allowed), "/")
+		if allowed != "" && (secretPath == allowed || strings.HasPrefix(secretPath, allowed+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// vaultSecret returns the value of a key of a vault secret
+func (client *Client) vaultSecret(ctx context.Context, path string, key string) (string, error) {
+	base := strings.TrimSpace(client.Settings.VaultURL)
+	if base == "" {
+		return "", errors.New("vault placeholders require a vault URL. configure it in the datasource settings")
+	}
+	if !isAllowedVaultPath(path, client.Settings.VaultAllowedPaths) {
+		return "", fmt.Errorf("the vault secret %q is not allowed. To allow it, update the allowed vault paths of the datasource settings", path)
+	}
+	hash := sha256.Sum256([]byte(base + "\n" + client.Settings.VaultToken + "\n" + path))
+	values, err := sharedVaultCache.secret(ctx, hex.EncodeToString(hash[:]), vaultTTL(client.Settings), func() (map[string]string, error) {
+		// the read is shared by the concurrent requests, it isn't cancelled with the request which started it
+		fetchCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, vaultFetchTimeout)
+		defer cancel()
+		return client.fetchVaultSecret(fetchCtx, base, path)
+	})
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in the vault secret %q", key, path)
+	}
+	return value, nil
+}
+
+func (client *Client) fetchVaultSecret(ctx context.Context, base string, path string) (map[string]string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "invalid vault url", err)
+	}
+	q := u.Query()
+	q.Set("path", path)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "invalid vault url", err)
+	}
+	req.Header.Set(headerKeyAccept, contentTypeJSON)
+	if client.Settings.VaultToken != "" {
+		req.Header.Set(headerKeyAuthorization, "Bearer "+client.Settings.VaultToken)
+	}
+	httpClient := client.ProxyHttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", fmt.Sprintf("error reading the vault secret %q", path), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("error reading the vault secret %q. status: %d", path, res.StatusCode)
+	}
+	var out map[string]any
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxVaultResponseSize)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("%v: %w", fmt.Sprintf("error parsing the vault secret %q", path), err)
+	}
+	// KV secret engines nest the values under data, twice for the version 2 of the engine
+	for i := 0; i < 2; i++ {
+		if data, ok := out["data"].(map[string]any); ok {
+			out = data
+		}
+	}
+	values := make(map[string]string, len(out))
+	for key, value := range out {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		default:
+			b, _ := json.Marshal(v)
+			values[key] = string(b)
+		}
+	}
+	return values, nil
+}
+
+type vaultSecretsKey struct{}
+
+// withVaultSecrets keeps the resolved secrets of a request, to mask them in the errors, logs and captures
+func withVaultSecrets(ctx context.Context, secrets []string) context.Context {
+	if len(secrets) == 0 {
+		return ctx
+	}
+	if recorder, ok := ctx.Value(captureRecorderKey{}).(*captureRecorder); ok && recorder != nil {
+		recorder.addSecrets(secrets)
+	}
+	return context.WithValue(ctx, vaultSecretsKey{}, secrets)
+}
+
+func maskVaultSecrets(ctx context.Context, input string) string {
+	secrets, _ := ctx.Value(vaultSecretsKey{}).([]string)
+	for _, secret := range secrets {
+		if secret != "" {
+			input = strings.ReplaceAll(input, secret, dummyHeader)
+			input = strings.ReplaceAll(input, url.QueryEscape(secret), dummyHeader)
+			input = strings.ReplaceAll(input, url.PathEscape(secret), dummyHeader)
+		}
+	}
+	return input
+}
+
+// maskVaultSecretsInError masks the secrets in the URL the http client errors include
+func maskVaultSecretsInError(ctx context.Context, err error) error {
+	var urlErr *url.Error
+	if _, ok := ctx.Value(vaultSecretsKey{}).([]string); ok && errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: maskVaultSecrets(ctx, urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package infinity_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultServer stands in for the vault API. It serves a KV version 2 secret at apps/api, to the vault token only
+func vaultServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("path") != "apps/api" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"data":{"token":"s3cr3t-token","user":"svc-user","port":8080}}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// requestEcho returns the user param, the X-Token header and the body it received
+func requestEcho(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"user":%q,"token":%q,"body":%q}`, r.URL.Query().Get("user"), r.Header.Get("X-Token"), string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultPlaceholders(t *testing.T) {
+	query := models.Query{
+		URL:    "/items?user=${__vault.apps/api#user}",
+		Type:   models.QueryTypeJSON,
+		Source: "url",
+		URLOptions: models.URLOptions{
+			Method:   http.MethodPost,
+			BodyType: "raw",
+			Body:     `{"port":${__vault.apps/api#port}}`,
+			Headers:  []models.URLOptionKeyValuePair{{Key: "X-Token", Value: "Bearer ${__vault.apps/api#token}"}},
+		},
+	}
+	t.Run("should resolve the placeholders when the request is sent", func(t *testing.T) {
+		vault, calls := vaultServer(t)
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: vault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}})
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			res, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"user": "svc-user", "token": "Bearer s3cr3t-token", "body": `{"port":8080}`}, res)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		assert.Equal(t, "Bearer ${__vault.apps/api#token}", query.URLOptions.Headers[0].Value)
+
+		executed := client.GetExecutedURL(query)
+		assert.NotContains(t, executed, "svc-user")
+		assert.NotContains(t, executed, "s3cr3t-token")
+
+		debug := query
+		debug.URLOptions.Debug = true
+		capture := getCapture(t, client, debug)
+		require.NotNil(t, capture)
+		assert.NotContains(t, fmt.Sprintf("%+v", capture), "svc-user")
+		assert.NotContains(t, fmt.Sprintf("%+v", capture), "s3cr3t-token")
+	})
+	t.Run("should read the secrets again after their TTL", func(t *testing.T) {
+		vault, calls := vaultServer(t)
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: vault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}, VaultTTLInSeconds: 1})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		time.Sleep(1100 * time.Millisecond)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+	t.Run("should fail on unknown secrets", func(t *testing.T) {
+		vault, _ := vaultServer(t)
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: vault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/?key=${__vault.apps/api#missing}", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `key "missing" not found in the vault secret "apps/api"`)
+
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/?key=${__vault.apps/other#token}", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `error reading the vault secret "apps/other". status: 404`)
+	})
+	t.Run("should only read the allowed paths", func(t *testing.T) {
+		vault, calls := vaultServer(t)
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: vault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/api"}})
+		require.NoError(t, err)
+		for _, path := range []string{"admin/root", "apps/api2", "apps/api/../../admin/root"} {
+			_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/?key=${__vault." + path + "#token}", Type: models.QueryTypeJSON}, map[string]string{})
+			require.Error(t, err, path)
+			assert.Contains(t, err.Error(), fmt.Sprintf("the vault secret %q is not allowed", path))
+		}
+		client, err = infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: vault.URL, VaultToken: "vault-token"})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `the vault secret "apps/api" is not allowed`)
+		assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+	})
+	t.Run("should fail without a vault url", func(t *testing.T) {
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), query, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "vault placeholders require a vault URL")
+	})
+	t.Run("a cancelled request shouldn't cancel the read shared with other requests", func(t *testing.T) {
+		release := make(chan struct{})
+		vault, calls := vaultServer(t)
+		slowVault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			vault.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer slowVault.Close()
+		api := requestEcho(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: api.URL, VaultURL: slowVault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}})
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := make(chan error)
+		go func() {
+			_, _, _, err := client.GetResults(ctx, query, map[string]string{})
+			cancelled <- err
+		}()
+		waiting := make(chan error)
+		go func() {
+			_, _, _, err := client.GetResults(context.Background(), query, map[string]string{})
+			waiting <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		assert.Error(t, <-cancelled)
+		close(release)
+		assert.NoError(t, <-waiting)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+	t.Run("should mask the secrets in the errors", func(t *testing.T) {
+		vault, _ := vaultServer(t)
+		client, err := infinity.NewClient(models.InfinitySettings{URL: "http://127.0.0.1:1", VaultURL: vault.URL, VaultToken: "vault-token", VaultAllowedPaths: []string{"apps/"}})
+		require.NoError(t, err)
+		_, _, _, err = client.GetResults(context.Background(), models.Query{URL: "/?user=${__vault.apps/api#user}", Type: models.QueryTypeJSON}, map[string]string{})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "svc-user")
+		assert.Contains(t, err.Error(), "user=xxxxxxxx")
+	})
+}
@@ -55,6 +55,36 @@ type CmdbCloudElementResponse struct {
 	ProductEnclaveInstanceId string                 `json:"productEnclaveInstanceId,omitempty"`
 }
 
+// Tags returns the resource tags of the cloud element, stored in its configuration either as a map or as a list of
+// AWS Key/Value pairs
+func (e *CmdbCloudElementResponse) Tags() map[string]string {
+	tags := make(map[string]string)
+	if e == nil {
+		return tags
+	}
+	for _, key := range []string{"tags", "Tags"} {
+		switch v := e.ConfigJson[key].(type) {
+		case map[string]interface{}:
+			for tagKey, tagValue := range v {
+				tags[tagKey] = fmt.Sprintf("%v", tagValue)
+			}
+		case []interface{}:
+			for _, item := range v {
+				pair, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				tagKey, _ := pair["Key"].(string)
+				tagValue, _ := pair["Value"].(string)
+				if tagKey != "" {
+					tags[tagKey] = tagValue
+				}
+			}
+		}
+	}
+	return tags
+}
+
 type QueryType string
 
 const (
@@ -168,6 +198,8 @@ type Query struct {
 	ResponseType        string                   `json:"responseType"`
 	CrossAccountRoleArn string                   `json:"crossAccountRoleArn,omitempty"`
 	ExternalId          string                   `json:"externalId,omitempty"`
+	// AWSCredentials are the landing zone credentials resolved for the query, to sign its requests
+	AWSCredentials *AwsCredential `json:"-"`
 }
 
 // It's copied from metric_find_query.go
@@ -191,9 +223,42 @@ type URLOptions struct {
 	BodyContentType  string                  `json:"body_content_type"`
 	BodyForm         []URLOptionKeyValuePair `json:"body_form"`
 	BodyGraphQLQuery string                  `json:"body_graphql_query"`
+	Pagination       *URLPagination          `json:"pagination,omitempty"`
+	Retry            *RetrySettings          `json:"retry,omitempty"` // overrides the retry settings of the datasource
+	AWS              *URLAWSOptions          `json:"aws,omitempty"`   // overrides the SigV4 region and service of the datasource
+	Debug            bool                    `json:"debug,omitempty"` // captures the redacted requests and responses into the frame meta
 	// BodyGraphQLVariables string           `json:"body_graphql_variables"`
 }
 
+type URLAWSOptions struct {
+	Region  string `json:"region,omitempty"`
+	Service string `json:"service,omitempty"`
+}
+
+type PaginationMode string
+
+const (
+	PaginationModeNone   PaginationMode = "none"
+	PaginationModeOffset PaginationMode = "offset"
+	PaginationModePage   PaginationMode = "page"
+	PaginationModeCursor PaginationMode = "cursor"
+	PaginationModeLink   PaginationMode = "link"
+)
+
+type URLPagination struct {
+	Mode PaginationMode `json:"mode"` // 'none' | 'offset' | 'page' | 'cursor' | 'link'
+	// Limit is the number of rows requested per page in the offset and page modes
+	Limit          int64  `json:"limit"`
+	LimitParam     string `json:"limit_param"`
+	OffsetParam    string `json:"offset_param"`
+	PageParam      string `json:"page_param"`
+	PageStart      *int64 `json:"page_start"`
+	CursorParam    string `json:"cursor_param"`
+	CursorSelector string `json:"cursor_selector"` // path of the next cursor in the response, such as meta.next_cursor
+	MaxPages       int64  `json:"max_pages"`
+	MaxRows        int64  `json:"max_rows"`
+}
+
 type InfinityCSVOptions struct {
 	Delimiter          string `json:"delimiter"`
 	SkipEmptyLines     bool   `json:"skip_empty_lines"`
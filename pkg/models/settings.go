@@ -23,6 +23,9 @@ const (
 const (
 	AuthOAuthTypeClientCredentials = "client_credentials"
 	AuthOAuthJWT                   = "jwt"
+	AuthOAuthRefreshToken          = "refresh_token"
+	AuthOAuthPassword              = "password"
+	AuthOAuthTokenExchange         = "token_exchange"
 	AuthOAuthOthers                = "others"
 )
 
@@ -42,18 +45,45 @@ type OAuth2Settings struct {
 	ClientSecret   string
 	PrivateKey     string
 	EndpointParams map[string]string
+	// password grant
+	Username string `json:"username,omitempty"`
+	Password string
+	// refresh token grant
+	RefreshToken string
+	// token exchange (RFC 8693) of the forwarded user token
+	Audience           string `json:"audience,omitempty"`
+	Resource           string `json:"resource,omitempty"`
+	SubjectTokenType   string `json:"subject_token_type,omitempty"`
+	RequestedTokenType string `json:"requested_token_type,omitempty"`
+	// tokens are refreshed this long before they expire
+	EarlyRefreshInSeconds int64 `json:"early_refresh_in_seconds,omitempty"`
 }
 
 type AWSAuthType string
 
 const (
-	AWSAuthTypeKeys AWSAuthType = "keys"
+	AWSAuthTypeKeys    AWSAuthType = "keys"
+	AWSAuthTypeDefault AWSAuthType = "default"
+	// the credentials of the landing zone of the query's cloud element, from the CMDB and the vault
+	AWSAuthTypeLandingZone AWSAuthType = "landingZone"
 )
 
 type AWSSettings struct {
-	AuthType AWSAuthType `json:"authType"`
-	Region   string      `json:"region"`
-	Service  string      `json:"service"`
+	AuthType      AWSAuthType `json:"authType"`
+	Region        string      `json:"region"`
+	Service       string      `json:"service"`
+	AssumeRoleARN string      `json:"assumeRoleArn,omitempty"`
+	ExternalID    string      `json:"externalId,omitempty"`
+	STSEndpoint   string      `json:"stsEndpoint,omitempty"`
+}
+
+// RetrySettings configures how failed requests are retried. Zero values fall back to the defaults of the client.
+type RetrySettings struct {
+	MaxRetries       int64 `json:"maxRetries,omitempty"`
+	StatusCodes      []int `json:"statusCodes,omitempty"`
+	InitialDelayInMs int64 `json:"initialDelayInMs,omitempty"`
+	MaxDelayInMs     int64 `json:"maxDelayInMs,omitempty"`
+	TimeoutInSeconds int64 `json:"timeoutInSeconds,omitempty"`
 }
 
 type InfinitySettings struct {
@@ -66,6 +96,7 @@ type InfinitySettings struct {
 	AWSSettings          AWSSettings
 	AWSAccessKey         string
 	AWSSecretKey         string
+	AWSSessionToken      string
 	URL                  string
 	BasicAuthEnabled     bool
 	UserName             string
@@ -82,6 +113,8 @@ type InfinitySettings struct {
 	TLSClientCert        string
 	TLSClientKey         string
 	AllowedHosts         []string
+	DeniedHosts          []string
+	BlockPrivateNetworks bool
 	EnableOpenAPI        bool
 	OpenAPIVersion       string
 	OpenAPIUrl           string
@@ -89,6 +122,19 @@ type InfinitySettings struct {
 	ReferenceData        []RefData
 	CmdbUrl              string
 	AwsxUrl              string
+	CacheEnabled         bool
+	CacheTTLInSeconds    int64
+	CacheMaxSizeInMB     int64
+	Retry                RetrySettings
+	MaxResponseSizeInMB  int64
+	ProxyURL             string
+	ProxyUserName        string
+	ProxyPassword        string
+	NoProxy              []string
+	VaultURL             string
+	VaultToken           string
+	VaultTTLInSeconds    int64
+	VaultAllowedPaths    []string
 }
 
 func (s *InfinitySettings) Validate() error {
@@ -144,11 +190,24 @@ type InfinitySettingsJson struct {
 	TLSAuthWithCACert    bool           `json:"tlsAuthWithCACert,omitempty"`
 	TimeoutInSeconds     int64          `json:"timeoutInSeconds,omitempty"`
 	AllowedHosts         []string       `json:"allowedHosts,omitempty"`
+	DeniedHosts          []string       `json:"deniedHosts,omitempty"`
+	BlockPrivateNetworks bool           `json:"blockPrivateNetworks,omitempty"`
 	EnableOpenAPI        bool           `json:"enableOpenApi,omitempty"`
 	OpenAPIVersion       string         `json:"openApiVersion,omitempty"`
 	OpenAPIUrl           string         `json:"openApiUrl,omitempty"`
 	OpenAPIBaseUrl       string         `json:"openAPIBaseURL,omitempty"`
 	ReferenceData        []RefData      `json:"refData,omitempty"`
+	CacheEnabled         bool           `json:"cacheEnabled,omitempty"`
+	CacheTTLInSeconds    int64          `json:"cacheTTLInSeconds,omitempty"`
+	CacheMaxSizeInMB     int64          `json:"cacheMaxSizeInMB,omitempty"`
+	Retry                RetrySettings  `json:"retry,omitempty"`
+	MaxResponseSizeInMB  int64          `json:"maxResponseSizeInMB,omitempty"`
+	ProxyURL             string         `json:"proxyUrl,omitempty"`
+	ProxyUserName        string         `json:"proxyUsername,omitempty"`
+	NoProxy              []string       `json:"noProxy,omitempty"`
+	VaultURL             string         `json:"vaultUrl,omitempty"`
+	VaultTTLInSeconds    int64          `json:"vaultTTLInSeconds,omitempty"`
+	VaultAllowedPaths    []string       `json:"vaultAllowedPaths,omitempty"`
 }
 
 func LoadSettings(config backend.DataSourceInstanceSettings) (settings InfinitySettings, err error) {
@@ -189,21 +248,48 @@ func LoadSettings(config backend.DataSourceInstanceSettings) (settings InfinityS
 		if len(infJson.AllowedHosts) > 0 {
 			settings.AllowedHosts = infJson.AllowedHosts
 		}
+		if len(infJson.DeniedHosts) > 0 {
+			settings.DeniedHosts = infJson.DeniedHosts
+		}
+		settings.BlockPrivateNetworks = infJson.BlockPrivateNetworks
 	}
 	settings.EnableOpenAPI = infJson.EnableOpenAPI
 	settings.OpenAPIVersion = infJson.OpenAPIVersion
 	settings.OpenAPIUrl = infJson.OpenAPIUrl
 	settings.OpenAPIBaseUrl = infJson.OpenAPIBaseUrl
 	settings.ReferenceData = infJson.ReferenceData
+	settings.CacheEnabled = infJson.CacheEnabled
+	settings.CacheTTLInSeconds = infJson.CacheTTLInSeconds
+	settings.CacheMaxSizeInMB = infJson.CacheMaxSizeInMB
+	settings.Retry = infJson.Retry
+	settings.MaxResponseSizeInMB = infJson.MaxResponseSizeInMB
+	settings.ProxyURL = infJson.ProxyURL
+	settings.ProxyUserName = infJson.ProxyUserName
+	settings.NoProxy = infJson.NoProxy
+	settings.VaultURL = infJson.VaultURL
+	settings.VaultTTLInSeconds = infJson.VaultTTLInSeconds
+	settings.VaultAllowedPaths = infJson.VaultAllowedPaths
 	if val, ok := config.DecryptedSecureJSONData["basicAuthPassword"]; ok {
 		settings.Password = val
 	}
+	if val, ok := config.DecryptedSecureJSONData["proxyPassword"]; ok {
+		settings.ProxyPassword = val
+	}
+	if val, ok := config.DecryptedSecureJSONData["vaultToken"]; ok {
+		settings.VaultToken = val
+	}
 	if val, ok := config.DecryptedSecureJSONData["oauth2ClientSecret"]; ok {
 		settings.OAuth2Settings.ClientSecret = val
 	}
 	if val, ok := config.DecryptedSecureJSONData["oauth2JWTPrivateKey"]; ok {
 		settings.OAuth2Settings.PrivateKey = val
 	}
+	if val, ok := config.DecryptedSecureJSONData["oauth2Password"]; ok {
+		settings.OAuth2Settings.Password = val
+	}
+	if val, ok := config.DecryptedSecureJSONData["oauth2RefreshToken"]; ok {
+		settings.OAuth2Settings.RefreshToken = val
+	}
 	if val, ok := config.DecryptedSecureJSONData["tlsCACert"]; ok {
 		settings.TLSCACert = val
 	}
@@ -222,6 +308,9 @@ func LoadSettings(config backend.DataSourceInstanceSettings) (settings InfinityS
 	if val, ok := config.DecryptedSecureJSONData["awsSecretKey"]; ok {
 		settings.AWSSecretKey = val
 	}
+	if val, ok := config.DecryptedSecureJSONData["awsSessionToken"]; ok {
+		settings.AWSSessionToken = val
+	}
 	settings.CustomHeaders = GetSecrets(config, "httpHeaderName", "httpHeaderValue")
 	settings.SecureQueryFields = GetSecrets(config, "secureQueryName", "secureQueryValue")
 	settings.OAuth2Settings.EndpointParams = GetSecrets(config, "oauth2EndPointParamsName", "oauth2EndPointParamsValue")
@@ -19,6 +19,9 @@ func (host *PluginHost) getRouter() *mux.Router {
 	router.HandleFunc("/reference-data", host.withDatasourceHandlerFunc(GetReferenceDataHandler)).Methods("GET")
 	router.HandleFunc("/open-api", host.withDatasourceHandlerFunc(GetOpenAPIHandler)).Methods("GET") // NOT IN USE YET
 	router.HandleFunc("/ping", host.withDatasourceHandlerFunc(GetPingHandler)).Methods("GET")
+	router.HandleFunc("/accounts", host.withDatasourceHandlerFunc(GetAccountsHandler)).Methods("GET")
+	router.HandleFunc("/cache/flush", host.withDatasourceHandlerFunc(FlushCacheHandler)).Methods("POST")
+	router.PathPrefix(cloudWatchResourcePrefix + "/").HandlerFunc(host.withDatasourceHandlerFunc(GetCloudWatchHandler))
 	router.NotFoundHandler = http.HandlerFunc(host.withDatasourceHandlerFunc(defaultHandler))
 	return router
 }
@@ -152,6 +155,23 @@ func GetPingHandler(client *instanceSettings) http.HandlerFunc {
 	}
 }
 
+// FlushCacheHandler removes the cached responses of the datasource
+func FlushCacheHandler(client *instanceSettings) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		flushed := 0
+		if client.client.Cache != nil {
+			flushed = client.client.Cache.Flush()
+		}
+		b, err := json.Marshal(map[string]any{"enabled": client.client.Cache != nil, "flushed": flushed})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rw, "%s", string(b))
+	}
+}
+
 func defaultHandler(client *instanceSettings) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		http.Error(rw, "not a known resource call", http.StatusInternalServerError)
@@ -2,12 +2,23 @@ package pluginhost
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch"
+	"github.com/appkube/cloud-datasource/pkg/infinity"
+	"github.com/appkube/cloud-datasource/pkg/infra/httpclient"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/appkube/cloud-datasource/pkg/pluginhost/health"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 // CheckHealth handles health checks sent from Grafana to the plugin.
+// Besides validating the settings it checks, stage by stage, that the CMDB, the vault, STS and the CloudWatch APIs
+// can be reached for the health check element, and that the base URL responds.
 func (ds *PluginHost) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	client, err := getInstance(ds.im, req.PluginContext)
 	if err != nil || client == nil || client.client == nil {
@@ -22,8 +33,111 @@ func (ds *PluginHost) CheckHealth(ctx context.Context, req *backend.CheckHealthR
 			Message: fmt.Sprintf("invalid settings. %s", err.Error()),
 		}, nil
 	}
+
+	report := health.NewReport()
+	checkCloudWatchHealth(ctx, client, req, report)
+	checkBaseURLHealth(ctx, client, report)
+
+	details, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	failed := report.Failed()
+	if len(failed) > 0 {
+		reasons := make([]string, 0, len(failed))
+		for _, stage := range failed {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", stage.Name, stage.Reason))
+		}
+		return &backend.CheckHealthResult{
+			Status:      backend.HealthStatusError,
+			Message:     fmt.Sprintf("%d of %d health checks failed. %s", len(failed), len(report.Stages), strings.Join(reasons, "; ")),
+			JSONDetails: details,
+		}, nil
+	}
 	return &backend.CheckHealthResult{
-		Status:  backend.HealthStatusOk,
-		Message: "OK",
+		Status:      backend.HealthStatusOk,
+		Message:     "OK",
+		JSONDetails: details,
 	}, nil
 }
+
+func checkCloudWatchHealth(ctx context.Context, client *instanceSettings, req *backend.CheckHealthRequest, report *health.Report) {
+	stages := []string{"cmdb", "vault", "sts", "cloudwatch-metrics", "cloudwatch-logs"}
+	elementId := client.cwSettings.HealthCheckElementId
+	if elementId <= 0 {
+		report.Skip("no health check element configured", stages...)
+		return
+	}
+
+	query := models.Query{Type: models.QueryTypeAppKubeCloudWatch, ElementId: elementId}
+	var cmdbResp *models.CmdbCloudElementResponse
+	var awsCreds *models.AwsCredential
+	var assumeRoleSession *session.Session
+	ok := report.Chain([]health.Stage{
+		{Name: "cmdb", Check: func() error {
+			resp, statusCode, _, err := getCmdbData(ctx, *client.client, query, req.Headers)
+			if err != nil {
+				return err
+			}
+			if statusCode/100 != 2 {
+				return fmt.Errorf("cmdb api failed. status: %d", statusCode)
+			}
+			if resp == nil {
+				return fmt.Errorf("cloud element %d not found in cmdb", elementId)
+			}
+			cmdbResp = resp
+			return nil
+		}},
+		{Name: "vault", Check: func() error {
+			creds, err := resolveLandingZoneAwsCreds(ctx, client, cmdbResp.LandingzoneId, query, req.Headers)
+			awsCreds = creds
+			return err
+		}},
+		{Name: "sts", Check: func() error {
+			sess, err := cloudwatch.NewAssumeRoleSession(awsCreds)
+			assumeRoleSession = sess
+			return err
+		}},
+	}, stages[3:]...)
+	if !ok {
+		return
+	}
+
+	cloudWatchService := cloudwatch.ProvideServiceWithSession(httpclient.NewProvider(), awsCreds, client.cwSettings, assumeRoleSession)
+	if cloudWatchService.Executor == nil {
+		report.Skip("error creating cloudwatch executor", stages[3:]...)
+		return
+	}
+	report.Run("cloudwatch-metrics", func() error {
+		return cloudWatchService.Executor.CheckHealthMetrics(req.PluginContext)
+	})
+	report.Run("cloudwatch-logs", func() error {
+		return cloudWatchService.Executor.CheckHealthLogs(req.PluginContext)
+	})
+}
+
+func checkBaseURLHealth(ctx context.Context, client *instanceSettings, report *health.Report) {
+	baseURL := client.client.Settings.URL
+	if baseURL == "" {
+		report.Skip("no base URL configured", "base-url")
+		return
+	}
+	report.Run("base-url", func() error {
+		if err := infinity.CheckURL(baseURL, client.client.Settings); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.client.HttpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("base URL responded with status %s", res.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package pluginhost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/appkube/cloud-datasource/pkg/cloudwatch"
+	"github.com/appkube/cloud-datasource/pkg/infra/httpclient"
+	"github.com/appkube/cloud-datasource/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const cloudWatchResourcePrefix = "/cloudwatch"
+
+// GetCloudWatchHandler serves the CloudWatch resource routes under /cloudwatch/*.
+// The AWS credentials are resolved from the CMDB and the vault, the same way as for queries, for the cloud element
+// given by the elementId parameter or directly for the landing zone given by the landingZoneId parameter.
+// Requests without a region use the region of the credentials.
+func GetCloudWatchHandler(client *instanceSettings) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		awsCreds, status, err := cloudWatchResourceCreds(r.Context(), client, r)
+		if err != nil {
+			http.Error(rw, err.Error(), status)
+			return
+		}
+
+		cloudWatchService := cloudwatch.ProvideService(httpclient.NewProvider(), awsCreds, client.cwSettings)
+		if cloudWatchService.Executor == nil {
+			backend.Logger.Error("error creating cloudwatch executor", "url", r.URL.Path)
+			http.Error(rw, "error creating aws session for the cloud element", http.StatusBadGateway)
+			return
+		}
+
+		parameters := r.URL.Query()
+		if region := parameters.Get("region"); region == "" || region == "default" {
+			parameters.Set("region", awsCreds.Region)
+			r.URL.RawQuery = parameters.Encode()
+		}
+		http.StripPrefix(cloudWatchResourcePrefix, cloudWatchService.Executor).ServeHTTP(rw, r)
+	}
+}
+
+// GetAccountsHandler lists the monitoring account and its linked source accounts usable in cross-account queries,
+// like /cloudwatch/accounts
+func GetAccountsHandler(client *instanceSettings) http.HandlerFunc {
+	cloudWatchHandler := GetCloudWatchHandler(client)
+	return func(rw http.ResponseWriter, r *http.Request) {
+		r.URL.Path = cloudWatchResourcePrefix + "/accounts"
+		r.URL.RawPath = ""
+		cloudWatchHandler(rw, r)
+	}
+}
+
+func cloudWatchResourceCreds(ctx context.Context, client *instanceSettings, r *http.Request) (*models.AwsCredential, int, error) {
+	parameters := r.URL.Query()
+	query := models.Query{Type: models.QueryTypeAppKubeCloudWatch}
+	headers := resourceRequestHeaders(r)
+
+	if elementId := parameters.Get("elementId"); elementId != "" {
+		id, err := strconv.ParseInt(elementId, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid elementId %q", elementId)
+		}
+		query.ElementId = id
+		awsCreds, err := resolveAwsCreds(ctx, client, query, headers)
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+		return awsCreds, http.StatusOK, nil
+	}
+
+	if landingZoneId := parameters.Get("landingZoneId"); landingZoneId != "" {
+		id, err := strconv.ParseInt(landingZoneId, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid landingZoneId %q", landingZoneId)
+		}
+		awsCreds, err := resolveLandingZoneAwsCreds(ctx, client, id, query, headers)
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+		return awsCreds, http.StatusOK, nil
+	}
+
+	return nil, http.StatusBadRequest, fmt.Errorf("elementId or landingZoneId is required")
+}
+
+// resourceRequestHeaders returns the forwarded identity of a resource call under the keys QueryData gets it, to pass
+// it on to the CMDB and the vault
+func resourceRequestHeaders(r *http.Request) map[string]string {
+	headers := map[string]string{}
+	for _, key := range []string{"Authorization", "X-ID-Token"} {
+		if value := r.Header.Get(key); value != "" {
+			headers[key] = value
+		}
+	}
+	return headers
+}
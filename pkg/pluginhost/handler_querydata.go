@@ -41,11 +41,34 @@ func (ds PluginHost) QueryData(ctx context.Context, req *backend.QueryDataReques
 
 		switch query.Type {
 		case models.QueryTypeAppKubeCloudWatch:
-			awsCreds, dataResponse, err2 := createAwsCreds(ctx, req, err, client, query, response)
-			if err2 != nil {
-				return dataResponse, err2
+			if _, done := response.Responses[q.RefID]; done {
+				// already answered together with the other log alert queries of the same element
+				continue
+			}
+			element, awsCreds, err := resolveElementAwsCreds(ctx, client, query, req.Headers)
+			if err != nil {
+				return response, err
+			}
+			var cloudWatchService = cloudwatch.ProvideService(httpclient.NewProvider(), awsCreds, client.cwSettings)
+			if cloudWatchService.Executor == nil {
+				return response, fmt.Errorf("error creating aws session for cloud element %d", query.ElementId)
+			}
+			if cloudwatch.IsCostQuery(q) {
+				cloudWatchService.Executor.SetElementTags(element.Tags())
+			}
+			if cloudwatch.IsLogAlertQuery(req, q) {
+				alertReq := *req
+				alertReq.Queries = logAlertQueriesForElement(req, query.ElementId)
+				res, err := cloudWatchService.Executor.QueryData(ctx, &alertReq, q, awsCreds.Region)
+				if err != nil {
+					backend.Logger.Error("error executing cloudwatch log alert query", "error", err.Error())
+					return response, fmt.Errorf("error executing cloudwatch log alert query. %w", err)
+				}
+				for refID, res := range res.Responses {
+					response.Responses[refID] = res
+				}
+				continue
 			}
-			var cloudWatchService = cloudwatch.ProvideService(httpclient.NewProvider(), awsCreds)
 			res, err := cloudWatchService.Executor.QueryData(ctx, req, q, awsCreds.Region)
 			if err != nil {
 				backend.Logger.Error("error executing cloudwatch query", "error", err.Error())
@@ -61,6 +84,22 @@ func (ds PluginHost) QueryData(ctx context.Context, req *backend.QueryDataReques
 	return response, nil
 }
 
+// logAlertQueriesForElement returns the log alert queries of req which target the given cloud element.
+// They share the element's credentials and are executed together.
+func logAlertQueriesForElement(req *backend.QueryDataRequest, elementId int64) []backend.DataQuery {
+	queries := make([]backend.DataQuery, 0)
+	for _, q := range req.Queries {
+		query, err := models.LoadQueryToIdentifyType(q)
+		if err != nil || query.Type != models.QueryTypeAppKubeCloudWatch || query.ElementId != elementId {
+			continue
+		}
+		if cloudwatch.IsLogAlertQuery(req, q) {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
 func QueryData(ctx context.Context, backendQuery backend.DataQuery, infClient infinity.Client, requestHeaders map[string]string, pluginContext backend.PluginContext) (response backend.DataResponse) {
 	//region Loading Query
 	query, err := models.LoadQuery(ctx, backendQuery, pluginContext)
@@ -163,7 +202,7 @@ func QueryData(ctx context.Context, backendQuery backend.DataQuery, infClient in
 				fmt.Println("creating frames....................................")
 				var frameLabels []string
 
-				frameLabels = getFrameNames(query.ElementType, query.QueryString)
+				frameLabels = getFrameNames(infClient.ProxyHttpClient, query.ElementType, query.QueryString)
 
 				//frameLabels = getFrameNames("cpu_usage_idle_panel")
 
@@ -265,6 +304,14 @@ func QueryData(ctx context.Context, backendQuery backend.DataQuery, infClient in
 		query, _ := infinity.UpdateQueryWithReferenceData(ctx, query, infClient.Settings)
 		switch query.Source {
 		case "url":
+			if usesLandingZoneAwsCreds(infClient.Settings) && query.ElementId > 0 {
+				awsCreds, err := resolveAwsCreds(ctx, &instanceSettings{client: unsignedClient(infClient)}, query, requestHeaders)
+				if err != nil {
+					response.Error = fmt.Errorf("error getting the aws credentials of the cloud element. %w", err)
+					return response
+				}
+				query.AWSCredentials = awsCreds
+			}
 			frame, err := infinity.GetFrameForURLSources(ctx, query, infClient, requestHeaders)
 			if err != nil {
 				frame, _ = infinity.WrapMetaForRemoteQuery(ctx, frame, err, query)
@@ -304,46 +351,95 @@ func QueryData(ctx context.Context, backendQuery backend.DataQuery, infClient in
 	return response
 }
 
-func createAwsCreds(ctx context.Context, req *backend.QueryDataRequest, err error, client *instanceSettings, query models.Query, response *backend.QueryDataResponse) (*models.AwsCredential, *backend.QueryDataResponse, error) {
-	cmdbResp, cmdbStatusCode, _, err := getCmdbData(ctx, *client.client, query, req.Headers)
+// usesLandingZoneAwsCreds tells whether URL queries are signed with the AWS credentials of the landing zone of their cloud element.
+func usesLandingZoneAwsCreds(settings models.InfinitySettings) bool {
+	return settings.AuthenticationMethod == models.AuthenticationMethodAWS && settings.AWSSettings.AuthType == models.AWSAuthTypeLandingZone
+}
+
+// unsignedClient returns a copy of the client without the AWS signing, to call the CMDB and the vault for the landing zone credentials.
+func unsignedClient(infClient infinity.Client) *infinity.Client {
+	infClient.Settings.AuthenticationMethod = models.AuthenticationMethodNone
+	infClient.Cache = nil
+	infClient.HttpClient = http.DefaultClient
+	if infClient.ProxyHttpClient != nil {
+		infClient.HttpClient = infClient.ProxyHttpClient
+	}
+	return &infClient
+}
+
+// resolveAwsCreds looks up the landing zone of the query's cloud element in the CMDB and fetches its AWS credentials from the vault.
+func resolveAwsCreds(ctx context.Context, client *instanceSettings, query models.Query, requestHeaders map[string]string) (*models.AwsCredential, error) {
+	_, awsCreds, err := resolveElementAwsCreds(ctx, client, query, requestHeaders)
+	return awsCreds, err
+}
+
+// resolveElementAwsCreds is resolveAwsCreds which also returns the cloud element found in the CMDB.
+func resolveElementAwsCreds(ctx context.Context, client *instanceSettings, query models.Query, requestHeaders map[string]string) (*models.CmdbCloudElementResponse, *models.AwsCredential, error) {
+	cmdbResp, cmdbStatusCode, _, err := getCmdbData(ctx, *client.client, query, requestHeaders)
 	if err != nil {
 		backend.Logger.Error("error in getting cmdb response", "error", err.Error())
-		return nil, response, fmt.Errorf("error in getting cmdb response. %w", err)
+		return nil, nil, fmt.Errorf("error in getting cmdb response. %w", err)
 	}
 	if cmdbStatusCode > http.StatusBadRequest {
-		backend.Logger.Error("cmdb api failed. status: "+strconv.Itoa(cmdbStatusCode), "error", err.Error())
-		return nil, response, fmt.Errorf("cmdb api failed. status: "+strconv.Itoa(cmdbStatusCode)+". %w", err)
+		backend.Logger.Error("cmdb api failed. status: " + strconv.Itoa(cmdbStatusCode))
+		return nil, nil, fmt.Errorf("cmdb api failed. status: %d", cmdbStatusCode)
 	}
-	vaultResp, vaultStatusCode, _, err := getAwsCredentials(cmdbResp.LandingzoneId, ctx, *client.client, query, req.Headers)
+	if cmdbResp == nil {
+		backend.Logger.Error("cloud element not found in cmdb", "elementId", query.ElementId)
+		return nil, nil, fmt.Errorf("cloud element %d not found in cmdb", query.ElementId)
+	}
+	awsCreds, err := resolveLandingZoneAwsCreds(ctx, client, cmdbResp.LandingzoneId, query, requestHeaders)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmdbResp, awsCreds, nil
+}
+
+// resolveLandingZoneAwsCreds fetches the AWS credentials of a landing zone from the vault.
+func resolveLandingZoneAwsCreds(ctx context.Context, client *instanceSettings, landingZoneId int64, query models.Query, requestHeaders map[string]string) (*models.AwsCredential, error) {
+	vaultResp, vaultStatusCode, _, err := getAwsCredentials(landingZoneId, ctx, *client.client, query, requestHeaders)
 	if err != nil {
 		backend.Logger.Error("error in getting aws credentials", "error", err.Error())
-		return nil, response, fmt.Errorf("error in getting aws credentials. %w", err)
+		return nil, fmt.Errorf("error in getting aws credentials. %w", err)
 	}
 	if vaultStatusCode/100 != 2 {
 		backend.Logger.Error("vault error", "error", vaultStatusCode)
-		return nil, response, fmt.Errorf("vault error. %w", vaultStatusCode)
+		return nil, fmt.Errorf("vault error. status: %d", vaultStatusCode)
 	}
 
 	vaultString, ok := vaultResp.(string)
 	if !ok {
 		backend.Logger.Error("vault response error", "error")
-		return nil, response, fmt.Errorf("vault response error.")
+		return nil, fmt.Errorf("vault response error.")
 	}
 	awsCreds := &models.AwsCredential{}
 	err = json.Unmarshal([]byte(vaultString), &awsCreds)
 	if err != nil {
 		backend.Logger.Error("error un-marshaling the vault response", "error", err.Error())
-		return nil, response, fmt.Errorf("error un-marshaling the vault response. %w", err)
+		return nil, fmt.Errorf("error un-marshaling the vault response. %w", err)
+	}
+	return awsCreds, nil
+}
+
+// internalQuery is the query of a CMDB or vault lookup. The options of the user's query, like its pagination, retries
+// or debug capture, don't apply to these calls.
+func internalQuery(url string) models.Query {
+	return models.Query{
+		Type:       models.QueryTypeAppKubeAPI,
+		Source:     "url",
+		URL:        url,
+		URLOptions: models.URLOptions{Method: http.MethodGet},
 	}
-	query.Region = awsCreds.Region
-	return awsCreds, nil, nil
 }
+
 func getCmdbData(ctx context.Context, infClient infinity.Client, query models.Query, requestHeaders map[string]string) (o *models.CmdbCloudElementResponse, statusCode int, duration time.Duration, err error) {
 	fmt.Println("Query CMDB to get landing zone")
-	query.URL = "http://34.199.12.114:6057/api/cloud-element/search?id=" + strconv.Itoa(int(query.ElementId))
-	backend.Logger.Info("CMDB URL: " + query.URL)
+	cmdbQuery := internalQuery("http://34.199.12.114:6057/api/cloud-element/search?id=" + strconv.Itoa(int(query.ElementId)))
+	backend.Logger.Info("CMDB URL: " + cmdbQuery.URL)
 
-	cmdbResp, cmdbStatusCode, duration, err := infClient.GetResults(ctx, query, requestHeaders)
+	// the cloud element and the credentials are always read fresh, never from the response cache
+	infClient.Cache = nil
+	cmdbResp, cmdbStatusCode, duration, err := infClient.GetResults(ctx, cmdbQuery, requestHeaders)
 	if err != nil {
 		backend.Logger.Error("CMDB call failed. Error: ", "error", err.Error())
 		return nil, cmdbStatusCode, duration, err
@@ -363,12 +459,13 @@ func getCmdbData(ctx context.Context, infClient infinity.Client, query models.Qu
 }
 func getAwsCredentials(landingZoneId int64, ctx context.Context, infClient infinity.Client, query models.Query, requestHeaders map[string]string) (o any, statusCode int, duration time.Duration, err error) {
 	fmt.Println("Query vault to get aws credentials")
-	query.URL = "http://34.199.12.114:6057/api/landingzone/cloud-creds?landingZoneId=" + strconv.Itoa(int(landingZoneId))
-	backend.Logger.Info("VAULT URL: " + query.URL)
-	return infClient.GetResults(ctx, query, requestHeaders)
+	vaultQuery := internalQuery("http://34.199.12.114:6057/api/landingzone/cloud-creds?landingZoneId=" + strconv.Itoa(int(landingZoneId)))
+	backend.Logger.Info("VAULT URL: " + vaultQuery.URL)
+	infClient.Cache = nil
+	return infClient.GetResults(ctx, vaultQuery, requestHeaders)
 }
 
-func getFrameNames(elementType string, query string) []string {
+func getFrameNames(httpClient *http.Client, elementType string, query string) []string {
 	type FrameInfo struct {
 		Frames interface{} `json:"frames"`
 	}
@@ -378,8 +475,11 @@ func getFrameNames(elementType string, query string) []string {
 	//baseURL := "http://localhost:6057/api/cloud-element-supported-api/search"
 	cmdURL := baseURL + "?name=" + query + "&elementType=" + elementType
 
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
 	// Perform HTTP GET request
-	response, err := http.Get(cmdURL)
+	response, err := httpClient.Get(cmdURL)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return nil
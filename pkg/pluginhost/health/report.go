@@ -0,0 +1,85 @@
+package health
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stage statuses
+const (
+	StatusOk      = "ok"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// Stage is a named check of the health check
+type Stage struct {
+	Name  string
+	Check func() error
+}
+
+// StageResult is the result of one stage of the health check, reported in the JSONDetails of the result
+type StageResult struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Report collects the results of the stages in the order they ran or were skipped
+type Report struct {
+	Stages []StageResult `json:"stages"`
+}
+
+// NewReport returns an empty report
+func NewReport() *Report {
+	return &Report{Stages: make([]StageResult, 0)}
+}
+
+// Run runs the check of the stage and reports whether it passed
+func (r *Report) Run(name string, check func() error) bool {
+	start := time.Now()
+	err := check()
+	stage := StageResult{Name: name, Status: StatusOk, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		stage.Status = StatusError
+		stage.Reason = err.Error()
+	}
+	r.Stages = append(r.Stages, stage)
+	return err == nil
+}
+
+// Skip reports the stages as skipped for the reason
+func (r *Report) Skip(reason string, names ...string) {
+	for _, name := range names {
+		r.Stages = append(r.Stages, StageResult{Name: name, Status: StatusSkipped, Reason: reason})
+	}
+}
+
+// Chain runs the stages in order, each depending on the ones before it.
+// The first failing stage skips the rest of the chain and the dependents.
+func (r *Report) Chain(stages []Stage, dependents ...string) bool {
+	for i, stage := range stages {
+		if r.Run(stage.Name, stage.Check) {
+			continue
+		}
+		skipped := make([]string, 0, len(stages)-i-1+len(dependents))
+		for _, next := range stages[i+1:] {
+			skipped = append(skipped, next.Name)
+		}
+		r.Skip(fmt.Sprintf("%s check failed", stage.Name), append(skipped, dependents...)...)
+		return false
+	}
+	return true
+}
+
+// Failed returns the stages which failed
+func (r *Report) Failed() []StageResult {
+	failed := make([]StageResult, 0)
+	for _, stage := range r.Stages {
+		if stage.Status == StatusError {
+			failed = append(failed, stage)
+		}
+	}
+	return failed
+}
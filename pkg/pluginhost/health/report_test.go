@@ -0,0 +1,84 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	pass := func() error { return nil }
+	names := func(report *Report) []string {
+		names := []string{}
+		for _, stage := range report.Stages {
+			names = append(names, stage.Name+":"+stage.Status)
+		}
+		return names
+	}
+
+	t.Run("stages are reported in the order they ran", func(t *testing.T) {
+		report := NewReport()
+		ran := []string{}
+		check := func(name string) func() error {
+			return func() error {
+				ran = append(ran, name)
+				return nil
+			}
+		}
+		ok := report.Chain([]Stage{{Name: "cmdb", Check: check("cmdb")}, {Name: "vault", Check: check("vault")}, {Name: "sts", Check: check("sts")}})
+		require.True(t, ok)
+		report.Run("cloudwatch-metrics", check("cloudwatch-metrics"))
+		report.Skip("no base URL configured", "base-url")
+		assert.Equal(t, []string{"cmdb", "vault", "sts", "cloudwatch-metrics"}, ran)
+		assert.Equal(t, []string{"cmdb:ok", "vault:ok", "sts:ok", "cloudwatch-metrics:ok", "base-url:skipped"}, names(report))
+		assert.Empty(t, report.Failed())
+	})
+	t.Run("a failing stage skips the stages depending on it", func(t *testing.T) {
+		report := NewReport()
+		stsRan := false
+		ok := report.Chain([]Stage{
+			{Name: "cmdb", Check: pass},
+			{Name: "vault", Check: func() error { return errors.New("secret not found") }},
+			{Name: "sts", Check: func() error { stsRan = true; return nil }},
+		}, "cloudwatch-metrics", "cloudwatch-logs")
+		require.False(t, ok)
+		assert.False(t, stsRan)
+		assert.Equal(t, []string{"cmdb:ok", "vault:error", "sts:skipped", "cloudwatch-metrics:skipped", "cloudwatch-logs:skipped"}, names(report))
+		for _, stage := range report.Stages[2:] {
+			assert.Equal(t, "vault check failed", stage.Reason)
+		}
+		failed := report.Failed()
+		require.Len(t, failed, 1)
+		assert.Equal(t, "vault", failed[0].Name)
+		assert.Equal(t, "secret not found", failed[0].Reason)
+	})
+	t.Run("a failing independent stage doesn't skip the others", func(t *testing.T) {
+		report := NewReport()
+		assert.False(t, report.Run("cloudwatch-metrics", func() error { return errors.New("access denied") }))
+		assert.True(t, report.Run("cloudwatch-logs", pass))
+		assert.Equal(t, []string{"cloudwatch-metrics:error", "cloudwatch-logs:ok"}, names(report))
+	})
+	t.Run("json details", func(t *testing.T) {
+		report := NewReport()
+		report.Run("cmdb", pass)
+		report.Run("vault", func() error { return errors.New("secret not found") })
+		report.Skip("vault check failed", "sts")
+		for i := range report.Stages {
+			report.Stages[i].LatencyMs = int64(i)
+		}
+		details, err := json.Marshal(report)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"stages":[
+			{"name":"cmdb","status":"ok","latencyMs":0},
+			{"name":"vault","status":"error","latencyMs":1,"reason":"secret not found"},
+			{"name":"sts","status":"skipped","latencyMs":2,"reason":"vault check failed"}
+		]}`, string(details))
+
+		details, err = json.Marshal(NewReport())
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"stages":[]}`, string(details))
+	})
+}
@@ -3,6 +3,7 @@ package pluginhost
 import (
 	"net/http"
 
+	cwmodels "github.com/appkube/cloud-datasource/pkg/cloudwatch/models"
 	"github.com/appkube/cloud-datasource/pkg/infinity"
 	"github.com/appkube/cloud-datasource/pkg/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -27,7 +28,8 @@ func NewDatasource() datasource.ServeOpts {
 }
 
 type instanceSettings struct {
-	client *infinity.Client
+	client     *infinity.Client
+	cwSettings cwmodels.CloudWatchSettings
 }
 
 func (is *instanceSettings) Dispose() {}
@@ -41,8 +43,27 @@ func newDataSourceInstance(setting backend.DataSourceInstanceSettings) (instance
 	if err != nil {
 		return nil, err
 	}
+	cwSettings, err := cwmodels.LoadCloudWatchSettings(setting)
+	if err != nil {
+		// the infinity queries don't need the cloudwatch settings, so the instance continues with the defaults
+		backend.Logger.Error("error loading the cloudwatch settings, using the defaults", "error", err.Error())
+		defaults := setting
+		defaults.JSONData = nil
+		cwSettings, _ = cwmodels.LoadCloudWatchSettings(defaults)
+	}
+	if catalogURL := cwSettings.MetricCatalogOptions().URL; catalogURL != "" {
+		// the catalog is fetched with the host restrictions of the datasource, but without its authentication. A URL
+		// which isn't allowed falls back to the embedded catalog.
+		if err := infinity.CheckURL(catalogURL, settings); err != nil {
+			backend.Logger.Error("metric catalog URL not allowed, using the embedded catalog", "error", err.Error())
+			cwSettings.MetricCatalogURL = ""
+		} else if cwSettings.MetricCatalogHTTPClient, err = infinity.NewHTTPClient(settings); err != nil {
+			return nil, err
+		}
+	}
 	return &instanceSettings{
-		client: client,
+		client:     client,
+		cwSettings: cwSettings,
 	}, nil
 }
 